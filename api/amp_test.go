@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerPreferAMP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page string
+		switch {
+		case r.Host == "amp.example.test" && r.URL.Path == "/post":
+			page = `<html><head><title>AMP</title></head><body><p>AMP body</p></body></html>`
+		case r.URL.Path == "/post":
+			page = `<html><head><title>Original</title><link rel="amphtml" href="http://amp.example.test/post"></head><body><p>Original body</p></body></html>`
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	// links to the loopback test server are refused, so the AMP version has a host name
+	oldClient := article.HTTPClient
+	article.HTTPClient = &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}}}
+	defer func() { article.HTTPClient = oldClient }()
+
+	get := func(query string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=json&"+query+"&url="+url.QueryEscape(srv.URL+"/post"), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %q", query, rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return rec, resp
+	}
+
+	rec, resp := get("prefer=amp")
+	if resp["title"] != "AMP" || resp["amp_url"] != "http://amp.example.test/post" {
+		t.Errorf("with prefer=amp: title = %v, amp_url = %v; want the AMP version", resp["title"], resp["amp_url"])
+	}
+	if header := rec.Header().Get("X-AMP-URL"); header != "http://amp.example.test/post" {
+		t.Errorf("X-AMP-URL = %q", header)
+	}
+
+	rec, resp = get("")
+	if resp["title"] != "Original" || resp["amp_url"] != nil || rec.Header().Get("X-AMP-URL") != "" {
+		t.Errorf("without prefer: title = %v, amp_url = %v; want the original page", resp["title"], resp["amp_url"])
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerAntibotChallenge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cf-Mitigated", "challenge")
+		w.WriteHeader(http.StatusForbidden)
+		if _, err := w.Write([]byte(`<html><head><title>Just a moment...</title></head><body>Checking your browser</body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+"/post"), nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d; want 502", rec.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["code"] != "BLOCKED_BY_ANTIBOT" || !strings.Contains(body["error"], "Cloudflare") {
+		t.Errorf("error body = %v", body)
+	}
+}
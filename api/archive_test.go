@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+// newArchiveServer serves origin under /origin/ next to a fake Wayback Machine
+// (with a snapshot of every origin page when archived is set) and points the fallback at it.
+func newArchiveServer(t *testing.T, origin http.HandlerFunc, archived bool) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/origin/"):
+			origin(w, r)
+		case r.URL.Path == "/available":
+			closest := map[string]any{"available": true, "status": "200", "timestamp": "20240102030405", "url": "ignored"}
+			if !archived || !strings.HasPrefix(r.URL.Query().Get("url"), srv.URL+"/origin/") {
+				closest = nil
+			}
+			if err := json.NewEncoder(w).Encode(map[string]any{"archived_snapshots": map[string]any{"closest": closest}}); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		case strings.HasPrefix(r.URL.Path, "/web/20240102030405id_/"):
+			if _, err := w.Write([]byte("<html><head><title>Archived copy</title></head><body><p>Archived body</p></body></html>")); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	oldClient, oldAvailability, oldSnapshot := article.HTTPClient, article.WaybackAvailabilityURL, article.WaybackSnapshotURL
+	article.HTTPClient, article.WaybackAvailabilityURL, article.WaybackSnapshotURL = srv.Client(), srv.URL+"/available", srv.URL+"/web/"
+	t.Cleanup(func() {
+		article.HTTPClient, article.WaybackAvailabilityURL, article.WaybackSnapshotURL = oldClient, oldAvailability, oldSnapshot
+	})
+	return srv
+}
+
+func TestHandlerArchiveFallback(t *testing.T) {
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		if _, err := w.Write([]byte("<html><head><title>Not found</title></head><body><p>Gone</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}
+	paywalled := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`<html><head><title>Teaser</title><script type="application/ld+json">{"@type":"NewsArticle","isAccessibleForFree":false}</script></head><body><p>Subscribe</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}
+
+	get := func(srv *httptest.Server, query string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=json&"+query+"&url="+url.QueryEscape(srv.URL+"/origin/post"), nil))
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v (%q)", err, rec.Body.String())
+		}
+		return rec, resp
+	}
+
+	for name, origin := range map[string]http.HandlerFunc{"not found": notFound, "paywalled": paywalled} {
+		t.Run(name, func(t *testing.T) {
+			srv := newArchiveServer(t, origin, true)
+			rec, resp := get(srv, "fallback=archive")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+			}
+			if resp["title"] != "Archived copy" {
+				t.Errorf("title = %v; want the archived page", resp["title"])
+			}
+			archive, _ := resp["archive"].(map[string]any)
+			if archive["timestamp"] != "2024-01-02T03:04:05Z" || !strings.HasSuffix(archive["url"].(string), "/web/20240102030405id_/"+srv.URL+"/origin/post") {
+				t.Errorf("archive = %v", resp["archive"])
+			}
+			if snapshot := rec.Header().Get("X-Archive-Snapshot"); snapshot != archive["url"] {
+				t.Errorf("X-Archive-Snapshot = %q", snapshot)
+			}
+
+			if _, resp := get(srv, ""); resp["archive"] != nil {
+				t.Errorf("archive used without ?fallback=archive: %v", resp)
+			}
+		})
+	}
+
+	t.Run("not archived", func(t *testing.T) {
+		srv := newArchiveServer(t, notFound, false)
+		rec, resp := get(srv, "fallback=archive")
+		if rec.Code != http.StatusBadGateway || resp["code"] != "UPSTREAM_ERROR" {
+			t.Errorf("status = %d, body = %v; want 502 UPSTREAM_ERROR", rec.Code, resp)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?fallback=cache&url=example.com", nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d; want 400", rec.Code)
+		}
+	})
+}
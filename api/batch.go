@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/formatter"
+	reqlog "github.com/lucasew/readability-web/internal/log"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/middleware"
+	"github.com/lucasew/readability-web/internal/response"
+)
+
+const (
+	// maxBatchItems caps how many URLs a single batch request may ask for.
+	maxBatchItems = 20
+	// batchWorkers is how many batch items are fetched and rendered at once.
+	batchWorkers = 4
+	// maxBatchBodySize caps the JSON payload of a batch request.
+	maxBatchBodySize = int64(64 * 1024)
+)
+
+/**
+ * BatchHandler is the Vercel Serverless Function serving `POST /api/batch`.
+ *
+ * It extracts several articles in one request, for pipelines that would otherwise
+ * call Handler in a loop. The body is a JSON object:
+ *
+ *	{"format": "md", "urls": ["https://...", {"url": "https://...", "format": "json"}]}
+ *
+ * Items are plain URLs or objects overriding the batch format (which defaults to the
+ * `?format=` parameter, then html). Every other option is taken from the query string
+ * and applies to all items. Items are processed in parallel, and the response lists
+ * their results in input order (see formatter.BatchResult).
+ *
+ * Batches in the instapaper format return a single reading list instead, linking
+ * every item that was extracted in that format. With `?batch-format=multipart`,
+ * results are streamed as the parts of a multipart/mixed response instead of a
+ * JSON array (see formatter.WriteBatchMultipart).
+ */
+func BatchHandler(w http.ResponseWriter, r *http.Request) {
+	accessLogMiddleware(versionMiddleware(securityHeadersMiddleware(middleware.RateLimit(requestLimiter, http.HandlerFunc(batchHandler))))).ServeHTTP(w, r)
+}
+
+/**
+ * batchRequest is the JSON payload of BatchHandler.
+ */
+type batchRequest struct {
+	Format string      `json:"format"`
+	URLs   []batchItem `json:"urls"`
+}
+
+/**
+ * batchItem is a URL to extract, with an optional per-item format.
+ * It decodes from either a string or a {"url", "format"} object.
+ */
+type batchItem struct {
+	URL    string `json:"url"`
+	Format string `json:"format,omitempty"`
+}
+
+func (b *batchItem) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &b.URL)
+	}
+	type plain batchItem // avoids recursing into this method
+	return json.Unmarshal(data, (*plain)(b))
+}
+
+/**
+ * batchHandler validates the batch payload and renders its items with a bounded worker pool.
+ */
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		response.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req batchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+	if err := dec.Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "expected a JSON body with a urls array")
+		return
+	}
+	if len(req.URLs) == 0 {
+		response.Error(w, http.StatusBadRequest, "urls must not be empty")
+		return
+	}
+	if len(req.URLs) > maxBatchItems {
+		response.Error(w, http.StatusBadRequest, fmt.Sprintf("at most %d urls per batch", maxBatchItems))
+		return
+	}
+
+	batchFormat := cmp.Or(r.URL.Query().Get("batch-format"), "json")
+	if batchFormat != "json" && batchFormat != "multipart" {
+		response.Error(w, http.StatusBadRequest, "batch-format must be json or multipart")
+		return
+	}
+
+	timeout, err := parseTimeout(r.URL.Query().Get("timeout"), maxFetchTimeout())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fetchOpts, err := parseFetchOptions(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defaultFormat := cmp.Or(req.Format, getFormat(r))
+	reqlog.FromContext(r.Context()).Format = defaultFormat
+
+	results := make([]formatter.BatchResult, len(req.URLs))
+	sem := make(chan struct{}, batchWorkers)
+	var wg sync.WaitGroup
+	for i, item := range req.URLs {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = renderBatchItem(r, item, cmp.Or(item.Format, defaultFormat), timeout, fetchOpts)
+		})
+	}
+	wg.Wait()
+
+	if defaultFormat == "instapaper" {
+		var entries []formatter.ReadingListEntry
+		for _, result := range results {
+			if result.Entry != nil {
+				entries = append(entries, *result.Entry)
+			}
+		}
+		formatter.WriteReadingList(w, entries)
+		return
+	}
+
+	if batchFormat == "multipart" {
+		formatter.WriteBatchMultipart(w, results)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"results": results}); err != nil {
+		log.Printf("error encoding batch response: %v", err)
+	}
+}
+
+/**
+ * renderBatchItem fetches and renders a single batch item. Like Handler it reads
+ * the article cache first and fetches through fetchArticle otherwise, so items
+ * share fetches and cached pages with the article requests.
+ */
+func renderBatchItem(r *http.Request, item batchItem, format string, timeout time.Duration, fetchOpts article.Options) formatter.BatchResult {
+	result := formatter.BatchResult{URL: item.URL, Format: format}
+	fail := func(status int, msg string) formatter.BatchResult {
+		result.Status = status
+		result.Error = msg
+		return result
+	}
+
+	opts, err := parseFormatOptions(r, format)
+	if err != nil {
+		return fail(http.StatusBadRequest, err.Error())
+	}
+	link, err := normalizeAndValidateURL(item.URL)
+	if err != nil {
+		return fail(http.StatusBadRequest, "Invalid URL provided")
+	}
+	opts.Link = link
+	result.Link = link
+
+	key := articleCacheKey(link, r)
+	fetched, cached := articleCacheStore.Get(key)
+	if !cached {
+		// each item gets its own reqlog.LogContext, as fetches record their timings there
+		ctx := reqlog.NewContext(r.Context(), &reqlog.LogContext{ArticleURL: link.String(), Format: format})
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		fetched, err = fetchArticle(ctx, key, link, r, fetchOpts)
+		if errors.Is(err, article.ErrJSRenderedPage) {
+			return fail(http.StatusUnprocessableEntity, err.Error())
+		}
+		if errors.Is(err, article.ErrRobotsDisallowed) {
+			return fail(http.StatusForbidden, err.Error())
+		}
+		var antibotErr *article.AntibotError
+		if errors.As(err, &antibotErr) {
+			return fail(http.StatusBadGateway, err.Error())
+		}
+		var statusErr *article.UpstreamStatusError
+		if errors.As(err, &statusErr) {
+			return fail(http.StatusBadGateway, err.Error()+" and no archived copy was found")
+		}
+		if err != nil {
+			log.Printf("error fetching or parsing batch URL %q: %v", item.URL, err)
+			return fail(http.StatusUnprocessableEntity, "Failed to process URL")
+		}
+	}
+
+	if format == "instapaper" {
+		entryOpts := opts
+		if fetched.Document != nil {
+			entryOpts.Canonical = meta.ExtractCanonicalURL(fetched.Document, link)
+		}
+		entry := formatter.NewReadingListEntry(fetched.Article, entryOpts)
+		result.Entry = &entry
+	}
+
+	rec := response.NewBuffered()
+	renderArticle(rec, r, format, fetched, opts)
+	result.Status = rec.Status
+	result.ContentType = rec.Header().Get("Content-Type")
+	result.Content = rec.Body.String()
+	return result
+}
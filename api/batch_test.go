@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/formatter"
+)
+
+func TestBatchHandler(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		page := fmt.Sprintf("<html><head><title>Page %s</title></head><body><article><h1>Page %[1]s</h1><p>%s</p></article></body></html>",
+			strings.TrimPrefix(r.URL.Path, "/"), strings.Repeat("Batch article body text. ", 10))
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	formats := []string{"md", "json", "text", "html", "md", "json", "text", "html"}
+	var items []string
+	for i, format := range formats {
+		items = append(items, fmt.Sprintf(`{"url": %q, "format": %q}`, fmt.Sprintf("%s/%d", srv.URL, i), format))
+	}
+	items = append(items, fmt.Sprintf("%q", srv.URL+"/default"), `{"url": "ftp://example.com"}`, `{"url": "example.com", "format": "bogus"}`)
+	body := `{"format": "text", "urls": [` + strings.Join(items, ",") + `]}`
+
+	rec := httptest.NewRecorder()
+	BatchHandler(rec, httptest.NewRequest("POST", "/api/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results []formatter.BatchResult `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(resp.Results) != len(formats)+3 {
+		t.Fatalf("got %d results; want %d", len(resp.Results), len(formats)+3)
+	}
+
+	contentTypes := map[string]string{"md": "text/markdown", "json": "application/json", "text": "text/plain", "html": "text/html"}
+	for i, format := range formats {
+		res := resp.Results[i]
+		if res.URL != fmt.Sprintf("%s/%d", srv.URL, i) || res.Format != format {
+			t.Errorf("result %d is %s as %s; results must keep input order", i, res.URL, res.Format)
+		}
+		if res.Status != http.StatusOK || !strings.HasPrefix(res.ContentType, contentTypes[format]) {
+			t.Errorf("result %d: status = %d, content type = %q; want %s", i, res.Status, res.ContentType, contentTypes[format])
+		}
+		if !strings.Contains(res.Content, "Batch article body text.") {
+			t.Errorf("result %d: content missing article text: %q", i, res.Content)
+		}
+	}
+	var jsonItem map[string]any
+	if err := json.Unmarshal([]byte(resp.Results[1].Content), &jsonItem); err != nil || jsonItem["title"] != "Page 1" {
+		t.Errorf("json item content = %q (%v)", resp.Results[1].Content, err)
+	}
+	if strings.Contains(resp.Results[0].Content, "<p>") {
+		t.Errorf("md item returned HTML: %q", resp.Results[0].Content)
+	}
+
+	if res := resp.Results[len(formats)]; res.Format != "text" || res.Status != http.StatusOK {
+		t.Errorf("plain URL item should use the batch format: %+v", res)
+	}
+	if res := resp.Results[len(formats)+1]; res.Status != http.StatusBadRequest || res.Error == "" {
+		t.Errorf("invalid URL item: %+v", res)
+	}
+	if res := resp.Results[len(formats)+2]; res.Status != http.StatusBadRequest || res.Error != "invalid format" {
+		t.Errorf("invalid format item: %+v", res)
+	}
+
+	if p := peak.Load(); p < 2 || p > batchWorkers {
+		t.Errorf("peak concurrent fetches = %d; want parallel rendering bounded by %d workers", p, batchWorkers)
+	}
+}
+
+func TestBatchHandlerSharesArticleCache(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		page := "<html><head><title>Shared</title></head><body><article><p>" + strings.Repeat("Shared article body text. ", 10) + "</p></article></body></html>"
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+	defer waitForFlights(t)
+
+	body := fmt.Sprintf(`{"urls": [{"url": %q, "format": "md"}, {"url": %[1]q, "format": "zip"}]}`, srv.URL+"/shared")
+	rec := httptest.NewRecorder()
+	BatchHandler(rec, httptest.NewRequest("POST", "/api/batch", strings.NewReader(body)))
+	var resp struct {
+		Results []formatter.BatchResult `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Results) != 2 {
+		t.Fatalf("invalid batch response (%v): %s", err, rec.Body.String())
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("upstream hits for two items of one URL = %d; want 1", got)
+	}
+
+	zipped := resp.Results[1]
+	if zipped.Status != http.StatusOK || zipped.ContentEncoding != "base64" {
+		t.Fatalf("zip item: status = %d, content_encoding = %q", zipped.Status, zipped.ContentEncoding)
+	}
+	data, err := base64.StdEncoding.DecodeString(zipped.Content)
+	if err != nil || !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		t.Errorf("zip item content is not a base64-encoded zip archive (%v): %.40q", err, zipped.Content)
+	}
+	if md := resp.Results[0]; md.ContentEncoding != "" || !strings.Contains(md.Content, "Shared article body text.") {
+		t.Errorf("md item: content_encoding = %q, content = %q", md.ContentEncoding, md.Content)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=md&url="+srv.URL+"/shared", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "HIT" || hits.Load() != 1 {
+		t.Errorf("article request after the batch: status = %d, X-Cache = %q, upstream hits = %d", rec.Code, rec.Header().Get("X-Cache"), hits.Load())
+	}
+}
+
+func TestBatchHandlerRejectsInvalidPayloads(t *testing.T) {
+	tooMany := `{"urls": [` + strings.TrimSuffix(strings.Repeat(`"example.com",`, maxBatchItems+1), ",") + `]}`
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"GET", "GET", "", http.StatusMethodNotAllowed},
+		{"not JSON", "POST", "urls=example.com", http.StatusBadRequest},
+		{"empty", "POST", `{"urls": []}`, http.StatusBadRequest},
+		{"too many", "POST", tooMany, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		BatchHandler(rec, httptest.NewRequest(tt.method, "/api/batch", strings.NewReader(tt.body)))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d; want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestBatchHandlerRejectsUnknownBatchFormat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	BatchHandler(rec, httptest.NewRequest("POST", "/api/batch?batch-format=xml", strings.NewReader(`{"urls": ["example.com"]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+// TestMain disables the article cache so tests hitting Handler always fetch
+// from their own fixture servers (whose ports may be reused between tests).
+// Cache tests install their own cache.
+func TestMain(m *testing.M) {
+	articleCacheStore = article.NewCacheStore(0, cacheTTL)
+	os.Exit(m.Run())
+}
+
+func useArticleCache(t *testing.T, size int, ttl time.Duration) {
+	t.Helper()
+	old := articleCacheStore
+	articleCacheStore = article.NewCacheStore(size, ttl)
+	t.Cleanup(func() { articleCacheStore = old })
+}
+
+// waitForFlights waits until no coalesced fetch is running: they outlive the
+// requests that started them, so tests must wait before restoring the globals
+// (such as article.HTTPClient) the fetches read.
+func waitForFlights(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		articleFlights.mu.Lock()
+		running := len(articleFlights.calls)
+		articleFlights.mu.Unlock()
+		if running == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d coalesced fetches still running", running)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandlerCustomCacheKey(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title := strings.TrimPrefix(r.URL.Path, "/")
+		if _, err := w.Write([]byte("<html><head><title>" + title + "</title></head><body><p>Body</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	get := func(path, key string) (string, string) {
+		req := httptest.NewRequest("GET", "/api?format=json&cache-key="+key+"&url="+url.QueryEscape(srv.URL+path), nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d; body: %q", path, rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return resp["title"].(string), rec.Header().Get("X-Cache")
+	}
+
+	if title, cache := get("/first", "shared-key.v1"); title != "first" || cache != "MISS" {
+		t.Errorf("first request = (%q, %q); want (first, MISS)", title, cache)
+	}
+	if title, cache := get("/second", "shared-key.v1"); title != "first" || cache != "HIT" {
+		t.Errorf("second request with the same key = (%q, %q); want cached (first, HIT)", title, cache)
+	}
+	if title, cache := get("/second", "other-key"); title != "second" || cache != "MISS" {
+		t.Errorf("request with another key = (%q, %q); want (second, MISS)", title, cache)
+	}
+}
+
+func TestHandlerRejectsInvalidCacheKey(t *testing.T) {
+	for _, key := range []string{"has%20space", "slash%2Fkey", strings.Repeat("k", 257)} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?url=example.com&cache-key="+key, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("cache-key %q: status = %d; want %d", key, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestWarmRenderer(t *testing.T) {
+	c := article.NewCacheStore(10, time.Minute)
+	link, _ := url.Parse("https://example.com/warm")
+	fetched := article.FetchResult{Article: testutil.ArticleFromFixture(t, "testdata/warm.html")}
+	c.Add("warm", fetched)
+	article.WarmCache("warm", fetched, c, warmRenderer(link))
+
+	for format, contentType := range map[string]string{
+		"html": "text/html",
+		"md":   "text/markdown",
+		"json": "application/json",
+		"text": "text/plain",
+	} {
+		rendered, found := c.Rendered("warm", format)
+		if !found {
+			t.Errorf("%s rendering not cached", format)
+			continue
+		}
+		if ct := rendered.Header().Get("Content-Type"); !strings.HasPrefix(ct, contentType) {
+			t.Errorf("%s rendering Content-Type = %q; want %s", format, ct, contentType)
+		}
+		if !strings.Contains(rendered.Body.String(), "Warm body") {
+			t.Errorf("%s rendering misses the content: %q", format, rendered.Body.String())
+		}
+	}
+}
+
+func TestHandlerServesWarmRendering(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(`<html><head><title>Warm</title></head><body><p>Body</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	link, _ := url.Parse(srv.URL + "/warm")
+	articleCacheStore.Add(link.String(), article.FetchResult{})
+	rendered := response.NewBuffered()
+	rendered.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rendered.Body.WriteString(`<style nonce="` + warmNonce + `"></style>pre-rendered`)
+	articleCacheStore.SetRendered(link.String(), "html", rendered)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=html&url="+url.QueryEscape(link.String())+query, nil))
+		return rec
+	}
+	rec := get("")
+	if !strings.HasSuffix(rec.Body.String(), "pre-rendered") {
+		t.Fatalf("default request not served from the warm rendering: %q", rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), warmNonce) {
+		t.Error("placeholder nonce leaked into the response")
+	}
+	if policy := rec.Header().Get("Content-Security-Policy"); !strings.Contains(rec.Body.String(), `nonce="`) || !strings.Contains(policy, "nonce-") {
+		t.Errorf("response nonce not filled in: body %q, policy %q", rec.Body.String(), policy)
+	}
+	if rec := get("&theme=dark"); strings.HasSuffix(rec.Body.String(), "pre-rendered") {
+		t.Error("requests with render options must not use the warm rendering")
+	}
+}
+
+func TestHandlerCoalescesConcurrentFetches(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+	var mu sync.Mutex
+	requests := 0
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		<-release
+		if _, err := w.Write([]byte(`<html><head><title>Popular</title></head><body><p>Body</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	const clients = 5
+	codes := make(chan int, clients)
+	for range clients {
+		go func() {
+			rec := httptest.NewRecorder()
+			Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+"/popular"), nil))
+			codes <- rec.Code
+		}()
+	}
+	// let every client reach the upstream fetch before it completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	for range clients {
+		if code := <-codes; code != http.StatusOK {
+			t.Errorf("status = %d; want %d", code, http.StatusOK)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("upstream fetched %d times; want 1", requests)
+	}
+}
+
+func TestHandlerCoalescedFetchOutlivesFirstClient(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+	var requests atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		<-release
+		if _, err := w.Write([]byte(`<html><head><title>Slow</title></head><body><p>Body</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	target := "/api?format=json&url=" + url.QueryEscape(srv.URL+"/slow")
+	second := make(chan int, 1)
+	first := httptest.NewRecorder()
+	go func() {
+		// joins the fetch the first client starts
+		time.Sleep(20 * time.Millisecond)
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", target, nil))
+		second <- rec.Code
+	}()
+	Handler(first, httptest.NewRequest("GET", target+"&timeout=100ms", nil))
+	if first.Code == http.StatusOK {
+		t.Fatalf("first client: status = %d; want its short timeout to fail it", first.Code)
+	}
+	release <- struct{}{}
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("second client: status = %d; want %d despite the first client giving up", code, http.StatusOK)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("upstream fetched %d times; want 1", n)
+	}
+}
+
+func TestHandlerServesFromPageCache(t *testing.T) {
+	old := pageCacheStore
+	cache := article.NewMemoryPageCache(10, time.Minute)
+	pageCacheStore = cache
+	defer func() { pageCacheStore = old }()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if _, err := w.Write([]byte("<html><head><title>Cached page</title></head><body><p>Body</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	get := func() string {
+		req := httptest.NewRequest("GET", "/api?format=json&cache-key=page-cache-test&url="+url.QueryEscape(srv.URL+"/post"), nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return resp["title"].(string)
+	}
+
+	if title := get(); title != "Cached page" {
+		t.Errorf("first title = %q", title)
+	}
+	// The page is stored in the background.
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, ok := cache.Get(context.Background(), "page-cache-test"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("page not stored in the page cache")
+		}
+	}
+	if title := get(); title != "Cached page" {
+		t.Errorf("second title = %q", title)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("origin fetched %d times; want 1", n)
+	}
+}
+
+func TestArticleCacheKeyFetchOptions(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post")
+	base := articleCacheKey(link, httptest.NewRequest("GET", "/api?url=https://example.com/post", nil))
+	for _, query := range []string{
+		"ipv4-only=true",
+		"respect-robots=true",
+	} {
+		if key := articleCacheKey(link, httptest.NewRequest("GET", "/api?url=https://example.com/post&"+query, nil)); key == base {
+			t.Errorf("%s: key = %q; want it to differ from requests without the option", query, key)
+		}
+	}
+	withLanguage := httptest.NewRequest("GET", "/api?url=https://example.com/post", nil)
+	withLanguage.Header.Set("Accept-Language", "fr")
+	if key := articleCacheKey(link, withLanguage); key == base {
+		t.Errorf("Accept-Language: key = %q; want it to differ from requests without one", key)
+	}
+	withLanguage.URL.RawQuery += "&lang=de"
+	if key := articleCacheKey(link, withLanguage); strings.Contains(key, "fr") {
+		t.Errorf("key = %q; want ?lang= to replace Accept-Language", key)
+	}
+}
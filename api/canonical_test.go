@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerCanonicalURL(t *testing.T) {
+	body := "<p>" + strings.Repeat("Some article text for the canonical test. ", 10) + "</p>"
+	pages := map[string]string{
+		"/canonical":  `<link rel="canonical" href="/canonical?clean=1">`,
+		"/og":         `<meta property="og:url" content="https://www.example.org/og">`,
+		"/input":      ``,
+		"/syndicated": `<link rel="canonical" href="https://original.example.net/story">`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := "<html><head><title>Canonical</title>" + pages[r.URL.Path] + "</head><body><article>" + body + "</article></body></html>"
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	tests := []struct {
+		path      string
+		canonical string
+		changed   bool
+	}{
+		{"/canonical", srv.URL + "/canonical?clean=1", false},
+		{"/og", "https://www.example.org/og", true},
+		{"/input", srv.URL + "/input", false},
+		{"/syndicated", "https://original.example.net/story", true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+tt.path), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %q", tt.path, rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.path, err)
+		}
+		if resp["canonical_url"] != tt.canonical {
+			t.Errorf("%s: canonical_url = %v; want %q", tt.path, resp["canonical_url"], tt.canonical)
+		}
+		if changed := rec.Header().Get("X-Canonical-URL-Changed") == "true"; changed != tt.changed {
+			t.Errorf("%s: X-Canonical-URL-Changed = %q; want changed=%v", tt.path, rec.Header().Get("X-Canonical-URL-Changed"), tt.changed)
+		}
+	}
+}
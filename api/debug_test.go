@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestJSONDebugDiagnostics(t *testing.T) {
+	htmlBody := `<html><head>
+<title>Raw Title</title>
+<meta name="description" content="Raw description">
+<meta property="og:title" content="OG Title">
+</head><body><nav>Menu</nav><article><p>Hello World, this is the article body.</p></article></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(htmlBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	tests := []struct {
+		name      string
+		env       string
+		query     string
+		wantDebug bool
+	}{
+		{"enabled", "true", "&format=json&debug=true", true},
+		{"env disabled", "", "&format=json&debug=true", false},
+		{"param absent", "true", "&format=json", false},
+		{"param false", "true", "&format=json&debug=false", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENABLE_DEBUG_PARAM", tt.env)
+		req := httptest.NewRequest("GET", "/api?url="+url.QueryEscape(srv.URL)+tt.query, nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; want 200, body: %q", tt.name, rec.Code, rec.Body.String())
+		}
+
+		var resp struct {
+			Title string               `json:"title"`
+			Debug *article.Diagnostics `json:"_debug"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.name, err)
+		}
+		if !tt.wantDebug {
+			if resp.Debug != nil {
+				t.Errorf("%s: unexpected _debug object: %+v", tt.name, resp.Debug)
+			}
+			continue
+		}
+		if resp.Debug == nil {
+			t.Fatalf("%s: missing _debug object", tt.name)
+		}
+		d := resp.Debug
+		if d.RawTitle != "Raw Title" {
+			t.Errorf("%s: raw_title = %q; want %q", tt.name, d.RawTitle, "Raw Title")
+		}
+		if d.RawDescription != "Raw description" {
+			t.Errorf("%s: raw_description = %q; want %q", tt.name, d.RawDescription, "Raw description")
+		}
+		if d.RawOGTitle != "OG Title" {
+			t.Errorf("%s: raw_og_title = %q; want %q", tt.name, d.RawOGTitle, "OG Title")
+		}
+		if d.RawBodyLength != int64(len(htmlBody)) {
+			t.Errorf("%s: raw_body_length = %d; want %d", tt.name, d.RawBodyLength, len(htmlBody))
+		}
+		if d.ContentLength == 0 {
+			t.Errorf("%s: content_length = 0; want extracted content length", tt.name)
+		}
+	}
+}
+
+func TestFetchRetriesWithAlternateUserAgent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != article.RetryUserAgent {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if _, err := w.Write([]byte(`<html><head><title>Crawlers Welcome</title></head><body><p>Indexed content.</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+	t.Setenv("ENABLE_DEBUG_PARAM", "true")
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&debug=true&url="+url.QueryEscape(srv.URL+"/retry"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200, body: %q", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Title string               `json:"title"`
+		Debug *article.Diagnostics `json:"_debug"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Title != "Crawlers Welcome" {
+		t.Errorf("title = %q", resp.Title)
+	}
+	if resp.Debug == nil || len(resp.Debug.FetchAttempts) != 2 {
+		t.Fatalf("_debug = %+v; want two fetch attempts", resp.Debug)
+	}
+	first, retry := resp.Debug.FetchAttempts[0], resp.Debug.FetchAttempts[1]
+	if first.Status != http.StatusForbidden || first.UserAgent == article.RetryUserAgent {
+		t.Errorf("first attempt = %+v; want a browser User-Agent refused with 403", first)
+	}
+	if retry.Status != http.StatusOK || retry.UserAgent != article.RetryUserAgent {
+		t.Errorf("retry = %+v; want retryUserAgent answered with 200", retry)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerETag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("<html><head><title>Tagged</title></head><body><p>Body</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	get := func(format, ifNoneMatch string) *httptest.ResponseRecorder {
+		target := "/api?url=" + url.QueryEscape(srv.URL+"/post")
+		if format != "" {
+			target += "&format=" + format
+		}
+		req := httptest.NewRequest("GET", target, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		return rec
+	}
+
+	// HTML responses embed a fresh CSP nonce each time, which must not change the ETag
+	first := get("", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %q", first.Code, first.Body.String())
+	}
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("ETag = %q; want a quoted strong tag", etag)
+	}
+	if cc := first.Header().Get("Cache-Control"); !strings.Contains(cc, "public") || !strings.Contains(cc, "s-maxage=") {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+	if vary := first.Header().Get("Vary"); !strings.Contains(vary, "Accept") {
+		t.Errorf("Vary = %q; want Accept since the format was negotiated", vary)
+	}
+	if _, err := time.Parse(http.TimeFormat, first.Header().Get("Last-Modified")); err != nil {
+		t.Errorf("Last-Modified = %q: %v", first.Header().Get("Last-Modified"), err)
+	}
+
+	if again := get("", ""); again.Header().Get("ETag") != etag {
+		t.Errorf("ETag changed between identical responses: %q, %q", etag, again.Header().Get("ETag"))
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := get("", header)
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: status = %d; want 304", header, rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: 304 has a body: %q", header, rec.Body.String())
+		}
+	}
+
+	if rec := get("", `"other"`); rec.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match: status = %d; want 200", rec.Code)
+	}
+	md := get("md", "")
+	if md.Header().Get("ETag") == etag {
+		t.Error("different formats share an ETag")
+	}
+	if vary := md.Header().Get("Vary"); vary != "Accept-Language" {
+		t.Errorf("Vary = %q; want only Accept-Language with an explicit format", vary)
+	}
+	if vary := get("md&lang=fr", "").Header().Get("Vary"); vary != "" {
+		t.Errorf("Vary = %q; want none with an explicit format and language", vary)
+	}
+}
+
+func TestHandlerErrorsNotCacheable(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?url=ftp://example.com", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d; want 400", rec.Code)
+	}
+	for _, name := range []string{"ETag", "Cache-Control"} {
+		if value := rec.Header().Get(name); value != "" {
+			t.Errorf("%s = %q on an error response", name, value)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerFormBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := `<html><head><title>Page ` + strings.TrimPrefix(r.URL.Path, "/") + `</title></head><body><p>Hello World</p></body></html>`
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	tests := []struct {
+		name, method, query, body, want string
+	}{
+		{"query string", "GET", "?format=json&url=" + url.QueryEscape(srv.URL+"/a"), "", "Page a"},
+		{"form body", "POST", "", "format=json&url=" + url.QueryEscape(srv.URL+"/b"), "Page b"},
+		{"query string wins", "POST", "?format=json&url=" + url.QueryEscape(srv.URL+"/a"), "format=html&url=" + url.QueryEscape(srv.URL+"/b"), "Page a"},
+		{"mixed", "POST", "?format=json", "url=" + url.QueryEscape(srv.URL+"/b"), "Page b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api"+tt.query, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rec := httptest.NewRecorder()
+			Handler(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+			}
+			var got struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if got.Title != tt.want {
+				t.Errorf("title = %q; want %q", got.Title, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerFormBodyIgnoredForOtherContentTypes(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api", strings.NewReader("url=https://example.com"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d for a missing url", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlerFormBodySSRFProtection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("dialer did not block private IP from a form body")
+	}))
+	defer srv.Close()
+
+	req := httptest.NewRequest("POST", "/api", strings.NewReader("url="+url.QueryEscape(srv.URL)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d; want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
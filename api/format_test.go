@@ -1,34 +1,46 @@
 package handler
 
 import (
-	"bytes"
+	"net/http"
 	"net/http/httptest"
-	"strings"
 	"testing"
-
-	"codeberg.org/readeck/go-readability/v2"
-	"golang.org/x/net/html"
 )
 
-func TestFormatTextRendersPlainText(t *testing.T) {
-	// Minimal article node: <p>Plain body</p>
-	p := &html.Node{Type: html.ElementNode, Data: "p"}
-	p.AppendChild(&html.Node{Type: html.TextNode, Data: "Plain body"})
-	article := readability.Article{Node: p}
-
-	rec := httptest.NewRecorder()
-	// Pass HTML-looking buffer deliberately: formatText must ignore it.
-	htmlBuf := bytes.NewBufferString("<p>should not appear</p>")
-	formatText(rec, article, htmlBuf)
-
-	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
-		t.Fatalf("Content-Type = %q; want text/plain", ct)
+func TestParseFormatOptionsWrap(t *testing.T) {
+	for query, want := range map[string]int{"": 0, "wrap=20": 20, "wrap=80": 80, "wrap=200": 200, "wrap=19": -1, "wrap=201": -1, "wrap=abc": -1} {
+		opts, err := parseFormatOptions(httptest.NewRequest("GET", "/api?format=text&"+query, nil), "text")
+		if want < 0 {
+			if err == nil {
+				t.Errorf("parseFormatOptions(%q) succeeded; want an error", query)
+			}
+			continue
+		}
+		if err != nil || opts.Text.Width != want {
+			t.Errorf("parseFormatOptions(%q) = (%d, %v); want %d", query, opts.Text.Width, err, want)
+		}
 	}
-	body := rec.Body.String()
-	if strings.Contains(body, "<p>") || strings.Contains(body, "should not appear") {
-		t.Fatalf("formatText returned HTML or buffer contents: %q", body)
+}
+
+func TestParseFormatOptionsDedupeWhitespace(t *testing.T) {
+	for query, preserve := range map[string]bool{"": false, "dedupe-whitespace=true": false, "dedupe-whitespace=false": true, "dedupe-whitespace=0": true} {
+		r := httptest.NewRequest("GET", "/api?format=text&"+query, nil)
+		opts, err := parseFormatOptions(r, "text")
+		if err != nil {
+			t.Fatalf("parseFormatOptions(%q) error = %v", query, err)
+		}
+		if opts.Text.PreserveWhitespace != preserve {
+			t.Errorf("parseFormatOptions(%q).Text.PreserveWhitespace = %v; want %v", query, opts.Text.PreserveWhitespace, preserve)
+		}
 	}
-	if !strings.Contains(body, "Plain body") {
-		t.Fatalf("formatText missing plain text, got: %q", body)
+}
+
+func TestHandlerRejectsInvalidTypography(t *testing.T) {
+	for _, query := range []string{"font-size=100", "line-height=5", "font-size=12%3Bcolor%3Ared"} {
+		req := httptest.NewRequest("GET", "/api?url=example.com&"+query, nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Handler(%q) status = %d; want %d", query, rec.Code, http.StatusBadRequest)
+		}
 	}
 }
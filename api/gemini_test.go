@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestGemtextAlias(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(`<html><head><title>Capsule</title></head><body><article><h1>Capsule</h1><p>Gemini capsules are small text sites served over their own protocol, with a single link per line. See <a href="https://a.example/">A</a> for more about them and how to publish one.</p></article></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	bodies := map[string]string{}
+	for _, format := range []string{"gemini", "gemtext"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format="+format+"&url="+url.QueryEscape(srv.URL+"/capsule"), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("format=%s: status = %d; body: %q", format, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/gemini; charset=utf-8" {
+			t.Errorf("format=%s: Content-Type = %q", format, ct)
+		}
+		bodies[format] = rec.Body.String()
+	}
+	if !strings.Contains(bodies["gemtext"], "=> https://a.example/ A") {
+		t.Errorf("gemtext = %q; want its link lines", bodies["gemtext"])
+	}
+	if bodies["gemtext"] != bodies["gemini"] {
+		t.Errorf("gemtext = %q; want the gemini output %q", bodies["gemtext"], bodies["gemini"])
+	}
+}
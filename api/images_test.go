@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/formatter"
+)
+
+// pngPixel is a 1x1 transparent PNG.
+var pngPixel, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=")
+
+func newImageServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			hits.Add(1)
+		}
+		var err error
+		switch r.URL.Path {
+		case "/pixel.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, err = w.Write(pngPixel)
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, err = w.Write(pngPixel)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, err = w.Write(append(pngPixel, make([]byte, formatter.MaxImageSize)...))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, err = w.Write([]byte("<html><body>not an image</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+		if err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestHandlerIncludeImagesAsBase64(t *testing.T) {
+	images := newImageServer(t, nil)
+	defer images.Close()
+	page := fmt.Sprintf(`<html><head><title>Pictures</title></head><body><article><h1>Pictures</h1>
+<p>%s</p><img src="%s/pixel.png"><p>%s</p></article></body></html>`,
+		strings.Repeat("Lead paragraph text. ", 20), images.URL, strings.Repeat("Closing paragraph text. ", 20))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	for _, param := range []string{"", "&include-images-as-base64=true"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=html&url="+url.QueryEscape(srv.URL)+param, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+		}
+		embedded := strings.Contains(rec.Body.String(), `src="data:image/png;base64,`)
+		if embedded != (param != "") {
+			t.Errorf("param %q: embedded = %v, body: %q", param, embedded, rec.Body.String())
+		}
+	}
+}
@@ -11,142 +11,69 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"html/template"
 	"log"
-	"math/rand"
-	"net"
+	"log/slog"
+	"math"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 
-	"codeberg.org/readeck/go-readability/v2"
-	"github.com/mattn/godown"
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/formatter"
+	reqlog "github.com/lucasew/readability-web/internal/log"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/middleware"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/lucasew/readability-web/internal/transport"
 	"golang.org/x/net/html"
 )
 
 const (
-	maxRedirects      = 5
-	httpClientTimeout = 10 * time.Second
-	maxBodySize       = int64(2 * 1024 * 1024) // 2 MiB
-	dialerTimeout     = 30 * time.Second
-	dialerKeepAlive   = 30 * time.Second
-	handlerTimeout    = 5 * time.Second
+	defaultMaxFetchTimeout = 10 * time.Second // see maxFetchTimeout
+	maxFormSize            = int64(64 * 1024) // form POST fields besides an html page, see mergeFormParams
+	handlerTimeout         = 5 * time.Second
+
+	defaultCacheSize = 100
+	cacheTTL         = 10 * time.Minute
+
+	rateLimitWindow = time.Minute
 )
 
 /**
- * Template is the raw HTML template string used for rendering the article.
- *
- * It provides a minimal HTML5 structure and includes the Sakura CSS library
- * for a clean, typography-focused reading experience without distractions.
- * The template expects a struct with Title and Content fields.
- */
-const Template = `
-<!DOCTYPE html>
-<html>
-<head>
-	<meta charset="utf-8"/>
-	<meta name="viewport" content="width=device-width, initial-scale=1.0">
-	<link id="theme" rel="stylesheet" href="https://unpkg.com/sakura.css/css/sakura.css">
-</head>
-<body>
-	<script src="https://bookmarklet-theme.vercel.app/script.js"></script>
-	<h1>{{.Title}}</h1>
-	{{.Content}}
-</body>
-</html>
-`
-
+ * buildVersion and buildTime identify the deployed build. They are set at build time with
+ * `-ldflags "-X github.com/lucasew/readability-web/api.buildVersion=v1.2.3 -X github.com/lucasew/readability-web/api.buildTime=2026-01-02T15:04:05Z"`.
+ */
 var (
-	/**
-	 * DefaultTemplate is the parsed Go template instance.
-	 *
-	 * It is initialized at startup to avoid the overhead of parsing the template
-	 * on every request, ensuring faster response times.
-	 */
-	DefaultTemplate = template.Must(template.New("article").Parse(Template))
-
-	/**
-	 * ReadabilityParser is the shared instance of the readability parser.
-	 *
-	 * It is reusable and thread-safe, allowing concurrent processing of multiple
-	 * requests without the need to create new parser instances.
-	 */
-	ReadabilityParser = readability.NewParser()
-
-	// httpClient used for fetching remote articles with timeouts and redirect policy
-	httpClient = &http.Client{
-		Transport: &http.Transport{
-			DialContext: newSafeDialer().DialContext,
-		},
-		Timeout: httpClientTimeout,
-		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
-			if len(via) >= maxRedirects {
-				return fmt.Errorf("stopped after %d redirects", maxRedirects)
-			}
-			return nil
-		},
-	}
+	buildVersion = "dev"
+	buildTime    = ""
 )
 
-/**
- * newSafeDialer creates a custom net.Dialer that prevents Server-Side Request Forgery (SSRF).
- *
- * It validates the resolved IP address before connecting, ensuring that it is not:
- * - A private network address (e.g., 192.168.x.x, 10.x.x.x)
- * - A loopback address (e.g., 127.0.0.1)
- * - An unspecified address (e.g., 0.0.0.0)
- *
- * This validation happens *after* DNS resolution but *before* the connection is established.
- * This prevents Time-of-Check Time-of-Use (TOCTOU) attacks where a domain could
- * resolve to a safe IP during check but switch to a private IP during connection.
- *
- * This is critical for preventing the application from accessing internal services or metadata services
- * (like AWS EC2 metadata) running on the same network.
- */
-func newSafeDialer() *net.Dialer {
-	dialer := &net.Dialer{
-		Timeout:   dialerTimeout,
-		KeepAlive: dialerKeepAlive,
-		Control: func(_, address string, _ syscall.RawConn) error {
-			host, _, err := net.SplitHostPort(address)
-			if err != nil {
-				return err
-			}
-			ips, err := net.LookupIP(host)
-			if err != nil {
-				return err
-			}
-			for _, ip := range ips {
-				if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
-					return errors.New("refusing to connect to private network address")
-				}
-			}
-			return nil
-		},
-	}
-	return dialer
-}
+var (
+	// articleCacheStore caches extracted articles, keyed by normalized URL or `?cache-key=`.
+	articleCacheStore = article.NewCacheStore(articleCacheSize(), cacheTTL)
 
-/**
- * userAgentPool contains a list of real browser User-Agent strings.
- *
- * We rotate through these to mimic legitimate traffic, as many websites block requests
- * from default HTTP clients (like Go-http-client) or known bot User-Agents.
- * This list requires periodic maintenance to stay current with browser versions.
- */
-var userAgentPool = []string{
-	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36 Edg/150.0.0.0",
-	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:152.0) Gecko/20100101 Firefox/152.0",
-	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_7_8 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
-}
+	// pageCacheStore keeps fetched pages behind articleCacheStore (nil when disabled).
+	pageCacheStore = article.PageCacheFromEnv(articleCacheSize())
+
+	// requestLimiter enforces RATE_LIMIT requests per client per rateLimitWindow.
+	requestLimiter = middleware.NewRateLimiter(middleware.RateLimitFromEnv(), rateLimitWindow)
+)
 
 /**
  * llmUserAgents contains a list of substring identifiers for known LLM bots and crawlers.
@@ -168,64 +95,235 @@ var llmUserAgents = []string{
 }
 
 /**
- * getRandomUserAgent returns a random User-Agent string from the pool.
- *
- * Rotating User-Agents helps to evade simple anti-bot measures that block requests
- * based on static or default Go HTTP client User-Agents.
+ * cachedPage is a fetched page as stored in the article.PageCache.
  */
-func getRandomUserAgent() string {
-	return userAgentPool[rand.Intn(len(userAgentPool))]
+type cachedPage struct {
+	// URL is the address the page came from, after meta refreshes.
+	URL  string `json:"url"`
+	HTML string `json:"html"`
+	// Size is the number of bytes fetched, for BodySize.
+	Size      int64                    `json:"size"`
+	FetchedAt time.Time                `json:"fetched_at"`
+	Archive   *article.ArchiveSnapshot `json:"archive,omitempty"`
+	AMP       bool                     `json:"amp,omitempty"`
 }
 
 /**
- * fetchAndParse retrieves the content from the target URL and parses it using the readability library.
- *
- * Key behaviors:
- * - Spoofs User-Agent and other browser headers to avoid blocking.
- * - Forwards Accept-Language from the client to respect language preferences.
- * - Sets security headers (Sec-Fetch-*) to look like a navigation request.
- * - Limits the response body size to maxBodySize to prevent Out-Of-Memory (OOM) crashes on large pages.
- * - Uses a custom httpClient with SSRF protection.
- */
-func fetchAndParse(ctx context.Context, link *url.URL, r *http.Request) (readability.Article, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", link.String(), nil)
+ * loadArticle returns the article at link, parsing the page stored in pageCacheStore
+ * under key when there is one, and fetching it otherwise (see article.Fetch). Fetched
+ * pages are then stored in the background.
+ */
+func loadArticle(ctx context.Context, key string, link *url.URL, r *http.Request, opts article.Options) (article.FetchResult, error) {
+	cache := pageCacheStore
+	if cache == nil {
+		return article.Fetch(ctx, link, r, opts)
+	}
+	if data, found := cache.Get(ctx, key); found {
+		var page cachedPage
+		err := json.Unmarshal(data, &page)
+		source, urlErr := url.Parse(page.URL)
+		node, parseErr := html.Parse(strings.NewReader(page.HTML))
+		if err = cmp.Or(err, urlErr, parseErr); err == nil {
+			fetched, err := article.Extract(ctx, node, source, page.Size, opts)
+			if !page.FetchedAt.IsZero() {
+				fetched.FetchedAt = page.FetchedAt
+			}
+			fetched.Archive, fetched.AMP = page.Archive, page.AMP
+			return fetched, err
+		}
+		log.Printf("ignoring invalid page cache entry for %q: %v", key, err)
+	}
+
+	fetched, err := article.Fetch(ctx, link, r, opts)
 	if err != nil {
-		return readability.Article{}, err
+		return fetched, err
+	}
+	var sb strings.Builder
+	if err := html.Render(&sb, fetched.Document); err != nil {
+		log.Printf("error rendering page for the page cache: %v", err)
+		return fetched, nil
 	}
+	data, err := json.Marshal(cachedPage{URL: fetched.URL.String(), HTML: sb.String(), Size: fetched.BodySize, FetchedAt: fetched.FetchedAt, Archive: fetched.Archive, AMP: fetched.AMP})
+	if err != nil {
+		log.Printf("error encoding page for the page cache: %v", err)
+		return fetched, nil
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), article.PageCacheTimeout)
+		defer cancel()
+		cache.Set(ctx, key, data)
+	}()
+	return fetched, nil
+}
 
-	// Always spoof everything to look like a real browser
-	ua := getRandomUserAgent()
-	req.Header.Set("User-Agent", ua)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+/**
+ * fetchArticle loads the article at link (see loadArticle), sharing the fetch with
+ * concurrent requests for key through articleFlights. The article is then stored
+ * in articleCacheStore under key and rendered ahead of time by article.WarmCache.
+ */
+func fetchArticle(ctx context.Context, key string, link *url.URL, r *http.Request, opts article.Options) (article.FetchResult, error) {
+	return articleFlights.Do(ctx, key, func(ctx context.Context) (article.FetchResult, error) {
+		fetched, err := loadArticle(ctx, key, link, r, opts)
+		if err == nil {
+			articleCacheStore.Add(key, fetched)
+			go article.WarmCache(key, fetched, articleCacheStore, warmRenderer(link))
+		}
+		return fetched, err
+	})
+}
 
-	// Fallback headers from client request
-	req.Header.Set("Accept-Language", cmp.Or(r.Header.Get("Accept-Language"), "en-US,en;q=0.9"))
+/**
+ * warmNonce stands for the CSP nonce in pre-rendered HTML; serveRendered swaps
+ * it for the nonce of the response. It is random so article content can't contain it.
+ */
+var warmNonce = newCSPNonce()
 
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Pragma", "no-cache")
-	req.Header.Set("Sec-Ch-Ua-Mobile", "?0")
-	req.Header.Set("Sec-Fetch-Dest", "document")
-	req.Header.Set("Sec-Fetch-Mode", "navigate")
-	req.Header.Set("Sec-Fetch-Site", "none")
-	req.Header.Set("Sec-Fetch-User", "?1")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
+/**
+ * warmRenderer renders the article at link for article.WarmCache, with the
+ * default options of each format.
+ */
+func warmRenderer(link *url.URL) article.Renderer {
+	return func(w http.ResponseWriter, format string, fetched article.FetchResult) {
+		req, err := http.NewRequest("GET", "/", nil)
+		var opts formatter.Options
+		if err == nil {
+			opts, err = parseFormatOptions(req, format)
+		}
+		if err != nil {
+			log.Printf("error warming %s for %q: %v", format, link, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		opts.Link = link
+		opts.Nonce = warmNonce
+		renderArticle(w, req, format, fetched, opts)
+	}
+}
 
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return readability.Article{}, err
+/**
+ * renderOnlyParams are the control parameters that don't change how an article is
+ * rendered: requests using only these can be served by article.WarmCache renderings.
+ */
+var renderOnlyParams = []string{"url", "format", "timeout", "cache-key", "lang", "referer", "selector", "ipv4-only", "respect-robots", "fallback", "prefer", "readability", "proxy", "proxy-signature", "proxy-expires"}
+
+/**
+ * serveRendered writes the article.WarmCache rendering of the article cached under key,
+ * when there is one and r asks for the default rendering. It reports whether it did.
+ */
+func serveRendered(w http.ResponseWriter, r *http.Request, key, format string) bool {
+	for param := range r.URL.Query() {
+		if slices.Contains(controlParams, param) && !slices.Contains(renderOnlyParams, param) {
+			return false
+		}
+	}
+	rendered, found := articleCacheStore.Rendered(key, format)
+	if !found {
+		return false
+	}
+	for name, values := range rendered.Header() {
+		w.Header()[name] = values
 	}
-	defer res.Body.Close()
+	body := bytes.Replace(rendered.Body.Bytes(), []byte(warmNonce), []byte(cspNonce(r.Context())), 1)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("error writing cached %s rendering: %v", format, err)
+	}
+	return true
+}
+
+/**
+ * flightCall is a fetch in progress in a flightGroup.
+ */
+type flightCall struct {
+	done    chan struct{}
+	article article.FetchResult
+	err     error
+	log     reqlog.LogContext
+}
+
+/**
+ * flightGroup coalesces concurrent fetches of the same article: while a fetch
+ * for a key runs, later callers wait for its result instead of fetching again.
+ */
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
 
-	// Cap the body so oversized pages error instead of being silently truncated
-	// (io.LimitReader returns EOF at the cap, which can yield partial HTML as a
-	// successful extract). MaxBytesReader surfaces an error when the cap is hit.
-	reader := http.MaxBytesReader(nil, res.Body, maxBodySize)
-	node, err := html.Parse(reader)
+/**
+ * Do runs fetch for key, unless a fetch for key is already running, in which case
+ * it waits for that one and returns its result (including its error).
+ *
+ * The fetch belongs to no caller: it runs in the background, with the values of
+ * the first caller's ctx but without its cancellation, under maxFetchTimeout and
+ * with a reqlog.LogContext of its own. Each caller waits until its own ctx is done, so a
+ * client that disconnects or asked for a short `?timeout=` doesn't fail the others,
+ * and gets the fetch timings added to its reqlog.LogContext.
+ */
+func (g *flightGroup) Do(ctx context.Context, key string, fetch func(context.Context) (article.FetchResult, error)) (article.FetchResult, error) {
+	g.mu.Lock()
+	call, found := g.calls[key]
+	if !found {
+		if g.calls == nil {
+			g.calls = map[string]*flightCall{}
+		}
+		call = &flightCall{done: make(chan struct{})}
+		g.calls[key] = call
+		lc := reqlog.FromContext(ctx)
+		call.log = reqlog.LogContext{ArticleURL: lc.ArticleURL, Format: lc.Format, RequestID: lc.RequestID}
+		go func() {
+			fetchCtx, cancel := context.WithTimeout(reqlog.NewContext(context.WithoutCancel(ctx), &call.log), maxFetchTimeout())
+			defer cancel()
+			fetched, err := fetch(fetchCtx)
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			call.article, call.err = fetched, err
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		lc := reqlog.FromContext(ctx)
+		lc.FetchDurationMs += call.log.FetchDurationMs
+		lc.ParseDurationMs += call.log.ParseDurationMs
+		lc.FetchAttempts = append(lc.FetchAttempts, call.log.FetchAttempts...)
+		return call.article, call.err
+	case <-ctx.Done():
+		return article.FetchResult{}, ctx.Err()
+	}
+}
+
+// articleFlights coalesces the upstream fetches of concurrent requests for the same article.
+var articleFlights flightGroup
+
+/**
+ * articleCacheSize returns the number of cached articles from ARTICLE_CACHE_SIZE.
+ */
+func articleCacheSize() int {
+	raw := os.Getenv("ARTICLE_CACHE_SIZE")
+	if raw == "" {
+		return defaultCacheSize
+	}
+	size, err := strconv.Atoi(raw)
 	if err != nil {
-		return readability.Article{}, err
+		log.Printf("invalid ARTICLE_CACHE_SIZE %q, using %d: %v", raw, defaultCacheSize, err)
+		return defaultCacheSize
 	}
+	return size
+}
 
-	return ReadabilityParser.ParseDocument(node, link)
+/**
+ * rejectedSchemes are the non-http(s) URL schemes with a dedicated error, as they
+ * are easy to paste from a browser but can never point at a fetchable page.
+ */
+var rejectedSchemes = map[string]error{
+	"data":       errors.New("data: URLs are not supported; provide a remote http/https URL"),
+	"javascript": errors.New("javascript: URLs are not supported; they run code instead of pointing at a page"),
+	"vbscript":   errors.New("vbscript: URLs are not supported; they run code instead of pointing at a page"),
+	"file":       errors.New("file: URLs are not supported; local files can't be read, provide a remote http/https URL"),
+	"blob":       errors.New("blob: URLs are not supported; they only exist inside the browser that created them"),
 }
 
 /**
@@ -236,11 +334,18 @@ func fetchAndParse(ctx context.Context, link *url.URL, r *http.Request) (readabi
  * - Malformed schemes caused by some proxies (e.g., http:/example.com -> http://example.com).
  *
  * It also restricts the scheme to 'http' or 'https' to prevent usage of other protocols like 'file://' or 'gopher://'.
+ * Schemes people plausibly paste (see rejectedSchemes) get an error saying why they can't work.
  */
 func normalizeAndValidateURL(rawLink string) (*url.URL, error) {
 	if rawLink == "" {
 		return nil, errors.New("url parameter is empty")
 	}
+	// checked before defaulting the scheme, as "data:text/html,..." has no "://"
+	if scheme, _, found := strings.Cut(rawLink, ":"); found {
+		if err, rejected := rejectedSchemes[strings.ToLower(strings.TrimSpace(scheme))]; rejected {
+			return nil, err
+		}
+	}
 
 	// Fix browser/proxy normalization of :// to :/
 	if strings.HasPrefix(rawLink, "http:/") && !strings.HasPrefix(rawLink, "http://") {
@@ -265,21 +370,53 @@ func normalizeAndValidateURL(rawLink string) (*url.URL, error) {
 	return link, nil
 }
 
+/**
+ * cspNonceKey is the context key under which securityHeadersMiddleware stores
+ * the per-response Content-Security-Policy nonce.
+ */
+type cspNonceKey struct{}
+
+/**
+ * newCSPNonce generates a random, base64-encoded nonce for inline styles.
+ */
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := cryptorand.Read(b); err != nil {
+		log.Printf("error generating CSP nonce: %v", err)
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+/**
+ * cspNonce returns the nonce generated for the current response, if any.
+ */
+func cspNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceKey{}).(string)
+	return nonce
+}
+
 /**
  * securityHeadersMiddleware applies a baseline of security headers to every response.
  *
  * Headers set:
  * - Content-Security-Policy: Restricts sources for scripts, styles, and other content to prevent XSS.
  *   - default-src 'self': Only allow content from same origin by default.
- *   - script-src 'self' ...: Whitelists the bookmarklet script.
- *   - style-src 'self' ...: Whitelists external CSS for the Sakura theme (unpkg.com).
+ *   - script-src 'self' ...: Whitelists the bookmarklet script and inline scripts carrying the nonce.
+ *   - style-src 'self' ...: Whitelists external CSS for the Sakura theme (unpkg.com) and
+ *     inline styles carrying the per-response nonce (used for typography overrides).
+ *   - img-src 'self' data:: Allows images embedded by `?include-images-as-base64=true`.
  * - X-Content-Type-Options: Prevents MIME-sniffing.
  * - X-Frame-Options: Prevents clickjacking by denying framing.
  * - Referrer-Policy: Controls how much referrer information is sent.
  */
 func securityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self' https://bookmarklet-theme.vercel.app; style-src 'self' https://unpkg.com;")
+		nonce := newCSPNonce()
+		if nonce != "" {
+			r = r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce))
+		}
+		w.Header().Set("Content-Security-Policy", formatter.ContentSecurityPolicy(nonce))
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("X-Frame-Options", "DENY")
 		w.Header().Set("Referrer-Policy", "no-referrer-when-downgrade")
@@ -287,99 +424,232 @@ func securityHeadersMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+/**
+ * versionMiddleware tells which build served the response in X-Article-Parser-Version,
+ * so operators running several deployments can tell them apart.
+ */
+func versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Article-Parser-Version", buildVersion)
+		next.ServeHTTP(w, r)
+	})
+}
+
+/**
+ * articleMaxAge is how long browsers may reuse an article response, and
+ * articleSharedMaxAge how long shared caches (Vercel's edge, proxies) may.
+ */
+const (
+	articleMaxAge       = 5 * time.Minute
+	articleSharedMaxAge = time.Hour
+)
+
+/**
+ * cachingMiddleware makes successful GET responses cacheable by CDNs and browsers.
+ *
+ * The response is buffered to compute its ETag, a hash of the body with the CSP
+ * nonce left out (it changes on every response, the article doesn't). Requests whose
+ * If-None-Match lists that ETag get an empty 304 Not Modified. Responses also get a
+ * Cache-Control header, unless the handler set one, and Vary on the headers getFormat
+ * reads when `?format=` is absent.
+ */
+func cachingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := response.NewBuffered()
+		next.ServeHTTP(rec, r)
+
+		header := w.Header()
+		for name, values := range rec.Header() {
+			header[name] = values
+		}
+		if rec.Status != http.StatusOK {
+			w.WriteHeader(rec.Status)
+			if _, err := w.Write(rec.Body.Bytes()); err != nil {
+				log.Printf("error writing response: %v", err)
+			}
+			return
+		}
+
+		hashed := rec.Body.Bytes()
+		if nonce := cspNonce(r.Context()); nonce != "" {
+			hashed = bytes.ReplaceAll(hashed, []byte(nonce), nil)
+		}
+		sum := sha256.Sum256(hashed)
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", int(articleMaxAge.Seconds()), int(articleSharedMaxAge.Seconds())))
+		}
+		if !r.URL.Query().Has("format") {
+			header.Add("Vary", "Accept, User-Agent")
+		}
+		// the client's language is forwarded upstream unless `?lang=` replaces it
+		if !r.URL.Query().Has("lang") {
+			header.Add("Vary", "Accept-Language")
+		}
+
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			// a 304 carries no body, so none of the headers describing it
+			for _, name := range []string{"Content-Type", "Content-Length", "Content-Disposition", "X-Content-Length"} {
+				header.Del(name)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if _, err := w.Write(rec.Body.Bytes()); err != nil {
+			log.Printf("error writing response: %v", err)
+		}
+	})
+}
+
+/**
+ * etagMatches reports whether an If-None-Match header value matches etag, using
+ * the weak comparison RFC 9110 prescribes for it.
+ */
+func etagMatches(ifNoneMatch, etag string) bool {
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * rateLimitStatusPath is where Handler serves middleware.RateLimitStatusHandler.
+ * vercel.json rewrites it to this function, so the status is read from the same
+ * limiter as the article requests it reports on.
+ */
+const rateLimitStatusPath = "/api/ratelimit"
+
 /**
  * Handler is the Vercel Serverless Function entrypoint.
  *
  * It is invoked by Vercel for all matching routes defined in `vercel.json`.
  * Since Vercel rewrites the path (e.g., `/api/extract` -> `/api/index.go`),
  * we rely on query parameters (like `url` and `format`) or request headers
- * to determine the desired action, rather than parsing the request path directly.
+ * to determine the desired action. The one path it reads is rateLimitStatusPath,
+ * which reports the caller's rate limit quota instead of extracting an article.
  */
 func Handler(w http.ResponseWriter, r *http.Request) {
-	securityHeadersMiddleware(http.HandlerFunc(handler)).ServeHTTP(w, r)
+	if r.URL.Path == rateLimitStatusPath {
+		versionMiddleware(securityHeadersMiddleware(middleware.RateLimitStatusHandler(requestLimiter))).ServeHTTP(w, r)
+		return
+	}
+	accessLogMiddleware(versionMiddleware(securityHeadersMiddleware(middleware.RateLimit(requestLimiter, cachingMiddleware(http.HandlerFunc(handler)))))).ServeHTTP(w, r)
 }
 
 /**
- * formatHandler defines the function signature for handling different output formats.
- *
- * Implementations are responsible for:
- * 1. Setting the appropriate Content-Type header.
- * 2. Encoding the article content (HTML, JSON, Markdown, etc.) into the response writer.
- * 3. Handling any encoding errors (logging them, as headers are already written).
+ * accessLogger is the destination of access log events.
  */
-type formatHandler func(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer)
+var accessLogger = slog.Default()
 
 /**
- * formatHTML renders the article using the standard HTML template.
- * This is the default view for human consumption.
+ * statusRecorder wraps an http.ResponseWriter to remember the response status.
  */
-func formatHTML(w http.ResponseWriter, article readability.Article, contentBuf *bytes.Buffer) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	// inject safe HTML content
-	data := struct {
-		Title   string
-		Content template.HTML
-	}{
-		Title:   article.Title(),
-		Content: template.HTML(contentBuf.String()),
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
 	}
-	if err := DefaultTemplate.Execute(w, data); err != nil {
-		// at this point, we can't write a JSON error, so we log it
-		log.Printf("error executing HTML template: %v", err)
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
 	}
+	return s.ResponseWriter.Write(b)
 }
 
 /**
- * formatMarkdown converts the article content to Markdown.
- * Useful for LLMs or note-taking applications.
+ * accessLogMiddleware attaches a reqlog.LogContext to the request and logs it once the response is done.
+ *
+ * The request ID is taken from the X-Request-Id or X-Vercel-Id headers when present,
+ * so log lines can be correlated with the platform logs, and generated otherwise.
  */
-func formatMarkdown(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer) {
-	w.Header().Set("Content-Type", "text/markdown")
-	if err := godown.Convert(w, buf, nil); err != nil {
-		log.Printf("error converting to markdown: %v", err)
-	}
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lc := &reqlog.LogContext{
+			RequestID: cmp.Or(r.Header.Get("X-Request-Id"), r.Header.Get("X-Vercel-Id"), newRequestID()),
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(reqlog.NewContext(r.Context(), lc)))
+		lc.Status = cmp.Or(rec.status, http.StatusOK)
+
+		accessLogger.Info("request",
+			slog.String("article_url", lc.ArticleURL),
+			slog.String("format", lc.Format),
+			slog.String("request_id", lc.RequestID),
+			slog.Int64("fetch_duration_ms", lc.FetchDurationMs),
+			slog.Int64("parse_duration_ms", lc.ParseDurationMs),
+			slog.Int("status", lc.Status),
+		)
+	})
 }
 
 /**
- * formatJSON returns the raw title and HTML content in a JSON object.
- * Useful for programmatic consumption where the client wants to handle rendering.
+ * newRequestID generates a random hex identifier for requests without one.
  */
-func formatJSON(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer) {
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"title":   article.Title(),
-		"content": buf.String(),
-	}); err != nil {
-		log.Printf("error encoding json: %v", err)
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := cryptorand.Read(b); err != nil {
+		return ""
 	}
+	return hex.EncodeToString(b)
 }
 
 /**
- * formatText returns the plain text content, stripped of HTML tags.
+ * parseTimeout parses the `timeout` query parameter.
  *
- * Uses Article.RenderText rather than the pre-rendered HTML buffer so
- * /txt and format=text responses are actual plain text.
+ * It accepts a Go duration string (`5s`, `1m30s`) or, for backward compatibility,
+ * a plain integer number of seconds. Values above limit are capped to it, and an
+ * empty value yields the default handlerTimeout.
  */
-func formatText(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	if err := article.RenderText(w); err != nil {
-		log.Printf("error writing text response: %v", err)
+func parseTimeout(raw string, limit time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return min(handlerTimeout, limit), nil
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil {
+		seconds, atoiErr := strconv.Atoi(raw)
+		if atoiErr != nil {
+			return 0, errors.New("timeout must be a duration (e.g. 5s, 1m30s) or a number of seconds")
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if timeout <= 0 {
+		return 0, errors.New("timeout must be positive")
 	}
+	return min(timeout, limit), nil
 }
 
 /**
- * formatters maps format names (including aliases) to their respective handler functions.
+ * maxFetchTimeout returns the upper bound for `?timeout=`, from MAX_FETCH_TIMEOUT.
  *
- * This design allows for easy extensibility of output formats. New formats can be
- * added by implementing a formatHandler and registering it here.
+ * The variable accepts the same formats as the parameter and defaults to
+ * defaultMaxFetchTimeout.
  */
-var formatters = map[string]formatHandler{
-	"html":     formatHTML,
-	"md":       formatMarkdown,
-	"markdown": formatMarkdown,
-	"json":     formatJSON,
-	"text":     formatText,
-	"txt":      formatText,
+func maxFetchTimeout() time.Duration {
+	raw := os.Getenv("MAX_FETCH_TIMEOUT")
+	if raw == "" {
+		return defaultMaxFetchTimeout
+	}
+	timeout, err := parseTimeout(raw, time.Duration(math.MaxInt64))
+	if err != nil {
+		log.Printf("invalid MAX_FETCH_TIMEOUT %q, using %s: %v", raw, defaultMaxFetchTimeout, err)
+		return defaultMaxFetchTimeout
+	}
+	return timeout
 }
 
 /**
@@ -419,6 +689,9 @@ func getFormat(r *http.Request) string {
 	if strings.Contains(accept, "text/markdown") || strings.Contains(accept, "text/x-markdown") {
 		return "md"
 	}
+	if strings.Contains(accept, "text/x-ansi") {
+		return "ansi"
+	}
 	if strings.Contains(accept, "text/plain") {
 		return "text"
 	}
@@ -434,6 +707,43 @@ func getFormat(r *http.Request) string {
 	return "html"
 }
 
+/**
+ * controlParams lists the query parameters consumed by this API itself.
+ *
+ * They are never forwarded to the target website when reconstructing its URL.
+ */
+var controlParams = []string{
+	"url",
+	"format",
+	"font-size",
+	"line-height",
+	"debug",
+	"theme",
+	"timeout",
+	"selector",
+	"cache-key",
+	"lang",
+	"no-images",
+	"no-links",
+	"ipv4-only",
+	"referer",
+	"include-images-as-base64",
+	"dedupe-whitespace",
+	"respect-robots",
+	"notion-parent-id",
+	"page",
+	"page-size",
+	"epub-compat",
+	"frontmatter",
+	"wrap",
+	"fallback",
+	"prefer",
+	"readability",
+	"proxy",
+	"proxy-signature",
+	"proxy-expires",
+}
+
 /**
  * reconstructTargetURL handles query parameter extraction quirks caused by Vercel rewrites.
  *
@@ -458,10 +768,10 @@ func reconstructTargetURL(r *http.Request) string {
 	originalQuery := r.URL.Query()
 	hasChanges := false
 	for k, vs := range originalQuery {
-		// Skip 'url' and 'format' as they are control parameters for this API,
+		// Skip control parameters for this API, as they are
 		// not part of the target website's query string.
 		// Including them would cause recursion or invalid target URLs.
-		if k == "url" || k == "format" {
+		if slices.Contains(controlParams, k) {
 			continue
 		}
 		hasChanges = true
@@ -480,60 +790,436 @@ func reconstructTargetURL(r *http.Request) string {
  * handler implements the core request processing pipeline.
  *
  * Flow:
+ * 0. Form POSTs: parameters in an urlencoded body are merged under the query string ones.
+ *    POSTs carrying the page itself (see isDocumentUpload) skip fetching and go the
+ *    way of ParseHandler.
  * 1. Reconstruct Target URL: Merges stray query parameters caused by Vercel rewrites.
  * 2. Determine Format: checks Query params > Accept header > User-Agent (LLM detection).
  * 3. Normalize & Validate: Ensures the target URL is valid and uses http/https.
  * 4. Fetch & Parse: Downloads the page (spoofing a browser) and extracts the main content.
+ *    Concurrent requests for the same article share one fetch (articleFlights), and cached
+ *    articles are served from their article.WarmCache renderings when the options allow it.
  * 5. Render: Converts the parsed article to a safe HTML buffer.
  * 6. Format: Outputs the result in the requested format (HTML, Markdown, JSON, etc.).
  */
 func handler(w http.ResponseWriter, r *http.Request) {
+	if err := mergeFormParams(w, r); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if isDocumentUpload(r) {
+		parseHandler(w, r)
+		return
+	}
 	format := getFormat(r)
-	formatter, found := formatters[format]
-	if !found {
-		writeError(w, http.StatusBadRequest, "invalid format")
+	opts, err := parseFormatOptions(r, format)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	timeout, err := parseTimeout(r.URL.Query().Get("timeout"), maxFetchTimeout())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	fetchOpts, err := parseFetchOptions(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if custom := r.URL.Query().Get("cache-key"); custom != "" && !cacheKeyPattern.MatchString(custom) {
+		response.Error(w, http.StatusBadRequest, "cache-key must be 1-256 characters of [a-zA-Z0-9._-]")
+		return
+	}
+
+	lc := reqlog.FromContext(r.Context())
+	lc.Format = format
+
 	rawLink := reconstructTargetURL(r)
-	log.Printf("request: %q %q", format, rawLink)
+	lc.ArticleURL = rawLink
 
 	link, err := normalizeAndValidateURL(rawLink)
 	if err != nil {
 		log.Printf("error normalizing URL %q: %v", rawLink, err)
-		writeError(w, http.StatusBadRequest, "Invalid URL provided")
+		response.Error(w, http.StatusBadRequest, "Invalid URL provided")
 		return
 	}
+	opts.Link = link
 
-	ctx, cancel := context.WithTimeout(r.Context(), handlerTimeout)
-	defer cancel()
+	key := articleCacheKey(link, r)
+	if custom := r.URL.Query().Get("cache-key"); custom != "" {
+		log.Printf("using custom cache key %q for %q", custom, rawLink)
+		key = custom
+	}
+
+	fetched, cached := articleCacheStore.Get(key)
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+		if serveRendered(w, r, key, format) {
+			return
+		}
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
 
-	article, err := fetchAndParse(ctx, link, r)
+		fetched, err = fetchArticle(ctx, key, link, r, fetchOpts)
+		if errors.Is(err, article.ErrJSRenderedPage) {
+			response.ErrorCode(w, http.StatusUnprocessableEntity, err.Error(), "JS_RENDERED_PAGE")
+			return
+		}
+		if errors.Is(err, article.ErrRobotsDisallowed) {
+			response.ErrorCode(w, http.StatusForbidden, err.Error(), "ROBOTS_DISALLOWED")
+			return
+		}
+		var antibotErr *article.AntibotError
+		if errors.As(err, &antibotErr) {
+			response.ErrorCode(w, http.StatusBadGateway, err.Error(), "BLOCKED_BY_ANTIBOT")
+			return
+		}
+		var statusErr *article.UpstreamStatusError
+		if errors.As(err, &statusErr) {
+			response.ErrorCode(w, http.StatusBadGateway, err.Error()+" and no archived copy was found", "UPSTREAM_ERROR")
+			return
+		}
+		if err != nil {
+			log.Printf("error fetching or parsing URL %q: %v", rawLink, err)
+			response.Error(w, http.StatusUnprocessableEntity, "Failed to process URL")
+			return
+		}
+	}
+
+	renderArticle(w, r, format, fetched, opts)
+}
+
+/**
+ * mergeFormParams lets POST requests with an application/x-www-form-urlencoded body
+ * (as sent by `curl -d url=...`) pass the parameters in the body.
+ *
+ * Body parameters are copied into the request query string unless the query string
+ * already has them, so the query string wins and everything downstream, including
+ * URL validation, handles both the same way. The `html` field holding an uploaded
+ * page (see isDocumentUpload) is left in the form.
+ */
+func mergeFormParams(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/x-www-form-urlencoded" {
+		return nil
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, article.MaxBodySize+maxFormSize)
+	if err := r.ParseForm(); err != nil {
+		return fmt.Errorf("invalid form body: %w", err)
+	}
+	query := r.URL.Query()
+	for key, values := range r.PostForm {
+		if key != "html" && !query.Has(key) {
+			query[key] = values
+		}
+	}
+	r.URL.RawQuery = query.Encode()
+	return nil
+}
+
+/**
+ * cacheKeyPattern restricts user-supplied cache keys to short, opaque tokens.
+ */
+var cacheKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,256}$`)
+
+/**
+ * articleCacheKey returns the automatic cache key for a request: the normalized URL,
+ * plus the options that change the extracted article or how it is fetched when set
+ * (selector, language or the client's Accept-Language, referer, fallback, prefer,
+ * ipv4-only, respect-robots and proxy).
+ */
+func articleCacheKey(link *url.URL, r *http.Request) string {
+	key := link.String()
+	if selector := r.URL.Query().Get("selector"); selector != "" {
+		key += " selector=" + selector
+		if queryFalse(r.URL.Query(), "readability") {
+			key += " readability=false"
+		}
+	}
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		key += " lang=" + lang
+	} else if lang := strings.TrimSpace(r.Header.Get("Accept-Language")); lang != "" {
+		// forwarded upstream, where it can select a translation
+		key += " accept-language=" + lang
+	}
+	if referer := r.URL.Query().Get("referer"); referer != "" {
+		key += " referer=" + referer
+	}
+	if fallback := r.URL.Query().Get("fallback"); fallback != "" {
+		key += " fallback=" + fallback
+	}
+	if prefer := r.URL.Query().Get("prefer"); prefer != "" {
+		key += " prefer=" + prefer
+	}
+	if queryBool(r.URL.Query(), "ipv4-only") {
+		key += " ipv4-only"
+	}
+	// articles fetched without checking robots.txt can't answer requests that ask for it
+	if queryBool(r.URL.Query(), "respect-robots") {
+		key += " respect-robots"
+	}
+	// a hash stands for the proxy, whose URL may hold credentials
+	if proxy := r.URL.Query().Get("proxy"); proxy != "" {
+		sum := sha256.Sum256([]byte(proxy))
+		key += " proxy=" + hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+/**
+ * parseFormatOptions validates the output-related query parameters for the given format.
+ *
+ * It is shared by every endpoint producing article output. Errors are meant to be
+ * shown to the client as a 400 response. The Link is left for the caller to fill.
+ */
+func parseFormatOptions(r *http.Request, format string) (formatter.Options, error) {
+	if _, found := formatter.Formatters[format]; !found {
+		return formatter.Options{}, errors.New("invalid format")
+	}
+
+	typo, err := formatter.ParseTypography(r.URL.Query())
 	if err != nil {
-		log.Printf("error fetching or parsing URL %q: %v", rawLink, err)
-		writeError(w, http.StatusUnprocessableEntity, "Failed to process URL")
-		return
+		return formatter.Options{}, err
+	}
+
+	theme, found := formatter.LookupTheme(cmp.Or(r.URL.Query().Get("theme"), formatter.DefaultTheme))
+	if !found {
+		return formatter.Options{}, errors.New("invalid theme")
+	}
+
+	parentID := r.URL.Query().Get("notion-parent-id")
+	if format == "notion-page" && !formatter.NotionIDPattern.MatchString(parentID) {
+		return formatter.Options{}, errors.New("notion-page requires notion-parent-id, the UUID of the parent page")
+	}
+
+	page, pageSize, err := parsePagination(r.URL.Query())
+	if err != nil {
+		return formatter.Options{}, err
+	}
+
+	var width int
+	if raw := r.URL.Query().Get("wrap"); raw != "" {
+		if width, err = strconv.Atoi(raw); err != nil || width < formatter.MinTextWidth || width > formatter.MaxTextWidth {
+			return formatter.Options{}, fmt.Errorf("wrap must be an integer between %d and %d", formatter.MinTextWidth, formatter.MaxTextWidth)
+		}
+	}
+
+	return formatter.Options{
+		Theme:          theme,
+		Typography:     typo,
+		Nonce:          cspNonce(r.Context()),
+		NoImages:       queryBool(r.URL.Query(), "no-images"),
+		NoLinks:        queryBool(r.URL.Query(), "no-links"),
+		EmbedImages:    queryBool(r.URL.Query(), "include-images-as-base64"),
+		Text:           formatter.TextOptions{PreserveWhitespace: queryFalse(r.URL.Query(), "dedupe-whitespace"), Width: width},
+		NotionParentID: parentID,
+		Page:           page,
+		PageSize:       pageSize,
+		EPUBCompat:     queryBool(r.URL.Query(), "epub-compat"),
+		Frontmatter:    queryBool(r.URL.Query(), "frontmatter"),
+	}, nil
+}
+
+/**
+ * parsePagination parses the `page` and `page-size` query parameters.
+ *
+ * Both are positive integers. page-size defaults to formatter.DefaultPageSize and is capped
+ * to formatter.MaxPageSize; setting it alone selects the first page. It returns a zero page
+ * when neither is set, which disables pagination.
+ */
+func parsePagination(q url.Values) (page, pageSize int, err error) {
+	rawPage, rawSize := q.Get("page"), q.Get("page-size")
+	if rawPage == "" && rawSize == "" {
+		return 0, 0, nil
+	}
+	page, pageSize = 1, formatter.DefaultPageSize
+	if rawPage != "" {
+		if page, err = strconv.Atoi(rawPage); err != nil || page < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+	}
+	if rawSize != "" {
+		if pageSize, err = strconv.Atoi(rawSize); err != nil || pageSize < 1 {
+			return 0, 0, errors.New("page-size must be a positive integer")
+		}
+	}
+	return page, min(pageSize, formatter.MaxPageSize), nil
+}
+
+/**
+ * queryBool reports whether the named query parameter holds a true value ("true", "1", ...).
+ */
+func queryBool(q url.Values, name string) bool {
+	v, err := strconv.ParseBool(q.Get(name))
+	return err == nil && v
+}
+
+/**
+ * queryFalse reports whether the named query parameter holds a false value ("false", "0", ...),
+ * for options that are on by default.
+ */
+func queryFalse(q url.Values, name string) bool {
+	v, err := strconv.ParseBool(q.Get(name))
+	return err == nil && !v
+}
+
+/**
+ * parseFetchOptions validates the extraction-related query parameters.
+ */
+func parseFetchOptions(r *http.Request) (article.Options, error) {
+	var opts article.Options
+	if raw := r.URL.Query().Get("selector"); raw != "" {
+		sel, err := cascadia.Parse(raw)
+		if err != nil {
+			return article.Options{}, fmt.Errorf("invalid selector: %w", err)
+		}
+		opts.Selector = sel
+	}
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		if !langPattern.MatchString(lang) {
+			return article.Options{}, errors.New("lang must be a BCP 47 language tag (e.g. fr, pt-BR)")
+		}
+		opts.AcceptLanguage = lang
+	}
+	opts.IPv4Only = queryBool(r.URL.Query(), "ipv4-only")
+	opts.RespectRobots = queryBool(r.URL.Query(), "respect-robots")
+	if queryFalse(r.URL.Query(), "readability") {
+		if opts.Selector == nil {
+			return article.Options{}, errors.New("readability=false requires a selector")
+		}
+		opts.SelectorOnly = true
+	}
+	switch fallback := r.URL.Query().Get("fallback"); fallback {
+	case "":
+	case "archive":
+		opts.ArchiveFallback = true
+	default:
+		return article.Options{}, errors.New("fallback must be archive")
+	}
+	switch prefer := r.URL.Query().Get("prefer"); prefer {
+	case "":
+	case "amp":
+		opts.PreferAMP = true
+	default:
+		return article.Options{}, errors.New("prefer must be amp")
+	}
+	if raw := r.URL.Query().Get("referer"); raw != "" {
+		referer, err := normalizeAndValidateURL(raw)
+		if err != nil {
+			return article.Options{}, fmt.Errorf("invalid referer: %w", err)
+		}
+		if referer.Scheme != "https" || referer.Host == "" {
+			return article.Options{}, errors.New("referer must be an https URL")
+		}
+		opts.Referer = referer.String()
+	}
+	if raw := r.URL.Query().Get("proxy"); raw != "" {
+		err := transport.VerifyProxySignature(os.Getenv("PROXY_SIGNING_KEY"), raw, r.URL.Query().Get("proxy-expires"), r.URL.Query().Get("proxy-signature"), time.Now())
+		if err != nil {
+			return article.Options{}, err
+		}
+		proxy, err := transport.ParseProxyURL(raw)
+		if err != nil {
+			return article.Options{}, err
+		}
+		opts.Proxy = proxy
+	}
+	return opts, nil
+}
+
+/**
+ * langPattern matches a BCP 47 language tag, which is all `?lang=` may contain.
+ * Anything else (spaces, commas, newlines) could smuggle extra header content.
+ */
+var langPattern = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+
+/**
+ * renderArticle renders the extracted article and writes it in the requested format.
+ *
+ * This is the final step shared by every endpoint, once the article is extracted.
+ */
+func renderArticle(w http.ResponseWriter, r *http.Request, format string, fetched article.FetchResult, opts formatter.Options) {
+	opts.Context = r.Context()
+	// transforms work on a copy, as the article may be shared through the cache
+	if (opts.NoImages || opts.NoLinks || opts.EmbedImages) && fetched.Node != nil {
+		fetched.Node = dom.CloneNode(fetched.Node)
+		if opts.NoImages {
+			formatter.StripImages(fetched.Node)
+		}
+		if opts.NoLinks {
+			formatter.UnwrapLinks(fetched.Node)
+		}
+		if opts.EmbedImages && !opts.NoImages {
+			if err := formatter.EmbedImages(r.Context(), fetched.Node, article.HTTPClient); err != nil {
+				log.Printf("error embedding images: %v", err)
+			}
+		}
 	}
 
 	contentBuf := &bytes.Buffer{}
-	if err := article.RenderHTML(contentBuf); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to render article content")
+	if err := fetched.RenderHTML(contentBuf); err != nil {
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
 		return
 	}
 
-	formatter(w, article, contentBuf)
+	// report how much was downloaded from upstream, regardless of the output format
+	w.Header().Set("X-Content-Length", strconv.FormatInt(fetched.BodySize, 10))
+	if !fetched.FetchedAt.IsZero() {
+		w.Header().Set("Last-Modified", fetched.FetchedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if opts.Page > 0 {
+		pages := formatter.Paginate(contentBuf, opts.PageSize)
+		w.Header().Set("X-Page-Count", strconv.Itoa(len(pages)))
+		w.Header().Set("X-Page-Size", strconv.Itoa(opts.PageSize))
+		if opts.Page > len(pages) {
+			response.ErrorCode(w, http.StatusNotFound, fmt.Sprintf("page %d not found, the article has %d", opts.Page, len(pages)), "PAGE_NOT_FOUND")
+			return
+		}
+		w.Header().Set("X-Page", strconv.Itoa(opts.Page))
+		// formatters read either the rendered content or the node, so both get the page
+		contentBuf = bytes.NewBufferString(pages[opts.Page-1].Content)
+		fetched.Node = dom.ParseFragment(pages[opts.Page-1].Content)
+	}
+
+	opts.Document = fetched.Document
+	opts.Archive, opts.Extractor = fetched.Archive, fetched.Extractor
+	if opts.Archive != nil {
+		w.Header().Set("X-Archive-Snapshot", opts.Archive.URL)
+	}
+	if fetched.AMP {
+		opts.AMPURL = fetched.URL
+		w.Header().Set("X-AMP-URL", fetched.URL.String())
+	}
+	if fetched.Document != nil {
+		opts.Canonical = meta.ExtractCanonicalURL(fetched.Document, opts.Link)
+	}
+	if opts.Canonical != nil && opts.Link != nil && !meta.SameSite(opts.Canonical, opts.Link) {
+		w.Header().Set("X-Canonical-URL-Changed", "true")
+	}
+
+	if format == "json" && debugEnabled(r) {
+		opts.Debug = article.Diagnose(fetched, contentBuf)
+		opts.Debug.FetchAttempts = reqlog.FromContext(r.Context()).FetchAttempts
+	}
+
+	formatter.Formatters[format](w, fetched.Article, contentBuf, opts)
 }
 
 /**
- * writeError writes a structured JSON error response.
+ * debugEnabled reports whether the request asked for diagnostics with `?debug=true`.
  *
- * It enforces a consistent error format {"error": "message"} across the API
- * and sets the correct HTTP status code and Content-Type header.
+ * The parameter is ignored unless the operator opted in with ENABLE_DEBUG_PARAM=true,
+ * since diagnostics reveal more about the upstream page than regular output.
  */
-func writeError(w http.ResponseWriter, status int, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(map[string]string{"error": msg}); err != nil {
-		log.Printf("error writing error response: %v", err)
-	}
+func debugEnabled(r *http.Request) bool {
+	return os.Getenv("ENABLE_DEBUG_PARAM") == "true" && r.URL.Query().Get("debug") == "true"
 }
@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"strings"
+	"strconv"
 	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
 )
 
 func TestNormalizeAndValidateURL(t *testing.T) {
@@ -40,50 +42,28 @@ func TestNormalizeAndValidateURL(t *testing.T) {
 	}
 }
 
-func TestFetchAndParse(t *testing.T) {
-	// Serve a minimal HTML page
-	htmlBody := `<html><head><title>Test Title</title></head><body><p>Hello World</p></body></html>`
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		if _, err := w.Write([]byte(htmlBody)); err != nil {
-			t.Errorf("failed to write response: %v", err)
-		}
-	}))
-	defer srv.Close()
-
-	// Override httpClient to use server's client
-	oldClient := httpClient
-	httpClient = srv.Client()
-	defer func() { httpClient = oldClient }()
-
-	u, err := url.Parse(srv.URL)
-	if err != nil {
-		t.Fatalf("failed to parse server URL: %v", err)
-	}
-	ctx := t.Context()
-	req := httptest.NewRequest("GET", "/", nil)
-	art, err := fetchAndParse(ctx, u, req)
-	if err != nil {
-		t.Fatalf("fetchAndParse returned error: %v", err)
-	}
-	if art.Title() != "Test Title" {
-		t.Errorf("Article.Title() = %q; want %q", art.Title(), "Test Title")
-	}
-
-	var content strings.Builder
-	err = art.RenderHTML(&content)
-	if err != nil {
-		t.Fatalf("failed to render article content: %v", err)
+func TestNormalizeAndValidateURLRejectedSchemes(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"data:text/html,<h1>hi</h1>", "data: URLs are not supported; provide a remote http/https URL"},
+		{"DATA:text/plain;base64,aGk=", "data: URLs are not supported; provide a remote http/https URL"},
+		{"javascript:alert(1)", "javascript: URLs are not supported; they run code instead of pointing at a page"},
+		{"vbscript:msgbox(1)", "vbscript: URLs are not supported; they run code instead of pointing at a page"},
+		{"file:///etc/passwd", "file: URLs are not supported; local files can't be read, provide a remote http/https URL"},
+		{"blob:https://example.com/550e8400", "blob: URLs are not supported; they only exist inside the browser that created them"},
+		{"gopher://example.com", "unsupported URL scheme"},
 	}
-
-	if !strings.Contains(content.String(), "<p>Hello World") {
-		t.Errorf("Article.Content missing expected paragraph, got: %q", content.String())
+	for _, tt := range tests {
+		_, err := normalizeAndValidateURL(tt.raw)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("normalizeAndValidateURL(%q) error = %v; want %q", tt.raw, err, tt.want)
+		}
 	}
 }
 
-func TestFetchAndParseRejectsOversizedBody(t *testing.T) {
-	// Body larger than maxBodySize must error, not parse a truncated page.
-	oversized := strings.Repeat("x", int(maxBodySize)+1)
-	htmlBody := "<html><head><title>Big</title></head><body><p>" + oversized + "</p></body></html>"
+func TestHandlerReportsUpstreamContentLength(t *testing.T) {
+	htmlBody := `<html><head><title>Sized</title></head><body><p>Hello World</p></body></html>`
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		if _, err := w.Write([]byte(htmlBody)); err != nil {
 			t.Errorf("failed to write response: %v", err)
@@ -91,66 +71,16 @@ func TestFetchAndParseRejectsOversizedBody(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	oldClient := httpClient
-	httpClient = srv.Client()
-	defer func() { httpClient = oldClient }()
-
-	u, err := url.Parse(srv.URL)
-	if err != nil {
-		t.Fatalf("failed to parse server URL: %v", err)
-	}
-	req := httptest.NewRequest("GET", "/", nil)
-	_, err = fetchAndParse(t.Context(), u, req)
-	if err == nil {
-		t.Fatal("fetchAndParse: expected error for oversized body, got nil")
-	}
-}
-
-/**
- * TestSSRFProtection confirms that the custom dialer correctly blocks connections
- * to private and loopback IP addresses.
- *
- * This is a critical security control to prevent the application from being used
- * as a proxy to attack internal infrastructure (SSRF).
- */
-func TestSSRFProtection(t *testing.T) {
-	// a dummy server that should never be reached
-	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
-		t.Fatal("dialer did not block private IP, connection was made")
-	}))
-	defer srv.Close()
-
-	// get loopback address of the server
-	// srv.URL will be something like http://127.0.0.1:54321
-	// we want to test if the dialer blocks the connection to 127.0.0.1
-	// so, we don't use the server's client, we use our own httpClient
-	req, err := http.NewRequest("GET", srv.URL, nil)
-	if err != nil {
-		t.Fatalf("failed to create request: %v", err)
-	}
-
-	_, err = httpClient.Do(req)
-	if err == nil {
-		t.Fatal("expected an error when dialing a private IP, but got none")
-	}
-	// check if the error is the one we expect from our dialer
-	// the error is wrapped, so we need to check for the substring
-	if !strings.Contains(err.Error(), "refusing to connect to private network address") {
-		t.Errorf("expected error to contain 'refusing to connect to private network address', but got: %v", err)
-	}
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
 
-	// Test Unspecified IP (0.0.0.0) bypass attempt
-	// We manually construct a URL with 0.0.0.0 and a port (it doesn't need to be open for the check to fire)
-	unspecifiedURL := "http://0.0.0.0:8080"
-	reqUnspecified, err := http.NewRequest("GET", unspecifiedURL, nil)
-	if err != nil {
-		t.Fatalf("failed to create request for unspecified IP: %v", err)
-	}
-	_, err = httpClient.Do(reqUnspecified)
-	if err == nil {
-		t.Fatal("expected an error when dialing 0.0.0.0, but got none")
-	}
-	if !strings.Contains(err.Error(), "refusing to connect to private network address") {
-		t.Errorf("expected error for 0.0.0.0 to contain 'refusing to connect to private network address', but got: %v", err)
+	for _, format := range []string{"html", "json", "md", "text"} {
+		req := httptest.NewRequest("GET", "/api?format="+format+"&url="+url.QueryEscape(srv.URL), nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if got, want := rec.Header().Get("X-Content-Length"), strconv.Itoa(len(htmlBody)); got != want {
+			t.Errorf("format %s: X-Content-Length = %q; want %q", format, got, want)
+		}
 	}
 }
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestBatchInstapaper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		canonical := ""
+		if r.URL.Path == "/amp" {
+			canonical = `<link rel="canonical" href="/original">`
+		}
+		page := fmt.Sprintf(`<html><head><title>Page %s</title>%s</head><body><article><p>%s</p></article></body></html>`,
+			r.URL.Path, canonical, strings.Repeat("Reading list body text. ", 10))
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	body := fmt.Sprintf(`{"format": "instapaper", "urls": [%q, %q, "ftp://example.com"]}`, srv.URL+"/first", srv.URL+"/amp")
+	rec := httptest.NewRecorder()
+	BatchHandler(rec, httptest.NewRequest("POST", "/api/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="reading-list.html"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	got := rec.Body.String()
+	if n := strings.Count(got, "<li>"); n != 2 {
+		t.Errorf("reading list has %d entries; want 2 (failed items are left out):\n%s", n, got)
+	}
+	first := strings.Index(got, `href="`+srv.URL+`/first"`)
+	second := strings.Index(got, `href="`+srv.URL+`/original"`)
+	if first < 0 || second < first {
+		t.Errorf("reading list does not link the items (canonical first) in order:\n%s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+const nextJSFixture = `<!DOCTYPE html><html><head><title>Next App</title>
+<script src="/_next/static/chunks/main.js" defer></script></head>
+<body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{}},"page":"/"}</script></body></html>`
+
+const craFixture = `<!DOCTYPE html><html><head><title>React App</title></head>
+<body><noscript>You need to enable JavaScript to run this app.</noscript><div id="root"></div>
+<script src="/static/js/main.js"></script></body></html>`
+
+func TestHandlerRejectsJSRenderedPages(t *testing.T) {
+	pages := map[string]string{"/next": nextJSFixture, "/cra": craFixture}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(pages[r.URL.Path])); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	for path := range pages {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+path), nil))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("%s: status = %d; want %d", path, rec.Code, http.StatusUnprocessableEntity)
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON error: %v", path, err)
+		}
+		if resp["error"] != "page requires JavaScript rendering" || resp["code"] != "JS_RENDERED_PAGE" {
+			t.Errorf("%s: error body = %v", path, resp)
+		}
+	}
+}
+
+func TestHandlerKeepsServerRenderedApps(t *testing.T) {
+	fetched := `<html><head><title>SSR</title></head><body><div id="__next"><article><h1>SSR</h1><p>` +
+		strings.Repeat("Server rendered pages carry the framework markers around real content. ", 10) +
+		`</p></article></div></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(fetched)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL), nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d; want %d, body: %q", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+func TestHandlerRendersJSPagesHeadless(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(craFixture)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer origin.Close()
+
+	var rendered []string
+	renderer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Method != "POST" {
+			t.Errorf("invalid render request: %s %v", r.Method, err)
+		}
+		var launch struct {
+			Args []string `json:"args"`
+		}
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("launch")), &launch); err != nil || !slices.Contains(launch.Args, "--proxy-server=http://10.0.0.2:8118") {
+			t.Errorf("browser launched with %v (%v); want it behind the proxy", launch.Args, err)
+		}
+		rendered = append(rendered, body.URL)
+		page := `<html><head><title>React App</title></head><body><div id="root"><article><p>` +
+			strings.Repeat("Content the scripts put in the page once they ran. ", 5) + `</p></article></div></body></html>`
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer renderer.Close()
+
+	// a public address, served by the origin test server
+	oldClient, oldEndpoint, oldProxy := article.HTTPClient, article.HeadlessEndpoint, article.HeadlessProxy
+	article.HTTPClient = &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, origin.Listener.Addr().String())
+	}}}
+	article.HeadlessEndpoint, article.HeadlessProxy = renderer.URL+"/content", "http://10.0.0.2:8118"
+	defer func() {
+		article.HTTPClient, article.HeadlessEndpoint, article.HeadlessProxy = oldClient, oldEndpoint, oldProxy
+	}()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape("http://203.0.113.10/app"), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %q", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if content, _ := resp["content"].(string); !strings.Contains(content, "once they ran") {
+		t.Errorf("content = %q; want the rendered page", content)
+	}
+	if len(rendered) != 1 || rendered[0] != "http://203.0.113.10/app" {
+		t.Errorf("rendered %v; want the article URL once", rendered)
+	}
+
+	// without the proxy, the browser would bypass the SSRF checks of the fetch
+	rendered, article.HeadlessProxy = nil, ""
+	rec = httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape("http://203.0.113.10/app"), nil))
+	if rec.Code != http.StatusUnprocessableEntity || len(rendered) != 0 {
+		t.Errorf("no proxy: status = %d, rendered %v; want 422 without rendering", rec.Code, rendered)
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestLangOverridesAcceptLanguage(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Accept-Language")
+		if _, err := w.Write([]byte("<html><head><title>Lang</title></head><body><p>Bonjour</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	tests := []struct {
+		name     string
+		lang     string
+		header   string
+		expected string
+	}{
+		{"lang param", "fr", "", "fr"},
+		{"lang with region supersedes client header", "pt-BR", "en-GB", "pt-BR"},
+		{"client header without lang", "", "de-DE", "de-DE"},
+		{"default", "", "", "en-US,en;q=0.9"},
+	}
+	for _, tt := range tests {
+		target := "/api?format=json&url=" + url.QueryEscape(srv.URL)
+		if tt.lang != "" {
+			target += "&lang=" + url.QueryEscape(tt.lang)
+		}
+		req := httptest.NewRequest("GET", target, nil)
+		if tt.header != "" {
+			req.Header.Set("Accept-Language", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %q", tt.name, rec.Code, rec.Body.String())
+		}
+		if got != tt.expected {
+			t.Errorf("%s: upstream Accept-Language = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestLangRejectsInjection(t *testing.T) {
+	for _, lang := range []string{"fr\r\nX-Injected: 1", "en-US,en;q=0.9", "fr fr", "toolongtag", "-fr", "fr-"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?url=example.com&lang="+url.QueryEscape(lang), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("lang %q: status = %d; want %d", lang, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -44,6 +44,7 @@ func TestGetFormat(t *testing.T) {
 		{"/api?url=...", "Mozilla/5.0", "application/json", "json"},
 		{"/api?url=...", "Mozilla/5.0", "text/markdown", "md"},
 		{"/api?url=...", "Mozilla/5.0", "text/plain", "text"},
+		{"/api?url=...", "curl/8.0", "text/x-ansi", "ansi"},
 		// Query param should override Accept
 		{"/api?url=...&format=txt", "Mozilla/5.0", "application/json", "txt"},
 	}
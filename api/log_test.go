@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestAccessLogFields(t *testing.T) {
+	htmlBody := `<html><head><title>Logged</title></head><body><p>Hello World</p></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// slow down the upstream so the fetch duration is measurable
+		time.Sleep(5 * time.Millisecond)
+		if _, err := w.Write([]byte(htmlBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	var logBuf bytes.Buffer
+	oldLogger := accessLogger
+	accessLogger = slog.New(slog.NewJSONHandler(&logBuf, nil))
+	defer func() { accessLogger = oldLogger }()
+
+	req := httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL), nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+
+	var event struct {
+		Msg             string `json:"msg"`
+		ArticleURL      string `json:"article_url"`
+		Format          string `json:"format"`
+		RequestID       string `json:"request_id"`
+		FetchDurationMs int64  `json:"fetch_duration_ms"`
+		ParseDurationMs *int64 `json:"parse_duration_ms"`
+		Status          int    `json:"status"`
+	}
+	if err := json.Unmarshal(logBuf.Bytes(), &event); err != nil {
+		t.Fatalf("access log is not a single JSON event: %v (%q)", err, logBuf.String())
+	}
+	if event.Msg != "request" {
+		t.Errorf("msg = %q; want %q", event.Msg, "request")
+	}
+	if event.ArticleURL != srv.URL {
+		t.Errorf("article_url = %q; want %q", event.ArticleURL, srv.URL)
+	}
+	if event.Format != "json" {
+		t.Errorf("format = %q; want %q", event.Format, "json")
+	}
+	if event.RequestID != "req-123" {
+		t.Errorf("request_id = %q; want %q", event.RequestID, "req-123")
+	}
+	if event.FetchDurationMs <= 0 {
+		t.Errorf("fetch_duration_ms = %d; want > 0", event.FetchDurationMs)
+	}
+	if event.ParseDurationMs == nil {
+		t.Error("parse_duration_ms missing")
+	}
+	if event.Status != http.StatusOK {
+		t.Errorf("status = %d; want %d", event.Status, http.StatusOK)
+	}
+}
+
+func TestAccessLogGeneratesRequestID(t *testing.T) {
+	var logBuf bytes.Buffer
+	oldLogger := accessLogger
+	accessLogger = slog.New(slog.NewJSONHandler(&logBuf, nil))
+	defer func() { accessLogger = oldLogger }()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=nope", nil))
+
+	var event struct {
+		RequestID string `json:"request_id"`
+		Status    int    `json:"status"`
+	}
+	if err := json.Unmarshal(logBuf.Bytes(), &event); err != nil {
+		t.Fatalf("invalid access log event: %v", err)
+	}
+	if event.RequestID == "" {
+		t.Error("request_id was not generated")
+	}
+	if event.Status != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d", event.Status, http.StatusBadRequest)
+	}
+}
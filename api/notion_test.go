@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/formatter"
+)
+
+func TestHandlerNotionPageRequiresParent(t *testing.T) {
+	for _, query := range []string{"", "&notion-parent-id=not-a-uuid"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?format=notion-page&url=example.com"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("query %q: status = %d; want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	formatter.FormatNotionPage(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), formatter.Options{})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("formatNotionPage without parent: status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"codeberg.org/readeck/go-readability/v2"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/formatter"
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func paginationContent(paragraphs int) string {
+	var sb strings.Builder
+	sb.WriteString(`<div id="readability-page-1" class="page">`)
+	for i := range paragraphs {
+		fmt.Fprintf(&sb, "<h2>Part %d</h2><p>%s é</p>\n", i, strings.Repeat("word ", 40))
+	}
+	sb.WriteString("<ul><li>One</li><li><p>Two</p></li></ul></div>")
+	return sb.String()
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query          string
+		page, pageSize int
+		wantErr        bool
+	}{
+		{"", 0, 0, false},
+		{"page=2", 2, formatter.DefaultPageSize, false},
+		{"page-size=500", 1, 500, false},
+		{"page=3&page-size=99999", 3, formatter.MaxPageSize, false},
+		{"page=0", 0, 0, true},
+		{"page=x", 0, 0, true},
+		{"page=1&page-size=-5", 0, 0, true},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		page, pageSize, err := parsePagination(q)
+		if (err != nil) != tt.wantErr || page != tt.page || pageSize != tt.pageSize {
+			t.Errorf("parsePagination(%q) = %d, %d, %v; want %d, %d (error %v)", tt.query, page, pageSize, err, tt.page, tt.pageSize, tt.wantErr)
+		}
+	}
+}
+
+func TestRenderArticlePage(t *testing.T) {
+	fetched := article.FetchResult{Article: readability.Article{Node: dom.ParseFragment(paginationContent(10))}}
+	total := len(formatter.Paginate(testutil.MustRenderHTML(t, fetched.Article), 500))
+	if total < 3 {
+		t.Fatalf("got %d pages; want several", total)
+	}
+
+	rec := httptest.NewRecorder()
+	renderArticle(rec, httptest.NewRequest("GET", "/api", nil), "text", fetched, formatter.Options{Page: 2, PageSize: 500})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Page"); got != "2" {
+		t.Errorf("X-Page = %q", got)
+	}
+	if got := rec.Header().Get("X-Page-Count"); got != fmt.Sprint(total) {
+		t.Errorf("X-Page-Count = %q; want %d", got, total)
+	}
+	if got := rec.Header().Get("X-Page-Size"); got != "500" {
+		t.Errorf("X-Page-Size = %q", got)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "Part 0") || utf8.RuneCountInString(body) > 500 {
+		t.Errorf("text output is not the second page: %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	renderArticle(rec, httptest.NewRequest("GET", "/api", nil), "text", fetched, formatter.Options{Page: total + 1, PageSize: 500})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d; want %d past the last page", rec.Code, http.StatusNotFound)
+	}
+}
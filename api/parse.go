@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/article"
+	reqlog "github.com/lucasew/readability-web/internal/log"
+	"github.com/lucasew/readability-web/internal/middleware"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/lucasew/readability-web/internal/transport"
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxUploadOverhead leaves room for multipart boundaries and the other form fields.
+	maxUploadOverhead = int64(64 * 1024)
+)
+
+/**
+ * uploadBaseURL is the placeholder page URL for uploaded documents without `?url=`,
+ * used by readability to resolve relative links.
+ */
+var uploadBaseURL = &url.URL{Scheme: "http", Host: "localhost", Path: "/"}
+
+/**
+ * ParseHandler is the Vercel Serverless Function serving `POST /api/parse`.
+ *
+ * It extracts the article from HTML sent by the client instead of fetching a URL,
+ * for pipelines that have local files or already downloaded pages. Accepted bodies:
+ * - multipart/form-data with the HTML in the `file` (or `html`) field and an optional
+ *   `format` field.
+ * - application/x-www-form-urlencoded with the HTML in the `html` field, as sent by
+ *   bookmarklets and browser extensions.
+ * - raw text/html or application/xhtml+xml.
+ *
+ * The optional `url` parameter (or form field) is the page's original URL, used to
+ * resolve relative links. Output goes through the same options and formatters as
+ * Handler, which also accepts these bodies (see isDocumentUpload).
+ */
+func ParseHandler(w http.ResponseWriter, r *http.Request) {
+	accessLogMiddleware(versionMiddleware(securityHeadersMiddleware(middleware.RateLimit(requestLimiter, http.HandlerFunc(parseHandler))))).ServeHTTP(w, r)
+}
+
+/**
+ * parseHandler implements the upload pipeline: read the document, extract, render.
+ */
+func parseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		response.Error(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var (
+		body   io.Reader
+		format = getFormat(r)
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		r.Body = http.MaxBytesReader(w, r.Body, article.MaxBodySize+maxUploadOverhead)
+		if err := r.ParseMultipartForm(article.MaxBodySize); err != nil {
+			log.Printf("error parsing multipart upload: %v", err)
+			response.Error(w, http.StatusBadRequest, "expected a multipart/form-data body with a file field")
+			return
+		}
+		defer func() {
+			if err := r.MultipartForm.RemoveAll(); err != nil {
+				log.Printf("error removing multipart temp files: %v", err)
+			}
+		}()
+		file, _, err := r.FormFile("file")
+		switch {
+		case err == nil:
+			defer file.Close()
+			body = file
+		case r.PostFormValue("html") != "":
+			body = strings.NewReader(r.PostFormValue("html"))
+		default:
+			response.Error(w, http.StatusBadRequest, "missing file field")
+			return
+		}
+		format = cmp.Or(r.PostFormValue("format"), format)
+	case "application/x-www-form-urlencoded":
+		r.Body = http.MaxBytesReader(w, r.Body, article.MaxBodySize+maxFormSize)
+		if err := r.ParseForm(); err != nil {
+			log.Printf("error parsing form upload: %v", err)
+			response.Error(w, http.StatusBadRequest, "invalid form body")
+			return
+		}
+		page := r.PostForm.Get("html")
+		if page == "" {
+			response.Error(w, http.StatusBadRequest, "missing html field")
+			return
+		}
+		body = strings.NewReader(page)
+		format = cmp.Or(r.PostForm.Get("format"), format)
+	case "text/html", "application/xhtml+xml":
+		body = r.Body
+	default:
+		response.Error(w, http.StatusBadRequest, "unsupported Content-Type, use text/html, application/xhtml+xml, multipart/form-data or application/x-www-form-urlencoded")
+		return
+	}
+
+	opts, err := parseFormatOptions(r, format)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fetchOpts, err := parseFetchOptions(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	link := uploadBaseURL
+	if rawLink := cmp.Or(r.URL.Query().Get("url"), r.PostForm.Get("url")); rawLink != "" {
+		if link, err = normalizeAndValidateURL(rawLink); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid URL provided")
+			return
+		}
+	}
+	opts.Link = link
+
+	lc := reqlog.FromContext(r.Context())
+	lc.Format = format
+	lc.ArticleURL = link.String()
+
+	node, size, err := readDocument(body)
+	if err != nil {
+		log.Printf("error reading uploaded document: %v", err)
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fetched, err := article.Extract(r.Context(), node, link, size, fetchOpts)
+	if errors.Is(err, article.ErrJSRenderedPage) {
+		response.ErrorCode(w, http.StatusUnprocessableEntity, err.Error(), "JS_RENDERED_PAGE")
+		return
+	}
+	if err != nil {
+		log.Printf("error parsing uploaded document: %v", err)
+		response.Error(w, http.StatusUnprocessableEntity, "Failed to process document")
+		return
+	}
+
+	renderArticle(w, r, format, fetched, opts)
+}
+
+/**
+ * isDocumentUpload reports whether r is a POST carrying the page to extract, which
+ * Handler then processes like ParseHandler: a text/html, application/xhtml+xml or
+ * multipart/form-data body, or a form (already parsed by mergeFormParams) with an
+ * `html` field.
+ */
+func isDocumentUpload(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "multipart/form-data":
+		return true
+	case "application/x-www-form-urlencoded":
+		return r.PostForm.Has("html")
+	}
+	return false
+}
+
+/**
+ * readDocument parses an HTML document of at most article.MaxBodySize bytes.
+ *
+ * It returns the number of bytes read so uploads are reported like fetched pages.
+ */
+func readDocument(r io.Reader) (*html.Node, int64, error) {
+	reader := transport.NewCountingReader(io.LimitReader(r, article.MaxBodySize+1))
+	node, err := html.Parse(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if reader.BytesRead() > article.MaxBodySize {
+		return nil, 0, errors.New("document exceeds the maximum size")
+	}
+	return node, reader.BytesRead(), nil
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+const uploadFixture = `<html><head><title>Uploaded Title</title></head><body><article><p>Uploaded body from a local file.</p></article></body></html>`
+
+func newUploadRequest(t *testing.T, fields map[string]string, file []byte) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatalf("failed to write field %q: %v", k, err)
+		}
+	}
+	if file != nil {
+		fw, err := mw.CreateFormFile("file", "article.html")
+		if err != nil {
+			t.Fatalf("failed to create file field: %v", err)
+		}
+		if _, err := fw.Write(file); err != nil {
+			t.Fatalf("failed to write file field: %v", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/api/parse", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestParseHandlerMultipart(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ParseHandler(rec, newUploadRequest(t, map[string]string{"format": "json"}, []byte(uploadFixture)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200, body: %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp["title"] != "Uploaded Title" {
+		t.Errorf("title = %v; want %q", resp["title"], "Uploaded Title")
+	}
+	if !strings.Contains(resp["content"].(string), "Uploaded body") {
+		t.Errorf("content = %v; want uploaded body", resp["content"])
+	}
+
+	rec = httptest.NewRecorder()
+	ParseHandler(rec, newUploadRequest(t, map[string]string{"format": "md"}, []byte(uploadFixture)))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/markdown" {
+		t.Errorf("Content-Type = %q; want text/markdown", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "Uploaded body from a local file.") {
+		t.Errorf("markdown output missing content: %q", rec.Body.String())
+	}
+}
+
+func TestParseHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"wrong method", httptest.NewRequest("GET", "/api/parse", nil), http.StatusMethodNotAllowed},
+		{"missing file", newUploadRequest(t, map[string]string{"format": "json"}, nil), http.StatusBadRequest},
+		{"invalid format", newUploadRequest(t, map[string]string{"format": "nope"}, []byte(uploadFixture)), http.StatusBadRequest},
+		{"oversized file", newUploadRequest(t, nil, bytes.Repeat([]byte("x"), int(article.MaxBodySize)+1)), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ParseHandler(rec, tt.req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d; want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestParseHandlerRawHTML(t *testing.T) {
+	const page = `<html><head><title>Raw Title</title></head><body><article><p>Raw body with a <a href="/relative">relative link</a>.</p></article></body></html>`
+	const xhtml = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>XHTML Title</title></head><body><article><p>XHTML body.</p></article></body></html>`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		query       string
+		wantTitle   string
+		wantContent string
+	}{
+		{"html", "text/html; charset=utf-8", page, "?format=json&url=" + "https://example.com/post", "Raw Title", `href="https://example.com/relative"`},
+		{"xhtml", "application/xhtml+xml", xhtml, "?format=json", "XHTML Title", "XHTML body."},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/parse"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		ParseHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; want 200, body: %q", tt.name, rec.Code, rec.Body.String())
+		}
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.name, err)
+		}
+		if resp["title"] != tt.wantTitle {
+			t.Errorf("%s: title = %v; want %q", tt.name, resp["title"], tt.wantTitle)
+		}
+		if !strings.Contains(resp["content"].(string), tt.wantContent) {
+			t.Errorf("%s: content = %v; want it to contain %q", tt.name, resp["content"], tt.wantContent)
+		}
+	}
+}
+
+func TestParseHandlerRawHTMLErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"wrong content type", "application/json", `{"html": "<p>hi</p>"}`},
+		{"missing content type", "", "<p>hi</p>"},
+		{"oversized body", "text/html", "<p>" + strings.Repeat("x", int(article.MaxBodySize)) + "</p>"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/parse", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		ParseHandler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d; want %d", tt.name, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandlerDocumentUpload(t *testing.T) {
+	const page = `<html><head><title>Posted Title</title></head><body><article><p>Posted body with a <a href="/relative">relative link</a>.</p></article></body></html>`
+	form := url.Values{"html": {page}, "url": {"https://example.com/post"}, "format": {"json"}}
+	multipartReq := newUploadRequest(t, map[string]string{"html": page, "format": "json"}, nil)
+	multipartReq.URL.Path = "/api"
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"raw html", httptest.NewRequest("POST", "/api?format=json&url=https://example.com/post", strings.NewReader(page))},
+		{"form html field", httptest.NewRequest("POST", "/api", strings.NewReader(form.Encode()))},
+		{"multipart html field", multipartReq},
+	}
+	tests[0].req.Header.Set("Content-Type", "text/html")
+	tests[1].req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler(rec, tt.req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d; want 200, body: %q", rec.Code, rec.Body.String())
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if resp["title"] != "Posted Title" {
+				t.Errorf("title = %v; want %q", resp["title"], "Posted Title")
+			}
+			if !strings.Contains(resp["content"].(string), "Posted body") {
+				t.Errorf("content = %v; want the posted body", resp["content"])
+			}
+		})
+	}
+}
+
+func TestParseHandlerFormErrors(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/parse", strings.NewReader("format=json&url=https://example.com"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	ParseHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d for a form without html", rec.Code, http.StatusBadRequest)
+	}
+}
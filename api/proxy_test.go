@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/transport"
+)
+
+func TestParseFetchOptionsProxy(t *testing.T) {
+	const proxy = "socks5h://127.0.0.1:9050"
+	expires := time.Now().Add(time.Hour)
+	signed := func(key, proxy string) string {
+		return "proxy=" + url.QueryEscape(proxy) + "&proxy-expires=" + strconv.FormatInt(expires.Unix(), 10) + "&proxy-signature=" + transport.SignProxy(key, proxy, expires)
+	}
+	expired := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name, key, query string
+		wantErr          bool
+	}{
+		{"signed", "secret", signed("secret", proxy), false},
+		{"no signing key", "", signed("secret", proxy), true},
+		{"wrong signature", "secret", signed("other", proxy), true},
+		{"unsigned", "secret", "proxy=" + url.QueryEscape(proxy), true},
+		{"no expiry", "secret", "proxy=" + url.QueryEscape(proxy) + "&proxy-signature=" + transport.SignProxy("secret", proxy, expires), true},
+		{"expired", "secret", "proxy=" + url.QueryEscape(proxy) + "&proxy-expires=" + strconv.FormatInt(expired.Unix(), 10) + "&proxy-signature=" + transport.SignProxy("secret", proxy, expired), true},
+		{"unsupported scheme", "secret", signed("secret", "ftp://proxy.example"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROXY_SIGNING_KEY", tt.key)
+			opts, err := parseFetchOptions(httptest.NewRequest("GET", "/api?"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFetchOptions() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (opts.Proxy == nil || opts.Proxy.String() != proxy) {
+				t.Errorf("Proxy = %v; want %s", opts.Proxy, proxy)
+			}
+		})
+	}
+}
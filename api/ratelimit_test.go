@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/middleware"
+)
+
+func useRateLimiter(t *testing.T, limit int, window time.Duration) {
+	t.Helper()
+	old := requestLimiter
+	requestLimiter = middleware.NewRateLimiter(limit, window)
+	t.Cleanup(func() { requestLimiter = old })
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	useRateLimiter(t, 3, time.Minute)
+
+	var reset string
+	for i, want := range []string{"2", "1", "0"} {
+		rec := httptest.NewRecorder()
+		ThemesHandler(rec, httptest.NewRequest("GET", "/api/themes", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d; want %d", i+1, rec.Code, http.StatusOK)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q; want 3", i+1, got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q; want %s", i+1, got, want)
+		}
+		got := rec.Header().Get("X-RateLimit-Reset")
+		unix, err := strconv.ParseInt(got, 10, 64)
+		if err != nil || unix < time.Now().Unix() || unix > time.Now().Add(time.Minute).Unix()+1 {
+			t.Errorf("request %d: X-RateLimit-Reset = %q; want a Unix time within the window", i+1, got)
+		}
+		if reset != "" && got != reset {
+			t.Errorf("request %d: X-RateLimit-Reset changed within a window: %q != %q", i+1, got, reset)
+		}
+		reset = got
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?url=example.com", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d; want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if rec.Header().Get("X-RateLimit-Remaining") != "0" || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("429 response missing quota headers: %v", rec.Header())
+	}
+
+	// other clients have their own quota
+	req := httptest.NewRequest("GET", "/api/themes", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	rec = httptest.NewRecorder()
+	ThemesHandler(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Remaining") != "2" {
+		t.Errorf("other client: status = %d, remaining = %q", rec.Code, rec.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	useRateLimiter(t, 0, time.Minute)
+	rec := httptest.NewRecorder()
+	ThemesHandler(rec, httptest.NewRequest("GET", "/api/themes", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("disabled limiter: status = %d, headers = %v", rec.Code, rec.Header())
+	}
+}
+
+func rateLimitStatus(t *testing.T, forwardedFor string) middleware.RateLimitStatus {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/ratelimit", nil)
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	var status middleware.RateLimitStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	return status
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	useRateLimiter(t, 5, 100*time.Millisecond)
+
+	first := rateLimitStatus(t, "203.0.113.9")
+	if first.Limit != 5 || first.Remaining != 5 || first.IP != "203.0.113.9" {
+		t.Fatalf("status before any request = %+v", first)
+	}
+	if again := rateLimitStatus(t, "203.0.113.9"); again.Remaining != 5 {
+		t.Errorf("status requests use the quota: remaining = %d", again.Remaining)
+	}
+
+	for range 2 {
+		req := httptest.NewRequest("GET", "/api", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.1")
+		Handler(httptest.NewRecorder(), req)
+	}
+	status := rateLimitStatus(t, "203.0.113.9")
+	if status.Remaining != 3 {
+		t.Errorf("remaining after two article requests = %d; want 3", status.Remaining)
+	}
+	reset, err := time.Parse(time.RFC3339, status.ResetAt)
+	if err != nil {
+		t.Fatalf("reset_at = %q: %v", status.ResetAt, err)
+	}
+	if other := rateLimitStatus(t, "198.51.100.1"); other.Remaining != 5 {
+		t.Errorf("other client remaining = %d; want 5", other.Remaining)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	after := rateLimitStatus(t, "203.0.113.9")
+	if after.Remaining != 5 {
+		t.Errorf("remaining after the window = %d; want 5", after.Remaining)
+	}
+	if next, err := time.Parse(time.RFC3339, after.ResetAt); err != nil || !next.After(reset) {
+		t.Errorf("reset_at after the window = %q; want later than %q", after.ResetAt, status.ResetAt)
+	}
+}
+
+func TestRateLimitStatusDisabled(t *testing.T) {
+	useRateLimiter(t, 0, time.Minute)
+	if status := rateLimitStatus(t, "203.0.113.9"); status.Limit != 0 || status.ResetAt != "" || status.IP != "203.0.113.9" {
+		t.Errorf("status = %+v", status)
+	}
+}
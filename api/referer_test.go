@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestRefererForwardedUpstream(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Values("Referer")
+		if _, err := w.Write([]byte("<html><head><title>Referer</title></head><body><p>Hello</p></body></html>")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	tests := []struct {
+		name     string
+		referer  string
+		header   string
+		expected string
+	}{
+		{"referer param", "https://www.google.com/", "", "https://www.google.com/"},
+		{"referer param without scheme", "www.google.com", "", "https://www.google.com"},
+		{"referer param wins over client header", "https://news.ycombinator.com/", "https://private.example/", "https://news.ycombinator.com/"},
+		{"client header is not forwarded", "", "https://private.example/", ""},
+		{"no referer", "", "", ""},
+	}
+	for _, tt := range tests {
+		target := "/api?format=json&url=" + url.QueryEscape(srv.URL)
+		if tt.referer != "" {
+			target += "&referer=" + url.QueryEscape(tt.referer)
+		}
+		req := httptest.NewRequest("GET", target, nil)
+		if tt.header != "" {
+			req.Header.Set("Referer", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d; body: %q", tt.name, rec.Code, rec.Body.String())
+		}
+		if tt.expected == "" {
+			if len(got) != 0 {
+				t.Errorf("%s: upstream Referer = %q; want none", tt.name, got)
+			}
+		} else if len(got) != 1 || got[0] != tt.expected {
+			t.Errorf("%s: upstream Referer = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestRefererRejectsInvalid(t *testing.T) {
+	for _, referer := range []string{"http://www.google.com/", "ftp://example.com/", "javascript:alert(1)", "https://"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?url=example.com&referer="+url.QueryEscape(referer), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("referer %q: status = %d; want %d", referer, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
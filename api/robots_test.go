@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func newRobotsServer(t *testing.T, status int, robots string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			_, err = w.Write([]byte(robots))
+		} else {
+			_, err = w.Write([]byte(`<html><head><title>Allowed</title></head><body><p>Hello World</p></body></html>`))
+		}
+		if err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestHandlerRespectRobots(t *testing.T) {
+	srv := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /\n")
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"&respect-robots=true", http.StatusForbidden},
+		{"", http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+"/post")+tt.query, nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("query %q: status = %d; want %d", tt.query, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestHandlerRespectRobotsCached(t *testing.T) {
+	useArticleCache(t, 10, time.Minute)
+	srv := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /\n")
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	// the article cached for the first request must not skip the robots.txt check
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusOK},
+		{"&respect-robots=true", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL+"/cached")+tt.query, nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("query %q: status = %d; want %d", tt.query, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+const selectorFixture = `<html><head><title>Selector Title</title></head><body>
+<div class="teaser"><p>Subscribe now to read this article, it is a very long teaser paragraph that readability may prefer over the real content because it is long.</p></div>
+<article class="paywall-bypass"><p>The real article body.</p></article>
+</body></html>`
+
+func TestHandlerSelector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(selectorFixture)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	req := httptest.NewRequest("GET", "/api?format=text&selector="+url.QueryEscape(".paywall-bypass")+"&url="+url.QueryEscape(srv.URL), nil)
+	rec := httptest.NewRecorder()
+	Handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200, body: %q", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "The real article body.") || strings.Contains(body, "Subscribe now") {
+		t.Errorf("selector was not applied, got: %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?selector="+url.QueryEscape("div[")+"&url=example.com", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid selector status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseFetchOptionsReadability(t *testing.T) {
+	for query, wantErr := range map[string]bool{
+		"readability=false&selector=.post": false,
+		"readability=true":                 false,
+		"readability=false":                true,
+	} {
+		opts, err := parseFetchOptions(httptest.NewRequest("GET", "/api?"+query, nil))
+		if (err != nil) != wantErr {
+			t.Errorf("parseFetchOptions(%q) error = %v; want error: %v", query, err, wantErr)
+		}
+		if want := query == "readability=false&selector=.post"; opts.SelectorOnly != want {
+			t.Errorf("parseFetchOptions(%q).SelectorOnly = %v; want %v", query, opts.SelectorOnly, want)
+		}
+	}
+}
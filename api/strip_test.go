@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+const imagesFixture = `<html><head><title>Images</title></head><body><article>
+<p>Intro paragraph with enough words to make readability keep this article content around.</p>
+<p><img src="https://example.com/inline.png" alt="inline"> Text next to an image.</p>
+<figure><img src="https://example.com/figure.png"></figure>
+<figure><picture><source srcset="https://example.com/pic.webp"><img src="https://example.com/pic.png"></picture><figcaption>Kept caption</figcaption></figure>
+<p>Closing paragraph with more words so the article is long enough to be extracted.</p>
+</article></body></html>`
+
+func TestHandlerNoImages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(imagesFixture)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	for _, format := range []string{"html", "md", "json"} {
+		for _, noImages := range []bool{false, true} {
+			target := "/api?format=" + format + "&url=" + url.QueryEscape(srv.URL)
+			if noImages {
+				target += "&no-images=true"
+			}
+			rec := httptest.NewRecorder()
+			Handler(rec, httptest.NewRequest("GET", target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: status = %d; body: %q", format, rec.Code, rec.Body.String())
+			}
+			hasImage := strings.Contains(rec.Body.String(), "inline.png")
+			if hasImage == noImages {
+				t.Errorf("format %s, no-images=%v: image present = %v, body: %q", format, noImages, hasImage, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestHandlerNoLinks(t *testing.T) {
+	const page = `<html><head><title>Links</title></head><body><article>
+<p>Intro paragraph with enough words to make readability keep this article content around.</p>
+<p>Read <a href="https://example.com/more">more <strong>here</strong></a> please.</p>
+</article></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	for _, format := range []string{"html", "md", "json"} {
+		rec := httptest.NewRecorder()
+		Handler(rec, httptest.NewRequest("GET", "/api?no-links=true&format="+format+"&url="+url.QueryEscape(srv.URL), nil))
+		body := rec.Body.String()
+		if strings.Contains(body, "example.com/more") || strings.Contains(body, "](") {
+			t.Errorf("format %s: link not removed: %q", format, body)
+		}
+		if !strings.Contains(body, "more") || !strings.Contains(body, "here") {
+			t.Errorf("format %s: link text lost: %q", format, body)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/formatter"
+)
+
+func TestReloadTemplateOnSIGHUP(t *testing.T) {
+	htmlBody := `<html><head><title>Reload</title></head><body><p>Reloaded body</p></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(htmlBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	path := filepath.Join(t.TempDir(), "article.html")
+	if err := os.WriteFile(path, []byte(`<p>before-reload</p>{{.Content}}`), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	t.Setenv("ARTICLE_TEMPLATE_PATH", path)
+
+	oldTemplate := formatter.SetTemplate(formatter.LoadTemplate(path))
+	defer formatter.SetTemplate(oldTemplate)
+
+	if err := os.WriteFile(path, []byte(`<p>after-reload</p>{{.Content}}`), 0o644); err != nil {
+		t.Fatalf("failed to rewrite template: %v", err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to send SIGHUP: %v", err)
+	}
+
+	// the reload happens asynchronously, so poll until the new template is served
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		req := httptest.NewRequest("GET", "/api?format=html&url="+url.QueryEscape(srv.URL), nil)
+		rec := httptest.NewRecorder()
+		Handler(rec, req)
+		body := rec.Body.String()
+		if strings.Contains(body, "after-reload") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("template was not reloaded after SIGHUP, got: %q", body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/lucasew/readability-web/internal/formatter"
+	"github.com/lucasew/readability-web/internal/middleware"
+)
+
+/**
+ * ThemesHandler is the Vercel Serverless Function serving `/api/themes`.
+ *
+ * It lists the built-in themes accepted by the `theme` query parameter,
+ * so clients can build a theme picker without hardcoding the list.
+ */
+func ThemesHandler(w http.ResponseWriter, r *http.Request) {
+	securityHeadersMiddleware(middleware.RateLimit(requestLimiter, http.HandlerFunc(themesHandler))).ServeHTTP(w, r)
+}
+
+/**
+ * themesHandler writes the available themes as JSON.
+ */
+func themesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string][]formatter.ThemeEntry{
+		"themes": formatter.AvailableThemes(),
+	}); err != nil {
+		log.Printf("error encoding themes: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/formatter"
+)
+
+func TestThemesHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ThemesHandler(rec, httptest.NewRequest("GET", "/api/themes", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+
+	var resp struct {
+		Themes []formatter.ThemeEntry `json:"themes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	got := map[string]string{}
+	for _, theme := range resp.Themes {
+		got[theme.Name] = theme.PreviewURL
+	}
+	for _, name := range []string{"sakura", "dark", "water", "classless", "none"} {
+		previewURL, ok := got[name]
+		if !ok {
+			t.Errorf("theme %q missing from /api/themes", name)
+			continue
+		}
+		if name != "none" && !strings.HasPrefix(previewURL, "https://") {
+			t.Errorf("theme %q preview_url = %q; want an https URL", name, previewURL)
+		}
+	}
+}
+
+func TestHandlerRejectsUnknownTheme(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?url=example.com&theme=comic-sans", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}
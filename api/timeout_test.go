@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestParseTimeout(t *testing.T) {
+	const limit = 3 * time.Minute
+	tests := []struct {
+		raw       string
+		want      time.Duration
+		shouldErr bool
+	}{
+		{"", handlerTimeout, false},
+		{"5s", 5 * time.Second, false},
+		{"2m", 2 * time.Minute, false},
+		{"90s", 90 * time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"10", 10 * time.Second, false},
+		{"10m", limit, false},
+		{"3600", limit, false},
+		{"soon", 0, true},
+		{"5 s", 0, true},
+		{"0", 0, true},
+		{"-5s", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeout(tt.raw, limit)
+		if tt.shouldErr {
+			if err == nil {
+				t.Errorf("parseTimeout(%q) expected error, got %v", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeout(%q) unexpected error: %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTimeout(%q) = %v; want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestMaxFetchTimeout(t *testing.T) {
+	t.Setenv("MAX_FETCH_TIMEOUT", "")
+	if got := maxFetchTimeout(); got != defaultMaxFetchTimeout {
+		t.Errorf("maxFetchTimeout() default = %v; want %v", got, defaultMaxFetchTimeout)
+	}
+	t.Setenv("MAX_FETCH_TIMEOUT", "20s")
+	if got := maxFetchTimeout(); got != 20*time.Second {
+		t.Errorf("maxFetchTimeout() = %v; want 20s", got)
+	}
+	t.Setenv("MAX_FETCH_TIMEOUT", "bogus")
+	if got := maxFetchTimeout(); got != defaultMaxFetchTimeout {
+		t.Errorf("maxFetchTimeout() with invalid value = %v; want %v", got, defaultMaxFetchTimeout)
+	}
+}
+
+func TestHandlerRejectsInvalidTimeout(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?url=example.com&timeout=forever", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d; want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFetchTimeoutAboveDefault(t *testing.T) {
+	// only the request's deadline bounds a fetch, so MAX_FETCH_TIMEOUT can exceed the default
+	if article.HTTPClient.Timeout != 0 {
+		t.Errorf("httpClient.Timeout = %v; want none, as it would override longer ?timeout= values", article.HTTPClient.Timeout)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+	defer waitForFlights(t)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?timeout=100ms&url="+srv.URL+"/slow", nil))
+	if rec.Code == http.StatusOK {
+		t.Errorf("status = %d; want the fetch to time out", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took %v; want it bounded by ?timeout=100ms", elapsed)
+	}
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+/**
+ * VersionHandler is the Vercel Serverless Function serving `/api/version`
+ * (also reachable as `/version`).
+ *
+ * It reports the deployed build (see buildVersion), for operators checking
+ * which version a deployment runs.
+ */
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+	versionMiddleware(securityHeadersMiddleware(http.HandlerFunc(versionHandler))).ServeHTTP(w, r)
+}
+
+/**
+ * versionHandler writes the build version and time as JSON.
+ */
+func versionHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"version":  buildVersion,
+		"built_at": buildTime,
+	}); err != nil {
+		log.Printf("error encoding version: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/article"
+)
+
+func TestHandlerReportsVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(`<html><head><title>Versioned</title></head><body><p>Hello World</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := article.HTTPClient
+	article.HTTPClient = srv.Client()
+	defer func() { article.HTTPClient = oldClient }()
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest("GET", "/api?format=json&url="+url.QueryEscape(srv.URL), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+	if got := rec.Header().Get("X-Article-Parser-Version"); got == "" || got != buildVersion {
+		t.Errorf("X-Article-Parser-Version = %q; want %q", got, buildVersion)
+	}
+}
+
+func TestVersionHandler(t *testing.T) {
+	oldVersion, oldTime := buildVersion, buildTime
+	buildVersion, buildTime = "v1.2.3", "2026-01-02T15:04:05Z"
+	defer func() { buildVersion, buildTime = oldVersion, oldTime }()
+
+	rec := httptest.NewRecorder()
+	VersionHandler(rec, httptest.NewRequest("GET", "/api/version", nil))
+	if got := rec.Header().Get("X-Article-Parser-Version"); got != "v1.2.3" {
+		t.Errorf("X-Article-Parser-Version = %q; want v1.2.3", got)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp["version"] != "v1.2.3" || resp["built_at"] != "2026-01-02T15:04:05Z" {
+		t.Errorf("version response = %v", resp)
+	}
+}
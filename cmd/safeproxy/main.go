@@ -0,0 +1,29 @@
+/**
+ * Command safeproxy runs transport.ForwardProxy, the proxy HEADLESS_RENDER_PROXY
+ * points the headless browser at, so the pages it renders (and everything they
+ * load or navigate to) can't reach private networks.
+ *
+ * It proxies to any public address for anyone who can connect, so it listens on
+ * SAFE_PROXY_ADDR (default 127.0.0.1:8118), which only the browser should reach.
+ */
+package main
+
+import (
+	"cmp"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/transport"
+)
+
+func main() {
+	server := &http.Server{
+		Addr:              cmp.Or(os.Getenv("SAFE_PROXY_ADDR"), "127.0.0.1:8118"),
+		Handler:           &transport.ForwardProxy{},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("proxying on %s", server.Addr)
+	log.Fatal(server.ListenAndServe())
+}
@@ -4,13 +4,12 @@ go 1.25.0
 
 require (
 	codeberg.org/readeck/go-readability/v2 v2.1.2
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/mattn/godown v0.0.1
 	golang.org/x/net v0.55.0
 )
 
 require (
-	github.com/andybalholm/cascadia v1.3.3 // indirect
-	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de // indirect
 	github.com/clipperhouse/stringish v0.1.1 // indirect
 	github.com/clipperhouse/uax29/v2 v2.3.0 // indirect
 	github.com/go-shiori/dom v0.0.0-20230515143342-73569d674e1c // indirect
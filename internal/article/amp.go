@@ -0,0 +1,70 @@
+package article
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/transport"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/**
+ * ExtractAMPURL returns the AMP version a page links to with <link rel="amphtml">,
+ * resolved against base, or nil when there is none. Like for meta refreshes, links
+ * to the page itself, to other schemes than http(s) and to refused IP addresses
+ * are ignored.
+ */
+func ExtractAMPURL(node *html.Node, base *url.URL) *url.URL {
+	head := dom.FindElement(node, "head")
+	if head == nil {
+		return nil
+	}
+	var href string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" && href == "" && slices.Contains(strings.Fields(strings.ToLower(dom.Attr(n, "rel"))), "amphtml") {
+			href = strings.TrimSpace(dom.Attr(n, "href"))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(head)
+	if href == "" {
+		return nil
+	}
+	target, err := base.Parse(href)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil
+	}
+	target.Fragment = ""
+	if target.String() == base.String() {
+		return nil
+	}
+	if ip := net.ParseIP(target.Hostname()); ip != nil && transport.IsBlockedIP(ip) {
+		return nil
+	}
+	return target
+}
+
+/**
+ * ConvertAMPImages turns the <amp-img> elements of an AMP page into the <img>
+ * elements readability knows, keeping their attributes. Their children (the
+ * <noscript> fallbacks) are dropped.
+ */
+func ConvertAMPImages(node *html.Node) {
+	if node.Type == html.ElementNode && node.Data == "amp-img" {
+		node.Data, node.DataAtom = "img", atom.Img
+		for node.FirstChild != nil {
+			node.RemoveChild(node.FirstChild)
+		}
+		return
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		ConvertAMPImages(c)
+	}
+}
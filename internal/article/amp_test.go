@@ -0,0 +1,54 @@
+package article
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+func TestExtractAMPURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/post")
+	tests := []struct {
+		name, head, want string
+	}{
+		{"absolute", `<link rel="amphtml" href="https://amp.example.com/post">`, "https://amp.example.com/post"},
+		{"relative", `<link rel="AMPHTML" href="/post/amp#top">`, "https://example.com/post/amp"},
+		{"self", `<link rel="amphtml" href="/post">`, ""},
+		{"private address", `<link rel="amphtml" href="http://127.0.0.1/post">`, ""},
+		{"other scheme", `<link rel="amphtml" href="javascript:alert(1)">`, ""},
+		{"none", `<link rel="canonical" href="/post">`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tt.head + "</head><body></body></html>"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if u := ExtractAMPURL(doc, base); u != nil {
+				got = u.String()
+			}
+			if got != tt.want {
+				t.Errorf("ExtractAMPURL() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertAMPImages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<p><amp-img src="a.png" alt="A" width="10" height="10"><noscript><img src="a.png"></noscript></amp-img></p>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ConvertAMPImages(doc)
+	var sb strings.Builder
+	if err := html.Render(&sb, dom.FindElement(doc, "p")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), `<p><img src="a.png" alt="A" width="10" height="10"/></p>`; got != want {
+		t.Errorf("ConvertAMPImages() = %q; want %q", got, want)
+	}
+}
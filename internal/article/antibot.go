@@ -0,0 +1,63 @@
+package article
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * AntibotError is returned by fetchDocument when the upstream answered with the
+ * challenge or block page of a bot protection service instead of the article.
+ */
+type AntibotError struct {
+	Vendor string
+}
+
+func (e *AntibotError) Error() string {
+	return fmt.Sprintf("blocked by the %s bot protection", e.Vendor)
+}
+
+/**
+ * antibotFingerprints identify the challenge pages of bot protection services,
+ * by their <title> or by elements only those pages carry. Scripts the services
+ * inject in regular pages are deliberately not fingerprints.
+ */
+var antibotFingerprints = []struct {
+	Vendor   string
+	Titles   []string
+	Selector cascadia.Selector
+}{
+	{"Cloudflare", []string{"Just a moment...", "Attention Required! | Cloudflare"}, cascadia.MustCompile("#challenge-form, #challenge-running, #cf-challenge-running")},
+	{"PerimeterX", []string{"Access to this page has been denied"}, cascadia.MustCompile(`#px-captcha, script[src*="captcha.px-cdn.net"]`)},
+	{"Akamai", nil, cascadia.MustCompile(`#sec-if-cpt-container, script[src*="/_sec/cp_challenge/"]`)},
+}
+
+/**
+ * DetectAntibot returns the name of the bot protection service whose challenge
+ * page doc is, or "" for anything else. Besides antibotFingerprints, it honors
+ * Cloudflare's `cf-mitigated: challenge` header and Akamai's "Access Denied" page.
+ */
+func DetectAntibot(header http.Header, doc *html.Node) string {
+	if header.Get("Cf-Mitigated") == "challenge" {
+		return "Cloudflare"
+	}
+	var title string
+	if n := dom.FindElement(doc, "title"); n != nil {
+		title = strings.TrimSpace(dom.TextContent(n))
+	}
+	for _, fp := range antibotFingerprints {
+		if slices.ContainsFunc(fp.Titles, func(t string) bool { return strings.HasPrefix(title, t) }) || fp.Selector.MatchFirst(doc) != nil {
+			return fp.Vendor
+		}
+	}
+	if title == "Access Denied" && (header.Get("Server") == "AkamaiGHost" || strings.Contains(dom.TextContent(doc), "errors.edgesuite.net")) {
+		return "Akamai"
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package article
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDetectAntibot(t *testing.T) {
+	tests := []struct {
+		name, page string
+		header     http.Header
+		want       string
+	}{
+		{"cloudflare header", `<title>Loading</title>`, http.Header{"Cf-Mitigated": {"challenge"}}, "Cloudflare"},
+		{"cloudflare title", `<title>Just a moment...</title>`, http.Header{}, "Cloudflare"},
+		{"cloudflare form", `<form id="challenge-form" action="/"></form>`, http.Header{}, "Cloudflare"},
+		{"perimeterx", `<title>Access to this page has been denied.</title><div id="px-captcha"></div>`, http.Header{}, "PerimeterX"},
+		{"akamai challenge", `<div id="sec-if-cpt-container"></div>`, http.Header{}, "Akamai"},
+		{"akamai denied", `<title>Access Denied</title><p>Reference #18.1 https://errors.edgesuite.net/18.1</p>`, http.Header{}, "Akamai"},
+		{"access denied elsewhere", `<title>Access Denied</title>`, http.Header{}, ""},
+		{"cloudflare scripts in an article", `<title>Post</title><script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script>`, http.Header{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectAntibot(tt.header, doc); got != tt.want {
+				t.Errorf("DetectAntibot() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * ArchiveSnapshot identifies the Wayback Machine copy an article was read from
+ * when `?fallback=archive` replaced the origin page.
+ */
+type ArchiveSnapshot struct {
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var (
+	// WaybackAvailabilityURL is the Wayback Machine API finding the closest snapshot of a URL.
+	WaybackAvailabilityURL = "https://archive.org/wayback/available"
+	// WaybackSnapshotURL prefixes the "<timestamp>id_/<url>" path of an unmodified snapshot.
+	WaybackSnapshotURL = "https://web.archive.org/web/"
+)
+
+/**
+ * UpstreamStatusError is returned by fetchDocument, when archive fallback is on,
+ * for the responses archiveFallbackStatus selects.
+ */
+type UpstreamStatusError struct {
+	Status int
+}
+
+func (e *UpstreamStatusError) Error() string {
+	return fmt.Sprintf("upstream responded with status %d", e.Status)
+}
+
+/**
+ * archiveFallbackStatus reports whether an upstream response status makes
+ * `?fallback=archive` look for an archived copy: 403, 404, 410 and 5xx.
+ */
+func archiveFallbackStatus(status int) bool {
+	return status == http.StatusForbidden || status == http.StatusNotFound || status == http.StatusGone || status >= 500
+}
+
+/**
+ * isPaywalled reports whether the page declares its content as paywalled, through
+ * the `"isAccessibleForFree": false` JSON-LD property publishers set for search engines.
+ */
+func isPaywalled(doc *html.Node) bool {
+	var walk func(v any) bool
+	walk = func(v any) bool {
+		switch v := v.(type) {
+		case map[string]any:
+			switch free := v["isAccessibleForFree"].(type) {
+			case bool:
+				if !free {
+					return true
+				}
+			case string:
+				if strings.EqualFold(free, "false") {
+					return true
+				}
+			}
+			for _, child := range v {
+				if walk(child) {
+					return true
+				}
+			}
+		case []any:
+			return slices.ContainsFunc(v, walk)
+		}
+		return false
+	}
+	return slices.ContainsFunc(meta.ExtractJSONLD(doc), walk)
+}
+
+/**
+ * FindArchiveSnapshot asks the Wayback Machine for its closest snapshot of link.
+ * It returns nil, without an error, when the page was never archived.
+ */
+func FindArchiveSnapshot(ctx context.Context, link *url.URL, client *http.Client) (*ArchiveSnapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", WaybackAvailabilityURL+"?url="+url.QueryEscape(link.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wayback machine responded with status %d", res.StatusCode)
+	}
+	var availability struct {
+		ArchivedSnapshots struct {
+			Closest *struct {
+				Available bool   `json:"available"`
+				Status    string `json:"status"`
+				Timestamp string `json:"timestamp"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxArchiveResponseSize)).Decode(&availability); err != nil {
+		return nil, err
+	}
+	closest := availability.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.Status != "200" {
+		return nil, nil
+	}
+	timestamp, err := time.Parse("20060102150405", closest.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot timestamp %q: %w", closest.Timestamp, err)
+	}
+	// the id_ flag serves the page as archived, without the Wayback Machine's toolbar and rewritten links
+	return &ArchiveSnapshot{URL: WaybackSnapshotURL + closest.Timestamp + "id_/" + link.String(), Timestamp: timestamp}, nil
+}
+
+/**
+ * fetchArchived fetches and parses the Wayback Machine snapshot of link for
+ * Fetch. As snapshots keep the page's own links, the article is resolved
+ * against link rather than the snapshot URL.
+ */
+func fetchArchived(ctx context.Context, link *url.URL, r *http.Request, opts Options) (FetchResult, error) {
+	snapshot, err := FindArchiveSnapshot(ctx, link, HTTPClient)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	if snapshot == nil {
+		return FetchResult{}, errors.New("no archived copy found")
+	}
+	target, err := url.Parse(snapshot.URL)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	// the origin's robots.txt was already checked; the referer was meant for it
+	opts.RespectRobots, opts.Referer, opts.ArchiveFallback = false, "", false
+	node, size, err := fetchDocument(ctx, target, r, opts)
+	if err != nil {
+		return FetchResult{}, err
+	}
+	article, err := Extract(ctx, node, link, size, opts)
+	article.Archive = snapshot
+	return article, err
+}
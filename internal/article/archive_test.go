@@ -0,0 +1,32 @@
+package article
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestIsPaywalled(t *testing.T) {
+	tests := []struct {
+		name, ldJSON string
+		want         bool
+	}{
+		{"free", `{"@type":"NewsArticle","isAccessibleForFree":true}`, false},
+		{"paywalled", `{"@type":"NewsArticle","isAccessibleForFree":false}`, true},
+		{"string value", `{"@type":"NewsArticle","isAccessibleForFree":"False"}`, true},
+		{"nested in graph", `{"@graph":[{"@type":"WebPage"},{"@type":"Article","isAccessibleForFree":false}]}`, true},
+		{"no property", `{"@type":"Article"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(`<script type="application/ld+json">` + tt.ldJSON + `</script>`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := isPaywalled(doc); got != tt.want {
+				t.Errorf("isPaywalled() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
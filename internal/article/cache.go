@@ -0,0 +1,187 @@
+package article
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/response"
+)
+
+/**
+ * lru is an in-memory LRU cache with a fixed entry lifetime, holding up to size
+ * values. It stores the articles of CacheStore and the pages of the memory PageCache.
+ */
+type lru[V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+// lruEntry is an element of lru.ll.
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+/**
+ * newLRU creates an lru holding up to size values for ttl each.
+ * A size of zero or less disables caching.
+ */
+func newLRU[V any](size int, ttl time.Duration) *lru[V] {
+	return &lru[V]{size: size, ttl: ttl, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+/**
+ * get returns the value stored under key, if present and not expired.
+ */
+func (c *lru[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[V])
+	if time.Now().After(entry.expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+/**
+ * add stores value under key, evicting the least recently used value when full.
+ */
+func (c *lru[V]) add(key string, value V) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &lruEntry[V]{key: key, value: value, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+/**
+ * CacheStore is an in-memory LRU cache of extracted articles with a fixed entry lifetime.
+ *
+ * It lives as long as the function instance, so warm instances can skip refetching
+ * popular pages. Cached articles are shared between requests and must not be mutated.
+ */
+type CacheStore struct {
+	entries *lru[*cacheEntry]
+	// mu guards the rendered maps of the entries.
+	mu sync.Mutex
+}
+
+// cacheEntry is an article stored in CacheStore, with its WarmCache renderings.
+type cacheEntry struct {
+	result   FetchResult
+	rendered map[string]*response.Buffered
+}
+
+/**
+ * NewCacheStore creates a cache holding up to size entries for ttl each.
+ * A size of zero or less disables caching.
+ */
+func NewCacheStore(size int, ttl time.Duration) *CacheStore {
+	return &CacheStore{entries: newLRU[*cacheEntry](size, ttl)}
+}
+
+/**
+ * Get returns the cached article for key, if present and not expired.
+ */
+func (c *CacheStore) Get(key string) (FetchResult, bool) {
+	entry, ok := c.entries.get(key)
+	if !ok {
+		return FetchResult{}, false
+	}
+	return entry.result, true
+}
+
+/**
+ * Add stores result under key, evicting the least recently used entry when full.
+ */
+func (c *CacheStore) Add(key string, result FetchResult) {
+	c.entries.add(key, &cacheEntry{result: result})
+}
+
+/**
+ * SetRendered stores a rendering of the article cached under key in the given
+ * format (see WarmCache). It is dropped along with the article.
+ */
+func (c *CacheStore) SetRendered(key, format string, rendered *response.Buffered) {
+	entry, ok := c.entries.get(key)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.rendered == nil {
+		entry.rendered = map[string]*response.Buffered{}
+	}
+	entry.rendered[format] = rendered
+}
+
+/**
+ * Rendered returns the rendering of the article cached under key in the given format,
+ * if WarmCache stored one and the article has not expired.
+ */
+func (c *CacheStore) Rendered(key, format string) (*response.Buffered, bool) {
+	entry, ok := c.entries.get(key)
+	if !ok {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rendered, ok := entry.rendered[format]
+	return rendered, ok
+}
+
+/**
+ * warmFormats are the formats WarmCache renders ahead of time.
+ */
+var warmFormats = []string{"html", "md", "json", "text"}
+
+/**
+ * Renderer writes result in format, as the handler answers a request for that
+ * format with the default options.
+ *
+ * Rendering lives above this package (it needs the formatters), so WarmCache
+ * takes it from the caller.
+ */
+type Renderer func(w http.ResponseWriter, format string, result FetchResult)
+
+/**
+ * WarmCache renders result in each of warmFormats and stores the successful
+ * renderings next to the article cached under key, so requests for other formats
+ * of a popular URL skip rendering too. It is meant to run in the background after
+ * a fetch.
+ */
+func WarmCache(key string, result FetchResult, cache *CacheStore, render Renderer) {
+	for _, format := range warmFormats {
+		rec := response.NewBuffered()
+		render(rec, format, result)
+		if rec.Status == http.StatusOK {
+			cache.SetRendered(key, format, rec)
+		}
+	}
+}
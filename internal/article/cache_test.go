@@ -0,0 +1,86 @@
+package article
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCacheStoreLRU(t *testing.T) {
+	c := NewCacheStore(2, time.Minute)
+	c.Add("a", FetchResult{BodySize: 1})
+	c.Add("b", FetchResult{BodySize: 2})
+	if _, ok := c.Get("a"); !ok { // "a" becomes the most recently used
+		t.Fatal("expected a to be cached")
+	}
+	c.Add("c", FetchResult{BodySize: 3})
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted as least recently used")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+
+	expiring := NewCacheStore(2, -time.Second)
+	expiring.Add("a", FetchResult{})
+	if _, ok := expiring.Get("a"); ok {
+		t.Error("expected expired entry to be dropped")
+	}
+
+	disabled := NewCacheStore(0, time.Minute)
+	disabled.Add("a", FetchResult{})
+	if _, ok := disabled.Get("a"); ok {
+		t.Error("expected a zero-sized cache to store nothing")
+	}
+}
+
+func TestWarmCache(t *testing.T) {
+	c := NewCacheStore(10, time.Minute)
+	result := FetchResult{BodySize: 42}
+	c.Add("warm", result)
+
+	var rendered []string
+	WarmCache("warm", result, c, func(w http.ResponseWriter, format string, got FetchResult) {
+		rendered = append(rendered, format)
+		if got.BodySize != result.BodySize {
+			t.Errorf("%s: rendered the wrong result: %+v", format, got)
+		}
+		if format == "text" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "x-test/"+format)
+		if _, err := w.Write([]byte(format + " body")); err != nil {
+			t.Errorf("failed to write %s: %v", format, err)
+		}
+	})
+
+	if want := []string{"html", "md", "json", "text"}; !slices.Equal(rendered, want) {
+		t.Errorf("rendered formats = %v; want %v", rendered, want)
+	}
+	for _, format := range []string{"html", "md", "json"} {
+		got, found := c.Rendered("warm", format)
+		if !found {
+			t.Errorf("%s rendering not cached", format)
+			continue
+		}
+		if ct := got.Header().Get("Content-Type"); ct != "x-test/"+format {
+			t.Errorf("%s rendering Content-Type = %q", format, ct)
+		}
+		if body := got.Body.String(); body != format+" body" {
+			t.Errorf("%s rendering body = %q", format, body)
+		}
+	}
+	if _, found := c.Rendered("warm", "text"); found {
+		t.Error("failed renderings should not be cached")
+	}
+	if _, found := c.Rendered("warm", "slides"); found {
+		t.Error("only the warm formats should be rendered ahead of time")
+	}
+	if _, found := c.Rendered("other", "html"); found {
+		t.Error("renderings are only kept for cached articles")
+	}
+}
@@ -0,0 +1,77 @@
+package article
+
+import (
+	"bytes"
+	"cmp"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	reqlog "github.com/lucasew/readability-web/internal/log"
+	"golang.org/x/net/html"
+)
+
+/**
+ * Diagnostics describes how readability processed a page.
+ *
+ * It is returned under `_debug` in the JSON output to help tune extraction.
+ * The raw HTML itself is deliberately left out, as it can be very large.
+ */
+type Diagnostics struct {
+	RawTitle       string `json:"raw_title"`
+	RawDescription string `json:"raw_description"`
+	RawOGTitle     string `json:"raw_og_title"`
+	RawBodyLength  int64  `json:"raw_body_length"`
+	ContentLength  int    `json:"content_length"`
+	FellBackToBody bool   `json:"fell_back_to_body"`
+	// FetchAttempts is the retry chain of this request's fetch, empty when the article was cached.
+	FetchAttempts []reqlog.FetchAttempt `json:"fetch_attempts,omitempty"`
+}
+
+/**
+ * Diagnose collects parser diagnostics from the raw document and the extracted content.
+ *
+ * Extraction is considered to have fallen back to the full body when readability
+ * found nothing or kept at least as much text as the page body has.
+ */
+func Diagnose(article FetchResult, contentBuf *bytes.Buffer) *Diagnostics {
+	diag := &Diagnostics{
+		RawBodyLength: article.BodySize,
+		ContentLength: contentBuf.Len(),
+	}
+	var body *html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if diag.RawTitle == "" {
+					diag.RawTitle = strings.TrimSpace(dom.TextContent(n))
+				}
+			case "meta":
+				switch {
+				case strings.EqualFold(dom.Attr(n, "name"), "description"):
+					diag.RawDescription = cmp.Or(diag.RawDescription, dom.Attr(n, "content"))
+				case strings.EqualFold(dom.Attr(n, "property"), "og:title"):
+					diag.RawOGTitle = cmp.Or(diag.RawOGTitle, dom.Attr(n, "content"))
+				}
+			case "body":
+				body = cmp.Or(body, n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if article.Document != nil {
+		walk(article.Document)
+	}
+
+	if article.Node == nil {
+		diag.FellBackToBody = true
+	} else if body != nil {
+		bodyText := len(strings.Join(strings.Fields(dom.TextContent(body)), " "))
+		contentText := len(strings.Join(strings.Fields(dom.TextContent(article.Node)), " "))
+		diag.FellBackToBody = contentText >= bodyText
+	}
+	return diag
+}
@@ -0,0 +1,36 @@
+package article
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+func TestLargestTextBlock(t *testing.T) {
+	tests := []struct {
+		name, page, want string
+	}{
+		{"paragraphs", `<div id="menu"><a href="/a">A long list of links to other sections of the site</a></div>
+<div id="main"><p>First paragraph of the story.</p><p>Second paragraph, <em>with</em> <a href="/x">a link</a>.</p></div>`, "main"},
+		{"loose text", `<div id="side"><p>Short</p></div><div id="main">Loose text without paragraphs, <em>as</em> some sites have it.</div>`, "main"},
+		{"boilerplate skipped", `<footer><p>A very long footer paragraph about cookies, privacy and the terms of use.</p></footer><div id="main"><p>The article.</p></div>`, "main"},
+		{"empty", `<div id="main"></div>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if block := LargestTextBlock(doc); block != nil {
+				got = dom.Attr(block, "id")
+			}
+			if got != tt.want {
+				t.Errorf("LargestTextBlock() = #%s; want #%s", got, tt.want)
+			}
+		})
+	}
+}
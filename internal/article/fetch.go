@@ -0,0 +1,672 @@
+/**
+ * Package article fetches the pages the service reads and extracts their articles.
+ */
+package article
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	reqlog "github.com/lucasew/readability-web/internal/log"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/transport"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	maxRedirects           = 5
+	MaxBodySize            = int64(2 * 1024 * 1024) // 2 MiB
+	maxArchiveResponseSize = int64(64 * 1024)       // Wayback Machine availability API answers, see FindArchiveSnapshot
+	headlessRenderTimeout  = 30 * time.Second       // see headlessClient
+
+	// minContentChars is the amount of text below which an extracted article is considered empty.
+	minContentChars = 100
+)
+
+var (
+	/**
+	 * ReadabilityParser holds the readability parser configuration.
+	 *
+	 * The parser keeps per-document state while parsing, so Extract works on
+	 * a copy of it, allowing concurrent requests (and batch items) to be parsed safely.
+	 */
+	ReadabilityParser = readability.NewParser()
+
+	// HTTPClient used for fetching remote articles with SSRF protection and redirect policy.
+	// It has no timeout of its own: every fetch runs under the deadline of its context
+	// (`?timeout=`, capped by MAX_FETCH_TIMEOUT).
+	HTTPClient = &http.Client{
+		Transport: transport.NewTransport(loadUpstreamProxy(os.Getenv("UPSTREAM_PROXY"))),
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+)
+
+/**
+ * loadUpstreamProxy parses the UPSTREAM_PROXY setting. An invalid value is logged
+ * and ignored, so a bad deployment setting never takes the service down.
+ */
+func loadUpstreamProxy(raw string) *url.URL {
+	if raw == "" {
+		return nil
+	}
+	proxy, err := transport.ParseProxyURL(raw)
+	if err != nil {
+		log.Printf("error parsing UPSTREAM_PROXY, fetching directly: %v", err)
+		return nil
+	}
+	return proxy
+}
+
+/**
+ * userAgentPool contains a list of real browser User-Agent strings.
+ *
+ * We rotate through these to mimic legitimate traffic, as many websites block requests
+ * from default HTTP clients (like Go-http-client) or known bot User-Agents.
+ * This list requires periodic maintenance to stay current with browser versions.
+ */
+var userAgentPool = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36 Edg/150.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:152.0) Gecko/20100101 Firefox/152.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_7_8 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Mobile/15E148 Safari/604.1",
+}
+
+/**
+ * RandomUserAgent returns a random User-Agent string from the pool.
+ *
+ * Rotating User-Agents helps to evade simple anti-bot measures that block requests
+ * based on static or default Go HTTP client User-Agents.
+ */
+func RandomUserAgent() string {
+	return userAgentPool[rand.Intn(len(userAgentPool))]
+}
+
+/**
+ * RetryUserAgent is the User-Agent fetchDocument retries with when a site refuses
+ * the browser one. Sites often let search engine crawlers through so they get indexed.
+ */
+const RetryUserAgent = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+
+/**
+ * Fetch retrieves the content from the target URL and parses it using the readability library.
+ *
+ * Key behaviors:
+ * - Spoofs User-Agent and other browser headers to avoid blocking.
+ * - Retries once with RetryUserAgent when the site answers 403 or 429.
+ * - Forwards Accept-Language from the client (or `?lang=`) to respect language preferences.
+ * - Sets security headers (Sec-Fetch-*) to look like a navigation request.
+ * - Limits the response body size to MaxBodySize to prevent Out-Of-Memory (OOM) crashes on large pages.
+ * - Uses a custom HTTPClient with SSRF protection, going through UPSTREAM_PROXY
+ *   or the request's signed proxy when there is one.
+ * - Refuses challenge pages of bot protection services (see DetectAntibot).
+ * - With `?prefer=amp`, parses the AMP version the page links to instead (see ExtractAMPURL).
+ * - Pages rendered by JavaScript are rendered by the HEADLESS_RENDER_URL service, if any.
+ *
+ * The raw document is kept alongside the article (readability works on a clone),
+ * so callers can inspect the page as it was fetched.
+ */
+func Fetch(ctx context.Context, link *url.URL, r *http.Request, opts Options) (FetchResult, error) {
+	if opts.IPv4Only {
+		ctx = transport.WithIPv4Only(ctx)
+	}
+	if opts.Proxy != nil {
+		ctx = transport.WithProxy(ctx, opts.Proxy)
+	}
+	node, size, err := fetchDocument(ctx, link, r, opts)
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		archived, archiveErr := fetchArchived(ctx, link, r, opts)
+		if archiveErr != nil {
+			log.Printf("no archive fallback for %q: %v", link, archiveErr)
+			return FetchResult{}, err
+		}
+		return archived, nil
+	}
+	if err != nil {
+		return FetchResult{}, err
+	}
+	// follow a single <meta http-equiv="refresh"> hop, which the HTTP client can't see
+	if target := extractMetaRefresh(node, link); target != nil {
+		if refreshed, refreshedSize, err := fetchDocument(ctx, target, r, opts); err != nil {
+			log.Printf("warning: failed to follow meta refresh from %q to %q, parsing the original page: %v", link, target, err)
+		} else {
+			node, size, link = refreshed, refreshedSize, target
+		}
+	}
+	var amp bool
+	if opts.PreferAMP {
+		if target := ExtractAMPURL(node, link); target != nil {
+			if ampNode, ampSize, err := fetchDocument(ctx, target, r, opts); err != nil {
+				log.Printf("warning: failed to fetch AMP version %q of %q, parsing the original page: %v", target, link, err)
+			} else {
+				ConvertAMPImages(ampNode)
+				node, size, link, amp = ampNode, ampSize, target, true
+			}
+		}
+	}
+	article, err := Extract(ctx, node, link, size, opts)
+	if errors.Is(err, ErrJSRenderedPage) && HeadlessEndpoint != "" && HeadlessProxy != "" {
+		if rendered, renderedSize, renderErr := renderHeadless(ctx, link); renderErr != nil {
+			log.Printf("warning: failed to render %q in a headless browser: %v", link, renderErr)
+		} else {
+			node, size = rendered, renderedSize
+			article, err = Extract(ctx, node, link, size, opts)
+		}
+	}
+	if err == nil {
+		article.AMP = amp
+	}
+	if err == nil && opts.ArchiveFallback && isPaywalled(node) {
+		if archived, archiveErr := fetchArchived(ctx, link, r, opts); archiveErr != nil {
+			log.Printf("no archive fallback for paywalled %q: %v", link, archiveErr)
+		} else {
+			return archived, nil
+		}
+	}
+	return article, err
+}
+
+/**
+ * fetchDocument performs the upstream request for Fetch and parses the
+ * response, returning the document and the number of bytes it came from.
+ */
+func fetchDocument(ctx context.Context, link *url.URL, r *http.Request, opts Options) (*html.Node, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", link.String(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Always spoof everything to look like a real browser
+	ua := RandomUserAgent()
+	req.Header.Set("User-Agent", ua)
+
+	if opts.RespectRobots {
+		allowed, err := CheckRobotsTxt(ctx, link, ua, HTTPClient)
+		if err != nil {
+			log.Printf("warning: failed to check robots.txt for %q, fetching anyway: %v", link, err)
+		} else if !allowed {
+			return nil, 0, ErrRobotsDisallowed
+		}
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+
+	// Explicit ?lang= wins, then the client's own preference
+	req.Header.Set("Accept-Language", cmp.Or(opts.AcceptLanguage, r.Header.Get("Accept-Language"), "en-US,en;q=0.9"))
+
+	if opts.Referer != "" {
+		req.Header.Set("Referer", opts.Referer)
+	}
+
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Pragma", "no-cache")
+	req.Header.Set("Sec-Ch-Ua-Mobile", "?0")
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.Header.Set("Sec-Fetch-User", "?1")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+
+	lc := reqlog.FromContext(ctx)
+	fetchStart := time.Now()
+	res, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	lc.FetchAttempts = append(lc.FetchAttempts, reqlog.FetchAttempt{URL: link.String(), UserAgent: ua, Status: res.StatusCode})
+	if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests {
+		res.Body.Close()
+		req.Header.Set("User-Agent", RetryUserAgent)
+		if res, err = HTTPClient.Do(req); err != nil {
+			return nil, 0, err
+		}
+		lc.FetchAttempts = append(lc.FetchAttempts, reqlog.FetchAttempt{URL: link.String(), UserAgent: RetryUserAgent, Status: res.StatusCode})
+	}
+	defer res.Body.Close()
+	if opts.ArchiveFallback && archiveFallbackStatus(res.StatusCode) {
+		lc.FetchDurationMs += time.Since(fetchStart).Milliseconds()
+		return nil, 0, &UpstreamStatusError{Status: res.StatusCode}
+	}
+
+	// Cap the body so oversized pages error instead of being silently truncated
+	// (io.LimitReader returns EOF at the cap, which can yield partial HTML as a
+	// successful extract). MaxBytesReader surfaces an error when the cap is hit.
+	reader := transport.NewCountingReader(http.MaxBytesReader(nil, res.Body, MaxBodySize))
+	node, err := html.Parse(reader)
+	lc.FetchDurationMs += time.Since(fetchStart).Milliseconds()
+	if err != nil {
+		return nil, 0, err
+	}
+	if vendor := DetectAntibot(res.Header, node); vendor != "" {
+		return nil, 0, &AntibotError{Vendor: vendor}
+	}
+	return node, reader.BytesRead(), nil
+}
+
+/**
+ * metaRefreshURLPattern matches the URL part of a refresh <meta> content,
+ * as in "0; url=https://example.com/" (the quotes are optional).
+ */
+var metaRefreshURLPattern = regexp.MustCompile(`(?i)^\s*[\d.]+\s*[;,]\s*(?:url\s*=\s*)?['"]?([^'"]+)`)
+
+/**
+ * extractMetaRefresh returns the target of the <meta http-equiv="refresh"> redirect
+ * in the <head> of node, resolved against base, or nil when there is none.
+ *
+ * Refreshes to the page itself (periodic reloads) are ignored, as are targets that
+ * aren't http(s) or that are IP addresses the SSRF protection refuses; host names
+ * are checked by transport.NewSafeDialer when connecting.
+ */
+func extractMetaRefresh(node *html.Node, base *url.URL) *url.URL {
+	head := dom.FindElement(node, "head")
+	if head == nil {
+		return nil
+	}
+	var content string
+	var walk func(n *html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "meta" && strings.EqualFold(dom.Attr(n, "http-equiv"), "refresh") {
+			content = dom.Attr(n, "content")
+			return true
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if walk(c) {
+				return true
+			}
+		}
+		return false
+	}
+	if !walk(head) {
+		return nil
+	}
+	match := metaRefreshURLPattern.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+	target, err := base.Parse(strings.TrimSpace(match[1]))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil
+	}
+	target.Fragment = ""
+	current := *base
+	current.Fragment = ""
+	if target.String() == current.String() {
+		return nil
+	}
+	if ip := net.ParseIP(target.Hostname()); ip != nil && transport.IsBlockedIP(ip) {
+		log.Printf("warning: refusing meta refresh from %q to private address %q", base, target)
+		return nil
+	}
+	return target
+}
+
+/**
+ * HeadlessEndpoint is the HEADLESS_RENDER_URL service rendering JavaScript-heavy
+ * pages, empty when there is none (see renderHeadless).
+ */
+var HeadlessEndpoint = os.Getenv("HEADLESS_RENDER_URL")
+
+/**
+ * HeadlessProxy is HEADLESS_RENDER_PROXY, the proxy (see cmd/safeproxy) the
+ * browser behind HeadlessEndpoint goes through. Pages are only rendered with one,
+ * as the browser would otherwise reach private networks freely.
+ */
+var HeadlessProxy = os.Getenv("HEADLESS_RENDER_PROXY")
+
+/**
+ * headlessClient sends requests to HeadlessEndpoint. It is operator configured
+ * and often runs next to the service, so it doesn't go through transport.NewSafeDialer.
+ */
+var headlessClient = &http.Client{Timeout: headlessRenderTimeout}
+
+/**
+ * renderHeadless has the page at link rendered by a headless browser behind
+ * HeadlessEndpoint, and parses the resulting DOM like fetchDocument parses pages.
+ *
+ * The endpoint is sent a JSON {"url": link} body and must answer with the
+ * page's HTML, like the /content API of browserless. As the browser fetches the
+ * page itself, past transport.NewSafeDialer, it is launched with HeadlessProxy as
+ * its only way out (browserless' `launch` parameter): checking link alone would
+ * miss the redirects, refreshes and navigation the browser follows.
+ */
+func renderHeadless(ctx context.Context, link *url.URL) (*html.Node, int64, error) {
+	launch, err := json.Marshal(map[string][]string{"args": {
+		"--proxy-server=" + HeadlessProxy,
+		// the browser skips the proxy for loopback unless told otherwise
+		"--proxy-bypass-list=<-loopback>",
+		"--force-webrtc-ip-handling-policy=disable_non_proxied_udp",
+	}})
+	if err != nil {
+		return nil, 0, err
+	}
+	endpoint, err := url.Parse(HeadlessEndpoint)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := endpoint.Query()
+	query.Set("launch", string(launch))
+	endpoint.RawQuery = query.Encode()
+
+	body, err := json.Marshal(map[string]string{"url": link.String()})
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	lc := reqlog.FromContext(ctx)
+	renderStart := time.Now()
+	res, err := headlessClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("renderer responded with status %d", res.StatusCode)
+	}
+	reader := transport.NewCountingReader(http.MaxBytesReader(nil, res.Body, MaxBodySize))
+	node, err := html.Parse(reader)
+	lc.FetchDurationMs += time.Since(renderStart).Milliseconds()
+	if err != nil {
+		return nil, 0, err
+	}
+	return node, reader.BytesRead(), nil
+}
+
+/**
+ * ErrJSRenderedPage is returned when a page has next to no content because it is
+ * rendered client-side by JavaScript, which this service does not run unless
+ * HEADLESS_RENDER_URL and HEADLESS_RENDER_PROXY are set.
+ */
+var ErrJSRenderedPage = errors.New("page requires JavaScript rendering")
+
+/**
+ * spaMountIDs are the ids of the empty elements single page app frameworks render into
+ * (Next.js, Create React App and friends).
+ */
+var spaMountIDs = []string{"__next", "root", "app", "__nuxt"}
+
+/**
+ * isJSRenderedPage reports whether the document looks like a single page app shell:
+ * it has a framework mount point (see spaMountIDs, or a data-reactroot attribute),
+ * or a <noscript> block with a substantial message such as "You need to enable JavaScript".
+ *
+ * It is only meant to explain near-empty extractions, as server-rendered apps carry
+ * the same markers around real content.
+ */
+func isJSRenderedPage(node *html.Node) bool {
+	if node.Type == html.ElementNode {
+		if slices.Contains(spaMountIDs, dom.Attr(node, "id")) || slices.ContainsFunc(node.Attr, func(a html.Attribute) bool { return a.Key == "data-reactroot" }) {
+			return true
+		}
+		if node.Data == "noscript" && len(noscriptText(node)) >= 20 {
+			return true
+		}
+	}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if isJSRenderedPage(c) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * noscriptText returns the visible text of a <noscript> element. As the parser runs
+ * with scripting enabled, its markup is kept as raw text and must be parsed again.
+ */
+func noscriptText(n *html.Node) string {
+	var raw strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		raw.WriteString(dom.TextContent(c))
+	}
+	doc, err := html.Parse(strings.NewReader(raw.String()))
+	if err != nil {
+		return strings.TrimSpace(raw.String())
+	}
+	return strings.Join(strings.Fields(dom.TextContent(doc)), " ")
+}
+
+/**
+ * Extract runs readability on an already parsed document.
+ *
+ * It applies the ExtractionRule of the site and the optional CSS selector, which
+ * takes precedence over the rule's Body, first and records the parse duration
+ * in the request's reqlog.LogContext. size is the number of bytes the document came from.
+ *
+ * When readability finds less than minContentChars of text, the LargestTextBlock
+ * of the page is used instead if it has more. The article's Extractor tells which
+ * content won.
+ */
+func Extract(ctx context.Context, node *html.Node, link *url.URL, size int64, opts Options) (FetchResult, error) {
+	parseStart := time.Now()
+	target := node
+	parser := ReadabilityParser
+	if rule := matchExtractionRule(extractionRules, link.Hostname()); rule != nil {
+		target, parser.DisableJSONLD = rule.Apply(node, opts.Selector == nil)
+	}
+	var selected bool
+	if opts.Selector != nil {
+		if target, selected = ApplyCSSSelector(target, opts.Selector); !selected {
+			log.Printf("warning: selector matched nothing on %q, parsing the full page", link)
+		}
+	}
+	article, err := parser.ParseDocument(target, link)
+	extractor := "readability"
+	if err == nil && selected && opts.SelectorOnly {
+		// readability still provides the metadata
+		article.Node = selectedContent(cascadia.Query(node, opts.Selector), link)
+		extractor = "selector"
+	}
+	contentLength := func(n *html.Node) int {
+		return len(strings.Join(strings.Fields(dom.TextContent(dom.OrEmpty(n))), " "))
+	}
+	if err == nil && extractor == "readability" && contentLength(article.Node) < minContentChars {
+		if block := LargestTextBlock(target); block != nil && contentLength(block) > contentLength(article.Node) {
+			article.Node = selectedContent(block, link)
+			extractor = "largest-block"
+		}
+	}
+	reqlog.FromContext(ctx).ParseDurationMs = time.Since(parseStart).Milliseconds()
+	if err != nil {
+		return FetchResult{}, err
+	}
+	if contentLength(article.Node) < minContentChars && isJSRenderedPage(node) {
+		return FetchResult{}, ErrJSRenderedPage
+	}
+	return FetchResult{Article: article, Document: node, URL: link, BodySize: size, FetchedAt: time.Now(), Extractor: extractor}, nil
+}
+
+/**
+ * Options holds validated per-request settings for Fetch.
+ */
+type Options struct {
+	// Selector, when set, restricts extraction to the first matching element.
+	Selector cascadia.Sel
+	// SelectorOnly makes the element Selector matches the article content as is,
+	// without readability's cleanup (`?readability=false`, see selectedContent).
+	SelectorOnly bool
+	// AcceptLanguage, when set, replaces the client's Accept-Language upstream.
+	AcceptLanguage string
+	// IPv4Only restricts the upstream connection to IPv4 addresses.
+	IPv4Only bool
+	// Referer, when set, is sent upstream. The client's own Referer is never forwarded.
+	Referer string
+	// RespectRobots refuses pages the site's robots.txt disallows (see CheckRobotsTxt).
+	RespectRobots bool
+	// ArchiveFallback reads the Wayback Machine copy of pages that fail or are
+	// paywalled (`?fallback=archive`, see fetchArchived).
+	ArchiveFallback bool
+	// PreferAMP parses the AMP version of pages that have one (`?prefer=amp`).
+	PreferAMP bool
+	// Proxy, when set, replaces UPSTREAM_PROXY for this request (a signed `?proxy=`).
+	Proxy *url.URL
+}
+
+/**
+ * ApplyCSSSelector narrows a document down to the first element matching sel.
+ *
+ * It returns a copy of doc whose <body> only contains the matched subtree, so
+ * readability still sees the page metadata in <head> (title, byline, etc.).
+ * The original document is left untouched. When nothing matches, it returns
+ * doc itself and false.
+ */
+func ApplyCSSSelector(doc *html.Node, sel cascadia.Sel) (*html.Node, bool) {
+	if cascadia.Query(doc, sel) == nil {
+		return doc, false
+	}
+	clone := dom.CloneNode(doc)
+	match := cascadia.Query(clone, sel)
+	body := dom.FindElement(clone, "body")
+	if body == nil {
+		return match, true
+	}
+	for n := body; n != nil; n = n.Parent {
+		if n == match {
+			// the selector matched <body> or one of its ancestors: nothing to narrow
+			return clone, true
+		}
+	}
+	match.Parent.RemoveChild(match)
+	for body.FirstChild != nil {
+		body.RemoveChild(body.FirstChild)
+	}
+	body.AppendChild(match)
+	return clone, true
+}
+
+// boilerplateElements hold page furniture rather than article text, which LargestTextBlock skips.
+var boilerplateElements = []string{"head", "script", "style", "noscript", "template", "nav", "header", "footer", "aside", "form"}
+
+/**
+ * LargestTextBlock returns the element of doc holding the most prose as direct
+ * content (text and paragraphs), the fallback extractor for pages readability
+ * gets next to nothing from. Link text doesn't count, so menus and link lists
+ * lose to prose, and boilerplateElements are skipped. It returns nil when doc
+ * has no text.
+ */
+func LargestTextBlock(doc *html.Node) *html.Node {
+	// prose is the text under n, without links
+	var prose func(n *html.Node) int
+	prose = func(n *html.Node) int {
+		switch {
+		case n.Type == html.TextNode:
+			return len(strings.Join(strings.Fields(n.Data), " "))
+		case n.Type == html.ElementNode && (n.Data == "a" || slices.Contains(boilerplateElements, n.Data)):
+			return 0
+		}
+		length := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			length += prose(c)
+		}
+		return length
+	}
+
+	var best *html.Node
+	bestScore := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		score := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				score += prose(c)
+			case c.Type != html.ElementNode || slices.Contains(boilerplateElements, c.Data):
+				continue
+			case c.Data == "p" || c.Data == "pre" || c.Data == "blockquote" || slices.Contains(meta.InlineElements, c.Data):
+				score += prose(c)
+			default:
+				walk(c)
+			}
+		}
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	walk(doc)
+	return best
+}
+
+/**
+ * selectedContent returns a copy of match wrapped in a <div> like readability's
+ * content, for elements used as the article as they are: the one a selector
+ * matched with `?readability=false`, or the LargestTextBlock of the page. Only
+ * what could run code is removed (see dom.Sanitize), and relative URLs are
+ * resolved against base.
+ */
+func selectedContent(match *html.Node, base *url.URL) *html.Node {
+	root := &html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"}
+	root.AppendChild(dom.CloneNode(match))
+	dom.Sanitize(root)
+	resolveURLs(root, base)
+	return root
+}
+
+/**
+ * resolveURLs resolves the href and src attributes under n against base, in place.
+ * Fragment-only links are kept as they are.
+ */
+func resolveURLs(n *html.Node, base *url.URL) {
+	for i, a := range n.Attr {
+		if (a.Key != "href" && a.Key != "src") || a.Namespace != "" || strings.HasPrefix(a.Val, "#") {
+			continue
+		}
+		if u, err := base.Parse(strings.TrimSpace(a.Val)); err == nil {
+			n.Attr[i].Val = u.String()
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		resolveURLs(c, base)
+	}
+}
+
+/**
+ * FetchResult is the result of Fetch.
+ *
+ * It embeds the extracted article and keeps the raw document and its size around
+ * for features that need to look at the page as it was fetched.
+ */
+type FetchResult struct {
+	readability.Article
+	// Document is the raw page before readability extraction.
+	Document *html.Node
+	// URL is the address the page came from.
+	URL *url.URL
+	// BodySize is the number of bytes read from the upstream response body.
+	BodySize int64
+	// FetchedAt is when the page was downloaded, sent as Last-Modified.
+	FetchedAt time.Time
+	// Archive is the snapshot the page came from, when the origin was replaced by it.
+	Archive *ArchiveSnapshot
+	// AMP reports that the page is the AMP version (at URL) of the requested one.
+	AMP bool
+	// Extractor names what produced the content: "readability", "selector"
+	// (`?readability=false`) or "largest-block" (see LargestTextBlock).
+	Extractor string
+}
@@ -0,0 +1,158 @@
+package article
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/testutil"
+	"github.com/lucasew/readability-web/internal/transport"
+)
+
+func TestFetchAndParse(t *testing.T) {
+	// Serve a minimal HTML page
+	htmlBody := `<html><head><title>Test Title</title></head><body><p>Hello World</p></body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(htmlBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	// Override HTTPClient to use server's client
+	oldClient := HTTPClient
+	HTTPClient = srv.Client()
+	defer func() { HTTPClient = oldClient }()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	ctx := t.Context()
+	req := httptest.NewRequest("GET", "/", nil)
+	art, err := Fetch(ctx, u, req, Options{})
+	if err != nil {
+		t.Fatalf("fetchAndParse returned error: %v", err)
+	}
+	if art.Title() != "Test Title" {
+		t.Errorf("Article.Title() = %q; want %q", art.Title(), "Test Title")
+	}
+
+	content := testutil.MustRenderHTML(t, art.Article)
+	if !strings.Contains(content.String(), "<p>Hello World") {
+		t.Errorf("Article.Content missing expected paragraph, got: %q", content.String())
+	}
+}
+
+func TestFetchAndParseRejectsOversizedBody(t *testing.T) {
+	// Body larger than MaxBodySize must error, not parse a truncated page.
+	oversized := strings.Repeat("x", int(MaxBodySize)+1)
+	htmlBody := "<html><head><title>Big</title></head><body><p>" + oversized + "</p></body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte(htmlBody)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	oldClient := HTTPClient
+	HTTPClient = srv.Client()
+	defer func() { HTTPClient = oldClient }()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	_, err = Fetch(t.Context(), u, req, Options{})
+	if err == nil {
+		t.Fatal("fetchAndParse: expected error for oversized body, got nil")
+	}
+}
+
+/**
+ * TestSSRFProtection confirms that the custom dialer correctly blocks connections
+ * to private and loopback IP addresses.
+ *
+ * This is a critical security control to prevent the application from being used
+ * as a proxy to attack internal infrastructure (SSRF).
+ */
+func TestSSRFProtection(t *testing.T) {
+	// a dummy server that should never be reached
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Fatal("dialer did not block private IP, connection was made")
+	}))
+	defer srv.Close()
+
+	// get loopback address of the server
+	// srv.URL will be something like http://127.0.0.1:54321
+	// we want to test if the dialer blocks the connection to 127.0.0.1
+	// so, we don't use the server's client, we use our own HTTPClient
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	_, err = HTTPClient.Do(req)
+	if err == nil {
+		t.Fatal("expected an error when dialing a private IP, but got none")
+	}
+	// check if the error is the one we expect from our dialer
+	// the error is wrapped, so we need to check for the substring
+	if !strings.Contains(err.Error(), "refusing to connect to private network address") {
+		t.Errorf("expected error to contain 'refusing to connect to private network address', but got: %v", err)
+	}
+
+	// Test Unspecified IP (0.0.0.0) bypass attempt
+	// We manually construct a URL with 0.0.0.0 and a port (it doesn't need to be open for the check to fire)
+	unspecifiedURL := "http://0.0.0.0:8080"
+	reqUnspecified, err := http.NewRequest("GET", unspecifiedURL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request for unspecified IP: %v", err)
+	}
+	_, err = HTTPClient.Do(reqUnspecified)
+	if err == nil {
+		t.Fatal("expected an error when dialing 0.0.0.0, but got none")
+	}
+	if !strings.Contains(err.Error(), "refusing to connect to private network address") {
+		t.Errorf("expected error for 0.0.0.0 to contain 'refusing to connect to private network address', but got: %v", err)
+	}
+}
+
+/**
+ * TestSSRFProtectionIPv4Only checks that `?ipv4-only=true` refuses IPv6 addresses
+ * with their own error, while IPv4 addresses still go through the private network check.
+ */
+func TestSSRFProtectionIPv4Only(t *testing.T) {
+	tests := []struct {
+		target  string
+		wantErr string
+	}{
+		{"http://[::1]:8080", "refusing IPv6 address"},
+		{"http://127.0.0.1:8080", "refusing to connect to private network address"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequestWithContext(transport.WithIPv4Only(t.Context()), "GET", tt.target, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		_, err = HTTPClient.Do(req)
+		if err == nil {
+			t.Fatalf("expected an error when dialing %s, but got none", tt.target)
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("dialing %s: expected error to contain %q, but got: %v", tt.target, tt.wantErr, err)
+		}
+	}
+
+	// without the flag, IPv6 loopback is still rejected as a private address
+	req, err := http.NewRequest("GET", "http://[::1]:8080", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	if _, err := HTTPClient.Do(req); err == nil || !strings.Contains(err.Error(), "refusing to connect to private network address") {
+		t.Errorf("expected private network error for [::1] without ipv4-only, got: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package article
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const noscriptFixture = `<!DOCTYPE html><html><head><title>Shell</title></head>
+<body><noscript><p>This site requires <b>JavaScript</b> to display its articles.</p></noscript><div class="shell"></div></body></html>`
+
+func TestIsJSRenderedPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		expected bool
+	}{
+		{"next.js", nextJSFixture, true},
+		{"create react app", craFixture, true},
+		{"noscript message", noscriptFixture, true},
+		{"react root attribute", `<body><div data-reactroot=""></div></body>`, true},
+		{"tracking pixel noscript", `<body><noscript><img src="https://example.com/px.gif" height="1" width="1"></noscript><p>Text</p></body>`, false},
+		{"plain article", `<body><article><p>Just a static page.</p></article></body>`, false},
+	}
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader(tt.page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isJSRenderedPage(doc); got != tt.expected {
+			t.Errorf("%s: isJSRenderedPage = %v; want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+const nextJSFixture = `<!DOCTYPE html><html><head><title>Next App</title>
+<script src="/_next/static/chunks/main.js" defer></script></head>
+<body><div id="__next"></div>
+<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{}},"page":"/"}</script></body></html>`
+
+const craFixture = `<!DOCTYPE html><html><head><title>React App</title></head>
+<body><noscript>You need to enable JavaScript to run this app.</noscript><div id="root"></div>
+<script src="/static/js/main.js"></script></body></html>`
@@ -0,0 +1,168 @@
+package article
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	defaultPageCacheTTL = time.Hour
+	// PageCacheTimeout bounds the requests to a shared PageCache.
+	PageCacheTimeout = 2 * time.Second
+)
+
+/**
+ * PageCache stores fetched pages under the article cache key, behind CacheStore.
+ *
+ * Where CacheStore keeps parsed articles in one instance, a PageCache can be
+ * shared by all of them (see kvPageCache), so a page fetched by one instance is
+ * only parsed again by the others. Implementations must be safe for concurrent use.
+ */
+type PageCache interface {
+	// Get returns the value stored under key, if present and not expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value under key for TTL.
+	Set(ctx context.Context, key string, value []byte)
+	// TTL is how long values are kept.
+	TTL() time.Duration
+}
+
+/**
+ * memoryPageCache is an in-memory LRU PageCache, for single long-lived instances
+ * (self-hosted deployments) that want to keep pages longer than articles.
+ */
+type memoryPageCache struct {
+	pages *lru[[]byte]
+}
+
+/**
+ * NewMemoryPageCache creates a PageCache holding up to size pages for ttl each.
+ */
+func NewMemoryPageCache(size int, ttl time.Duration) PageCache {
+	return &memoryPageCache{pages: newLRU[[]byte](size, ttl)}
+}
+
+func (c *memoryPageCache) Get(_ context.Context, key string) ([]byte, bool) {
+	return c.pages.get(key)
+}
+
+func (c *memoryPageCache) Set(_ context.Context, key string, value []byte) {
+	c.pages.add(key, value)
+}
+
+func (c *memoryPageCache) TTL() time.Duration { return c.pages.ttl }
+
+/**
+ * kvPageCache is a PageCache kept in Redis through the Upstash REST API, which is
+ * what Vercel KV exposes: commands are POSTed as JSON arrays with a bearer token.
+ * Errors are logged and count as misses, so an unavailable store only costs fetches.
+ */
+type kvPageCache struct {
+	url    string
+	token  string
+	ttl    time.Duration
+	client *http.Client
+}
+
+// kvKeyPrefix namespaces the keys kvPageCache stores, as the database may be shared.
+const kvKeyPrefix = "articleparser:page:"
+
+/**
+ * command runs a Redis command and returns its result.
+ */
+func (c *kvPageCache) command(ctx context.Context, args ...any) (json.RawMessage, error) {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 2*MaxBodySize)).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("decoding reply (status %d): %w", res.StatusCode, err)
+	}
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+	return reply.Result, nil
+}
+
+func (c *kvPageCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	result, err := c.command(ctx, "GET", kvKeyPrefix+key)
+	if err != nil {
+		log.Printf("error reading page cache: %v", err)
+		return nil, false
+	}
+	var value *string
+	if err := json.Unmarshal(result, &value); err != nil || value == nil {
+		return nil, false
+	}
+	return []byte(*value), true
+}
+
+func (c *kvPageCache) Set(ctx context.Context, key string, value []byte) {
+	if _, err := c.command(ctx, "SET", kvKeyPrefix+key, string(value), "EX", int(c.ttl.Seconds())); err != nil {
+		log.Printf("error writing page cache: %v", err)
+	}
+}
+
+func (c *kvPageCache) TTL() time.Duration { return c.ttl }
+
+/**
+ * PageCacheFromEnv returns the PageCache selected by PAGE_CACHE: "memory" for a
+ * memoryPageCache of size pages, "kv" for a kvPageCache at
+ * KV_REST_API_URL authenticated with KV_REST_API_TOKEN (set by Vercel KV), or
+ * "none". When unset, the KV store is used if configured. Pages are kept for
+ * PAGE_CACHE_TTL (a Go duration, one hour by default).
+ */
+func PageCacheFromEnv(size int) PageCache {
+	ttl := defaultPageCacheTTL
+	if raw := os.Getenv("PAGE_CACHE_TTL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < time.Second {
+			log.Printf("invalid PAGE_CACHE_TTL %q, using %s", raw, defaultPageCacheTTL)
+		} else {
+			ttl = parsed
+		}
+	}
+	kvURL, kvToken := os.Getenv("KV_REST_API_URL"), os.Getenv("KV_REST_API_TOKEN")
+	backend := os.Getenv("PAGE_CACHE")
+	if backend == "" && kvURL != "" && kvToken != "" {
+		backend = "kv"
+	}
+	switch backend {
+	case "", "none":
+		return nil
+	case "memory":
+		return NewMemoryPageCache(size, ttl)
+	case "kv":
+		if kvURL == "" || kvToken == "" {
+			log.Printf("PAGE_CACHE=kv needs KV_REST_API_URL and KV_REST_API_TOKEN, page cache disabled")
+			return nil
+		}
+		return &kvPageCache{url: kvURL, token: kvToken, ttl: ttl, client: &http.Client{Timeout: PageCacheTimeout}}
+	default:
+		log.Printf("unknown PAGE_CACHE %q, page cache disabled", backend)
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryPageCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryPageCache(2, time.Minute)
+	c.Set(ctx, "a", []byte("A"))
+	c.Set(ctx, "b", []byte("B"))
+	if value, ok := c.Get(ctx, "a"); !ok || string(value) != "A" {
+		t.Fatalf("Get(a) = (%q, %v); want (A, true)", value, ok)
+	}
+	c.Set(ctx, "c", []byte("C"))
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted as least recently used")
+	}
+
+	expiring := NewMemoryPageCache(2, -time.Second)
+	expiring.Set(ctx, "a", []byte("A"))
+	if _, ok := expiring.Get(ctx, "a"); ok {
+		t.Error("expected expired entry to be dropped")
+	}
+}
+
+func TestKVPageCache(t *testing.T) {
+	var mu sync.Mutex
+	store := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+			return
+		}
+		var cmd []any
+		if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			t.Errorf("invalid command: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		var result any
+		switch cmd[0] {
+		case "GET":
+			if value, ok := store[cmd[1].(string)]; ok {
+				result = value
+			}
+		case "SET":
+			if len(cmd) != 5 || cmd[3] != "EX" || cmd[4] != float64(60) {
+				t.Errorf("SET command = %v; want an expiry of 60 seconds", cmd)
+			}
+			store[cmd[1].(string)] = cmd[2].(string)
+			result = "OK"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &kvPageCache{url: srv.URL, token: "secret", ttl: time.Minute, client: srv.Client()}
+	if _, ok := c.Get(ctx, "page"); ok {
+		t.Error("Get() found a value before Set()")
+	}
+	c.Set(ctx, "page", []byte(`{"html":"<p>hi</p>"}`))
+	if value, ok := c.Get(ctx, "page"); !ok || string(value) != `{"html":"<p>hi</p>"}` {
+		t.Errorf("Get() = (%q, %v)", value, ok)
+	}
+	mu.Lock()
+	if _, ok := store[kvKeyPrefix+"page"]; !ok {
+		t.Errorf("value not stored under the prefixed key: %v", store)
+	}
+	mu.Unlock()
+
+	unauthorized := &kvPageCache{url: srv.URL, token: "wrong", ttl: time.Minute, client: srv.Client()}
+	if _, ok := unauthorized.Get(ctx, "page"); ok {
+		t.Error("Get() with a rejected token found a value")
+	}
+}
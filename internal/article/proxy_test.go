@@ -0,0 +1,45 @@
+package article
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFetchThroughUpstreamProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		if _, err := w.Write([]byte(`<html><head><title>Proxied</title></head><body><p>Fetched through the proxy.</p></body></html>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the proxy itself is on loopback, which only the proxy dial may reach
+	link, _ := url.Parse("http://203.0.113.10/post")
+	article, err := Fetch(context.Background(), link, httptest.NewRequest("GET", "/api", nil), Options{Proxy: proxyURL})
+	if err != nil {
+		t.Fatalf("fetchAndParse() error = %v", err)
+	}
+	if article.Title() != "Proxied" {
+		t.Errorf("title = %q", article.Title())
+	}
+	if len(proxied) != 1 || proxied[0] != link.String() {
+		t.Errorf("proxied %v; want %s", proxied, link)
+	}
+
+	private, _ := url.Parse("http://127.0.0.1/admin")
+	if _, err := Fetch(context.Background(), private, httptest.NewRequest("GET", "/api", nil), Options{Proxy: proxyURL}); err == nil {
+		t.Error("fetchAndParse() of a private address through the proxy succeeded")
+	}
+	if len(proxied) != 1 {
+		t.Errorf("private address reached the proxy: %v", proxied)
+	}
+}
@@ -0,0 +1,110 @@
+package article
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractMetaRefresh(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1#top")
+	tests := []struct {
+		name, head, want string
+	}{
+		{"absolute", `<meta http-equiv="refresh" content="0; url=https://other.com/login">`, "https://other.com/login"},
+		{"relative quoted", `<meta http-equiv="Refresh" content="0;URL='/articles/2'">`, "https://example.com/articles/2"},
+		{"no url keyword", `<meta http-equiv="refresh" content="3, https://other.com/">`, "https://other.com/"},
+		{"reload only", `<meta http-equiv="refresh" content="300">`, ""},
+		{"same page", `<meta http-equiv="refresh" content="60; url=https://example.com/articles/1">`, ""},
+		{"unsupported scheme", `<meta http-equiv="refresh" content="0; url=javascript:alert(1)">`, ""},
+		{"private address", `<meta http-equiv="refresh" content="0; url=http://10.0.0.1/admin">`, ""},
+		{"loopback address", `<meta http-equiv="refresh" content="0; url=http://127.0.0.1:8080/">`, ""},
+		{"no meta", `<title>Plain</title>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader("<html><head>" + tt.head + "</head><body></body></html>"))
+			if err != nil {
+				t.Fatalf("failed to parse fixture: %v", err)
+			}
+			got := ""
+			if target := extractMetaRefresh(doc, base); target != nil {
+				got = target.String()
+			}
+			if got != tt.want {
+				t.Errorf("extractMetaRefresh() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+/**
+ * withHostsServer points HTTPClient at srv for every host name, so tests can
+ * use public looking URLs (loopback ones are refused by meta refresh handling).
+ */
+func withHostsServer(t *testing.T, srv *httptest.Server) {
+	oldClient := HTTPClient
+	HTTPClient = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	}}
+	t.Cleanup(func() { HTTPClient = oldClient })
+}
+
+func TestFetchAndParseFollowsMetaRefresh(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var page string
+		switch r.Host {
+		case "origin.example":
+			page = `<html><head><meta http-equiv="refresh" content="0; url=http://target.example/story"><title>Redirecting</title></head><body></body></html>`
+		case "target.example":
+			page = `<html><head><title>Target Title</title></head><body><p>Hello from the target</p></body></html>`
+		}
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	withHostsServer(t, srv)
+
+	u, _ := url.Parse("http://origin.example/")
+	art, err := Fetch(t.Context(), u, httptest.NewRequest("GET", "/", nil), Options{})
+	if err != nil {
+		t.Fatalf("fetchAndParse returned error: %v", err)
+	}
+	if art.Title() != "Target Title" {
+		t.Errorf("Article.Title() = %q; want the refresh target's title", art.Title())
+	}
+}
+
+func TestFetchAndParseBlocksPrivateMetaRefresh(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		page := `<html><head><meta http-equiv="refresh" content="0; url=http://192.168.1.1/"><title>Origin Title</title></head><body><p>Origin body</p></body></html>`
+		if _, err := w.Write([]byte(page)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	withHostsServer(t, srv)
+
+	u, _ := url.Parse("http://origin.example/")
+	art, err := Fetch(t.Context(), u, httptest.NewRequest("GET", "/", nil), Options{})
+	if err != nil {
+		t.Fatalf("fetchAndParse returned error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d upstream requests; the private refresh target must not be fetched", requests)
+	}
+	if art.Title() != "Origin Title" {
+		t.Errorf("Article.Title() = %q; want the original page", art.Title())
+	}
+}
@@ -0,0 +1,219 @@
+package article
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// robots.txt checks (`?respect-robots=true`) limits
+	robotsCacheTTL     = time.Hour
+	robotsFetchTimeout = 3 * time.Second
+	maxRobotsSize      = 500 << 10 // 500 KiB
+)
+
+/**
+ * ErrRobotsDisallowed is returned when `?respect-robots=true` is set and the
+ * site's robots.txt disallows fetching the article.
+ */
+var ErrRobotsDisallowed = errors.New("fetching this page is disallowed by the site's robots.txt")
+
+/**
+ * robotsRule is an Allow or Disallow line of a robots.txt group.
+ */
+type robotsRule struct {
+	allow bool
+	path  string
+}
+
+/**
+ * robotsGroup is a robots.txt group: the rules for the listed user agents.
+ */
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+/**
+ * robotsEntry is a parsed robots.txt kept in robotsCache until expires.
+ */
+type robotsEntry struct {
+	groups  []robotsGroup
+	expires time.Time
+}
+
+var (
+	robotsMu sync.Mutex
+	// robotsCache holds the parsed robots.txt of each scheme://host for robotsCacheTTL.
+	robotsCache = map[string]robotsEntry{}
+)
+
+/**
+ * CheckRobotsTxt reports whether the robots.txt of targetURL's site lets ua fetch it.
+ *
+ * The file is fetched with client and cached per site for robotsCacheTTL. As in
+ * RFC 9309, a missing robots.txt (any 4xx) allows everything, the group of the
+ * longest user agent token found in ua applies (or the "*" group), and the longest
+ * matching rule wins, Allow winning ties. Network errors and 5xx responses are
+ * returned as errors for the caller to decide.
+ */
+func CheckRobotsTxt(ctx context.Context, targetURL *url.URL, ua string, client *http.Client) (bool, error) {
+	site := targetURL.Scheme + "://" + targetURL.Host
+	robotsMu.Lock()
+	entry, found := robotsCache[site]
+	robotsMu.Unlock()
+	if !found || time.Now().After(entry.expires) {
+		groups, err := fetchRobotsTxt(ctx, site, client)
+		if err != nil {
+			return false, err
+		}
+		entry = robotsEntry{groups: groups, expires: time.Now().Add(robotsCacheTTL)}
+		robotsMu.Lock()
+		for key, e := range robotsCache {
+			if time.Now().After(e.expires) {
+				delete(robotsCache, key)
+			}
+		}
+		robotsCache[site] = entry
+		robotsMu.Unlock()
+	}
+
+	path := cmp.Or(targetURL.EscapedPath(), "/")
+	if targetURL.RawQuery != "" {
+		path += "?" + targetURL.RawQuery
+	}
+	return robotsAllowed(entry.groups, strings.ToLower(ua), path), nil
+}
+
+/**
+ * fetchRobotsTxt downloads and parses the robots.txt of site (scheme://host).
+ */
+func fetchRobotsTxt(ctx context.Context, site string, client *http.Client) ([]robotsGroup, error) {
+	ctx, cancel := context.WithTimeout(ctx, robotsFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode >= 500:
+		return nil, fmt.Errorf("robots.txt unavailable: %s", res.Status)
+	case res.StatusCode >= 400:
+		return nil, nil
+	}
+	// like Google, only the first maxRobotsSize bytes are taken into account
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxRobotsSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseRobotsTxt(string(data)), nil
+}
+
+/**
+ * parseRobotsTxt parses the groups of a robots.txt file. Consecutive User-agent
+ * lines share the rules that follow them; other lines (Sitemap, Crawl-delay) are ignored.
+ */
+func parseRobotsTxt(data string) []robotsGroup {
+	var groups []robotsGroup
+	lastWasAgent := false
+	for line := range strings.Lines(data) {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !lastWasAgent {
+				groups = append(groups, robotsGroup{})
+			}
+			groups[len(groups)-1].agents = append(groups[len(groups)-1].agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			// an empty Disallow allows everything, which no rule expresses as well
+			if len(groups) == 0 || value == "" {
+				continue
+			}
+			groups[len(groups)-1].rules = append(groups[len(groups)-1].rules, robotsRule{allow: key == "allow", path: value})
+		default:
+			lastWasAgent = false
+		}
+	}
+	return groups
+}
+
+/**
+ * robotsAllowed applies the group of groups matching ua (lowercased) to path.
+ */
+func robotsAllowed(groups []robotsGroup, ua, path string) bool {
+	var rules []robotsRule
+	best := -1
+	for _, group := range groups {
+		for _, agent := range group.agents {
+			matched := len(agent)
+			if agent == "*" {
+				matched = 0
+			} else if !strings.Contains(ua, agent) {
+				continue
+			}
+			// groups for the same agent are merged
+			if matched > best {
+				best, rules = matched, nil
+			}
+			if matched == best {
+				rules = append(rules, group.rules...)
+			}
+		}
+	}
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		if !robotsPathMatch(rule.path, path) {
+			continue
+		}
+		if len(rule.path) > longest || (len(rule.path) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.path)
+		}
+	}
+	return allowed
+}
+
+/**
+ * robotsPathMatch reports whether the robots.txt path pattern matches path:
+ * patterns are prefixes, where "*" matches any sequence and a trailing "$"
+ * anchors the end.
+ */
+func robotsPathMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if anchored && rest != "" {
+		// the last "*" may still stretch to the end
+		return len(parts) > 1 && strings.HasSuffix(path, parts[len(parts)-1])
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package article
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const testUA = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/150.0.0.0 Safari/537.36"
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name, robots, path string
+		want               bool
+	}{
+		{"disallow all", "User-agent: *\nDisallow: /\n", "/post", false},
+		{"empty disallow", "User-agent: *\nDisallow:\n", "/post", true},
+		{"no rules", "", "/post", true},
+		{"path prefix", "User-agent: *\nDisallow: /private/\n", "/private/post", false},
+		{"other path", "User-agent: *\nDisallow: /private/\n", "/public/post", true},
+		{"longest match wins", "User-agent: *\nDisallow: /blog/\nAllow: /blog/free/\n", "/blog/free/post", true},
+		{"allow wins ties", "User-agent: *\nDisallow: /page\nAllow: /page\n", "/page", true},
+		{"wildcard", "User-agent: *\nDisallow: /*.pdf$\n", "/files/report.pdf", false},
+		{"anchor", "User-agent: *\nDisallow: /*.pdf$\n", "/files/report.pdf?download=1", true},
+		{"query string", "User-agent: *\nDisallow: /*?print=\n", "/post?print=1", false},
+		{"specific agent group", "User-agent: *\nDisallow:\n\nUser-agent: Chrome\nDisallow: /\n", "/post", false},
+		{"other agent group", "User-agent: Googlebot\nDisallow: /\n", "/post", true},
+		{"shared group", "User-agent: Googlebot\nUser-agent: Mozilla\nDisallow: /news\n", "/news/1", false},
+		{"comments", "# rules\nUser-agent: * # everyone\nDisallow: /a # no a\n", "/a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := robotsAllowed(parseRobotsTxt(tt.robots), "mozilla/5.0 chrome/150.0", tt.path); got != tt.want {
+				t.Errorf("robotsAllowed(%q) = %v; want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		robots  string
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{"disallow all", http.StatusOK, "User-agent: *\nDisallow: /\n", "/post", false, false},
+		{"path specific", http.StatusOK, "User-agent: *\nDisallow: /members/\n", "/post", true, false},
+		{"path specific blocked", http.StatusOK, "User-agent: *\nDisallow: /members/\n", "/members/post", false, false},
+		{"missing robots.txt", http.StatusNotFound, "", "/post", true, false},
+		{"server error", http.StatusServiceUnavailable, "", "/post", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRobotsServer(t, tt.status, tt.robots)
+			defer srv.Close()
+			target, _ := url.Parse(srv.URL + tt.path)
+			got, err := CheckRobotsTxt(t.Context(), target, testUA, srv.Client())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckRobotsTxt() error = %v; want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CheckRobotsTxt() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRobotsTxtCachesPerHost(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if _, err := w.Write([]byte("User-agent: *\nDisallow: /a\n")); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer srv.Close()
+	for _, path := range []string{"/a", "/b", "/a"} {
+		target, _ := url.Parse(srv.URL + path)
+		if _, err := CheckRobotsTxt(t.Context(), target, testUA, srv.Client()); err != nil {
+			t.Fatalf("CheckRobotsTxt() error = %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("robots.txt fetched %d times; want 1", requests)
+	}
+}
+
+func newRobotsServer(t *testing.T, status int, robots string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(status)
+			_, err = w.Write([]byte(robots))
+		} else {
+			_, err = w.Write([]byte(`<html><head><title>Allowed</title></head><body><p>Hello World</p></body></html>`))
+		}
+		if err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
@@ -0,0 +1,191 @@
+package article
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/**
+ * ExtractionRule tells how to extract articles from the sites it lists, where
+ * readability alone picks the wrong content or metadata. The fields other than
+ * Domains are CSS selectors, each of which may be left empty.
+ */
+type ExtractionRule struct {
+	// Domains are the hosts the rule applies to, along with their subdomains.
+	Domains []string `json:"domains"`
+	// Body selects the element holding the article, as `?selector=` does.
+	Body   string `json:"body,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Date selects the publication date, read from the element's datetime or
+	// content attribute, or from its text.
+	Date string `json:"date,omitempty"`
+
+	body, title, author, date cascadia.Sel
+}
+
+/**
+ * builtinExtractionRules cover popular sites readability gets wrong on its own.
+ * Rules from EXTRACTION_RULES_PATH are tried before them.
+ */
+var builtinExtractionRules = []ExtractionRule{
+	{Domains: []string{"wikipedia.org"}, Body: "#mw-content-text", Title: "#firstHeading"},
+	{Domains: []string{"medium.com"}, Body: "article", Title: "article h1", Author: `[data-testid="authorName"]`, Date: `[data-testid="storyPublishDate"]`},
+	{Domains: []string{"substack.com"}, Body: ".available-content", Title: "h1.post-title"},
+}
+
+/**
+ * extractionRules are the ExtractionRules Extract applies, loaded once at startup.
+ */
+var extractionRules = loadExtractionRules(os.Getenv("EXTRACTION_RULES_PATH"))
+
+/**
+ * compile parses the selectors of the rule.
+ */
+func (rule *ExtractionRule) compile() error {
+	if len(rule.Domains) == 0 {
+		return errors.New("rule has no domains")
+	}
+	for _, field := range []struct {
+		name, raw string
+		sel       *cascadia.Sel
+	}{
+		{"body", rule.Body, &rule.body},
+		{"title", rule.Title, &rule.title},
+		{"author", rule.Author, &rule.author},
+		{"date", rule.Date, &rule.date},
+	} {
+		if field.raw == "" {
+			continue
+		}
+		sel, err := cascadia.Parse(field.raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s selector for %v: %w", field.name, rule.Domains, err)
+		}
+		*field.sel = sel
+	}
+	return nil
+}
+
+/**
+ * loadExtractionRules returns the rules of the JSON file at path, a list of
+ * ExtractionRule objects, followed by builtinExtractionRules. A missing or
+ * invalid file is logged and only the built-in rules are used, as with
+ * ARTICLE_TEMPLATE_PATH.
+ */
+func loadExtractionRules(path string) []ExtractionRule {
+	var rules []ExtractionRule
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &rules)
+		}
+		for i := range rules {
+			if err != nil {
+				break
+			}
+			err = rules[i].compile()
+		}
+		if err != nil {
+			log.Printf("error loading extraction rules from EXTRACTION_RULES_PATH %q, using built-in: %v", path, err)
+			rules = nil
+		}
+	}
+	for _, rule := range builtinExtractionRules {
+		if err := rule.compile(); err != nil {
+			log.Printf("skipping built-in extraction rule: %v", err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+/**
+ * matchExtractionRule returns the first of rules listing host or one of its
+ * parent domains, or nil.
+ */
+func matchExtractionRule(rules []ExtractionRule, host string) *ExtractionRule {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for i, rule := range rules {
+		for _, domain := range rule.Domains {
+			domain = strings.ToLower(domain)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * Apply prepares doc for readability. It returns a copy of doc narrowed down to
+ * the Body element when narrow is set and the selector matches (see
+ * ApplyCSSSelector), with the title, author and date the other selectors find
+ * added to its <head> as the <meta> tags readability reads. doc itself is left
+ * untouched.
+ *
+ * It reports whether metadata was added: readability prefers the page's JSON-LD
+ * to <meta> tags, so it must then be told to ignore it.
+ */
+func (rule *ExtractionRule) Apply(doc *html.Node, narrow bool) (*html.Node, bool) {
+	text := func(sel cascadia.Sel, attributes bool) string {
+		if sel == nil {
+			return ""
+		}
+		match := cascadia.Query(doc, sel)
+		if match == nil {
+			return ""
+		}
+		if value := cmp.Or(dom.Attr(match, "datetime"), dom.Attr(match, "content")); attributes && value != "" {
+			return strings.TrimSpace(value)
+		}
+		return strings.Join(strings.Fields(dom.TextContent(match)), " ")
+	}
+	metadata := [][2]string{
+		{"dc:title", text(rule.title, false)},
+		{"dc:creator", text(rule.author, false)},
+		{"article:published_time", text(rule.date, true)},
+	}
+
+	out := doc
+	if narrow && rule.body != nil {
+		out, _ = ApplyCSSSelector(doc, rule.body)
+	}
+	if !slices.ContainsFunc(metadata, func(m [2]string) bool { return m[1] != "" }) {
+		return out, false
+	}
+	if out == doc {
+		out = dom.CloneNode(doc)
+	}
+	head := dom.FindElement(out, "head")
+	if head == nil {
+		return out, false
+	}
+	for _, m := range metadata {
+		if m[1] != "" {
+			// later <meta> tags win in readability, so these go last
+			head.AppendChild(&html.Node{Type: html.ElementNode, DataAtom: atom.Meta, Data: "meta", Attr: []html.Attribute{{Key: "property", Val: m[0]}, {Key: "content", Val: m[1]}}})
+		}
+	}
+	if title := metadata[0][1]; title != "" {
+		if element := dom.FindElement(head, "title"); element != nil {
+			for element.FirstChild != nil {
+				element.RemoveChild(element.FirstChild)
+			}
+			element.AppendChild(&html.Node{Type: html.TextNode, Data: title})
+		}
+	}
+	return out, true
+}
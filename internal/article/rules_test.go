@@ -0,0 +1,125 @@
+package article
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+const rulesPage = `<html><head><title>Site name | Wrong title</title></head><body>
+<nav>Menu</nav>
+<div class="post"><h1 class="headline">Right title</h1><p class="by">By <span>Jane Doe</span></p>
+<time datetime="2024-05-01T10:00:00Z">May 1st</time><p>Post body</p></div>
+<footer>Footer</footer>
+</body></html>`
+
+func TestLoadExtractionRules(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(valid, []byte(`[{"domains": ["example.com"], "body": ".post", "title": "h1"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`[{"domains": ["example.com"], "body": "[["}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := loadExtractionRules(valid)
+	if len(rules) != len(builtinExtractionRules)+1 {
+		t.Fatalf("loaded %d rules; want the file's one and the built-in ones", len(rules))
+	}
+	if rule := matchExtractionRule(rules, "blog.Example.com"); rule == nil || rule.Body != ".post" {
+		t.Errorf("subdomain not matched: %+v", rule)
+	}
+	if rule := matchExtractionRule(rules, "en.wikipedia.org"); rule == nil || rule.Title != "#firstHeading" {
+		t.Errorf("built-in rule not matched: %+v", rule)
+	}
+	if rule := matchExtractionRule(rules, "notexample.com"); rule != nil {
+		t.Errorf("unrelated domain matched: %+v", rule)
+	}
+
+	for _, path := range []string{invalid, filepath.Join(dir, "missing.json")} {
+		if rules := loadExtractionRules(path); len(rules) != len(builtinExtractionRules) {
+			t.Errorf("loadExtractionRules(%q) loaded %d rules; want only the built-in ones", path, len(rules))
+		}
+	}
+}
+
+func TestExtractionRuleApply(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(rulesPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := renderNode(t, doc)
+	rule := ExtractionRule{Domains: []string{"example.com"}, Body: ".post", Title: ".headline", Author: ".by span", Date: "time"}
+	if err := rule.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, metadata := rule.Apply(doc, true)
+	if !metadata {
+		t.Error("Apply() reported no metadata")
+	}
+	got := renderNode(t, out)
+	for _, want := range []string{
+		`<title>Right title</title>`,
+		`<meta property="dc:title" content="Right title"/>`,
+		`<meta property="dc:creator" content="Jane Doe"/>`,
+		`<meta property="article:published_time" content="2024-05-01T10:00:00Z"/>`,
+		`<body><div class="post">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "Menu") {
+		t.Errorf("body not narrowed to the rule's element: %s", got)
+	}
+	if renderNode(t, doc) != original {
+		t.Error("Apply() modified the original document")
+	}
+
+	if out, _ := rule.Apply(doc, false); !strings.Contains(renderNode(t, out), "Menu") {
+		t.Error("body narrowed although another selector takes precedence")
+	}
+}
+
+func TestExtractArticleWithRule(t *testing.T) {
+	old := extractionRules
+	extractionRules = []ExtractionRule{{Domains: []string{"example.com"}, Body: ".post", Title: ".headline"}}
+	if err := extractionRules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { extractionRules = old }()
+
+	doc, err := html.Parse(strings.NewReader(rulesPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, _ := url.Parse("https://www.example.com/post")
+	article, err := Extract(context.Background(), doc, link, 0, Options{})
+	if err != nil {
+		t.Fatalf("extractArticle() error = %v", err)
+	}
+	if title := article.Title(); title != "Right title" {
+		t.Errorf("title = %q; want the rule's", title)
+	}
+	if content := dom.TextContent(article.Node); strings.Contains(content, "Menu") || !strings.Contains(content, "Post body") {
+		t.Errorf("content = %q; want the rule's body", content)
+	}
+}
+
+func renderNode(t *testing.T, n *html.Node) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := html.Render(&sb, n); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
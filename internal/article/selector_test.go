@@ -0,0 +1,88 @@
+package article
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+func TestApplyCSSSelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorFixture))
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	selected, found := ApplyCSSSelector(doc, mustParseSelector(t, "article.paywall-bypass"))
+	if !found {
+		t.Fatal("ApplyCSSSelector did not match the <article> element")
+	}
+	var out strings.Builder
+	if err := html.Render(&out, selected); err != nil {
+		t.Fatalf("failed to render selection: %v", err)
+	}
+	if strings.Contains(out.String(), "Subscribe now") {
+		t.Errorf("selection still contains content outside the selector: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "<title>Selector Title</title>") {
+		t.Errorf("selection lost the document head: %q", out.String())
+	}
+
+	// the original document must be left untouched
+	out.Reset()
+	if err := html.Render(&out, doc); err != nil {
+		t.Fatalf("failed to render document: %v", err)
+	}
+	if !strings.Contains(out.String(), "Subscribe now") {
+		t.Error("ApplyCSSSelector mutated the original document")
+	}
+
+	if got, found := ApplyCSSSelector(doc, mustParseSelector(t, "#missing")); found || got != doc {
+		t.Error("ApplyCSSSelector should fall back to the full document when nothing matches")
+	}
+	if _, found := ApplyCSSSelector(doc, mustParseSelector(t, "html")); !found {
+		t.Error("ApplyCSSSelector should accept selectors matching an ancestor of <body>")
+	}
+}
+
+func TestExtractArticleSelectorOnly(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><title>Exact</title></head><body>
+<div class="post"><h2>Kept heading</h2><p onclick="steal()">Short <a href="/next">link</a></p><script>track()</script><img src="img/a.png"></div>
+<p>Outside</p></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, _ := url.Parse("https://example.com/blog/post")
+	article, err := Extract(context.Background(), doc, link, 0, Options{Selector: mustParseSelector(t, ".post"), SelectorOnly: true})
+	if err != nil {
+		t.Fatalf("extractArticle() error = %v", err)
+	}
+	var out strings.Builder
+	if err := html.Render(&out, article.Node); err != nil {
+		t.Fatal(err)
+	}
+	want := `<div><div class="post"><h2>Kept heading</h2><p>Short <a href="https://example.com/next">link</a></p><img src="https://example.com/blog/img/a.png"/></div></div>`
+	if got := strings.ReplaceAll(out.String(), "\n", ""); got != want {
+		t.Errorf("content = %q; want %q", got, want)
+	}
+	if article.Title() != "Exact" {
+		t.Errorf("title = %q; want the page metadata", article.Title())
+	}
+}
+
+const selectorFixture = `<html><head><title>Selector Title</title></head><body>
+<div class="teaser"><p>Subscribe now to read this article, it is a very long teaser paragraph that readability may prefer over the real content because it is long.</p></div>
+<article class="paywall-bypass"><p>The real article body.</p></article>
+</body></html>`
+
+func mustParseSelector(t *testing.T, raw string) cascadia.Sel {
+	t.Helper()
+	sel, err := cascadia.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse selector %q: %v", raw, err)
+	}
+	return sel
+}
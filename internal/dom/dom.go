@@ -0,0 +1,111 @@
+/**
+ * Package dom holds helpers for working with parsed HTML trees.
+ */
+package dom
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/**
+ * CloneNode returns a deep copy of n, detached from any tree.
+ */
+func CloneNode(n *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      slices.Clone(n.Attr),
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		clone.AppendChild(CloneNode(c))
+	}
+	return clone
+}
+
+/**
+ * OrEmpty returns n, or an empty node when n is nil (articles without content).
+ */
+func OrEmpty(n *html.Node) *html.Node {
+	if n == nil {
+		return &html.Node{Type: html.DocumentNode}
+	}
+	return n
+}
+
+/**
+ * FindElement returns the first element with the given tag name under n, in document order.
+ */
+func FindElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := FindElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+/**
+ * ParseFragment parses an HTML fragment of the article content, returning
+ * it wrapped in a <div> like the content root readability produces.
+ */
+func ParseFragment(fragment string) *html.Node {
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		log.Printf("error parsing content fragment: %v", err)
+	}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root
+}
+
+/**
+ * TextContent returns the concatenated text of a node, skipping non-visible elements.
+ */
+func TextContent(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && slices.Contains([]string{"script", "style", "noscript", "template"}, n.Data):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+/**
+ * Attr returns the value of the named attribute, or "" when absent.
+ */
+func Attr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// HasAttr reports whether the element has the attribute key, even if empty.
+func HasAttr(n *html.Node, key string) bool {
+	return slices.ContainsFunc(n.Attr, func(attr html.Attribute) bool {
+		return strings.EqualFold(attr.Key, key)
+	})
+}
@@ -0,0 +1,47 @@
+package dom
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/**
+ * unsafeElements are dropped by Sanitize: they run code, embed other
+ * documents or submit data.
+ */
+var unsafeElements = []string{
+	"script", "style", "noscript", "template", "iframe", "frame", "object", "embed",
+	"form", "input", "button", "select", "textarea", "link", "meta", "base",
+}
+
+/**
+ * Sanitize removes what could run code from the tree, in place, for
+ * content handed to other sites: unsafeElements, comments, event handler and
+ * style attributes, and script URLs.
+ */
+func Sanitize(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == html.CommentNode:
+			n.RemoveChild(c)
+		case c.Type != html.ElementNode:
+		case slices.Contains(unsafeElements, c.Data):
+			n.RemoveChild(c)
+		default:
+			c.Attr = slices.DeleteFunc(c.Attr, func(a html.Attribute) bool {
+				if a.Key == "href" || a.Key == "src" || a.Key == "action" || a.Key == "formaction" {
+					scheme, _, found := strings.Cut(strings.ToLower(strings.TrimSpace(a.Val)), ":")
+					if found && (scheme == "javascript" || scheme == "vbscript") {
+						return true
+					}
+				}
+				return a.Namespace != "" || a.Key == "style" || strings.HasPrefix(a.Key, "on")
+			})
+			Sanitize(c)
+		}
+		c = next
+	}
+}
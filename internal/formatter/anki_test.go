@@ -0,0 +1,23 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatAnki(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatAnki(rec, readability.Article{}, bytes.NewBufferString(`<p>Water boils at <b>100 °C</b> at sea level.</p>`), Options{})
+
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="flashcards.txt"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	want := "#separator:tab\n#html:true\n#columns:Front\tBack\n" +
+		"Water boils at _____ at sea level.\tWater boils at <b>100 °C</b> at sea level.\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatAnki() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+// ANSI escape sequences used by formatANSI. Each style has its own reset so
+// nested styles (e.g. bold inside a heading) don't cancel each other.
+const (
+	ansiBold         = "\x1b[1m"
+	ansiBoldOff      = "\x1b[22m"
+	ansiItalic       = "\x1b[3m"
+	ansiItalicOff    = "\x1b[23m"
+	ansiUnderline    = "\x1b[4m"
+	ansiUnderlineOff = "\x1b[24m"
+	ansiReverse      = "\x1b[7m"
+	ansiReverseOff   = "\x1b[27m"
+	ansiBlue         = "\x1b[34m"
+	ansiColorOff     = "\x1b[39m"
+)
+
+/**
+ * formatANSI renders the article as text with ANSI escape codes, for terminals and pagers.
+ *
+ * Headings are bold and underlined, <b>/<strong> bold, <i>/<em> italic, code in
+ * reverse video and links are followed by their URL in blue.
+ * Selected with `?format=ansi` or `Accept: text/x-ansi`.
+ */
+func formatANSI(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for ansi: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := article.Title(); title != "" {
+		sb.WriteString(ansiBold + ansiUnderline + title + ansiUnderlineOff + ansiBoldOff + "\n\n")
+	}
+	renderANSI(&sb, doc, false)
+	if _, err := io.WriteString(w, strings.TrimSpace(NormalizeWhitespace(sb.String()))+"\n"); err != nil {
+		log.Printf("error writing ansi response: %v", err)
+	}
+}
+
+/**
+ * renderANSI writes the text of n and its children to sb, wrapping styled elements in ANSI codes.
+ * Whitespace is collapsed like a browser would, except inside <pre>.
+ */
+func renderANSI(sb *strings.Builder, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			sb.WriteString(n.Data)
+			return
+		}
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			return
+		}
+		if strings.TrimLeft(n.Data, " \t\n\r") != n.Data && !strings.HasSuffix(sb.String(), "\n") && !strings.HasSuffix(sb.String(), " ") {
+			text = " " + text
+		}
+		if strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+			text += " "
+		}
+		sb.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderANSI(sb, c, pre)
+		}
+		return
+	}
+
+	children := func() {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderANSI(sb, c, pre || n.Data == "pre")
+		}
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		sb.WriteString("\n\n" + ansiBold + ansiUnderline)
+		children()
+		sb.WriteString(ansiUnderlineOff + ansiBoldOff + "\n\n")
+	case "b", "strong":
+		sb.WriteString(ansiBold)
+		children()
+		sb.WriteString(ansiBoldOff)
+	case "i", "em":
+		sb.WriteString(ansiItalic)
+		children()
+		sb.WriteString(ansiItalicOff)
+	case "code":
+		sb.WriteString(ansiReverse)
+		children()
+		sb.WriteString(ansiReverseOff)
+	case "pre":
+		sb.WriteString("\n\n" + ansiReverse)
+		children()
+		sb.WriteString(ansiReverseOff + "\n\n")
+	case "a":
+		children()
+		if href := dom.Attr(n, "href"); href != "" {
+			sb.WriteString(" (" + ansiBlue + href + ansiColorOff + ")")
+		}
+	case "br":
+		sb.WriteString("\n")
+	case "li":
+		sb.WriteString("\n  • ")
+		children()
+	case "p", "div", "section", "article", "blockquote", "ul", "ol", "table", "tr", "figure", "hr":
+		sb.WriteString("\n\n")
+		children()
+		sb.WriteString("\n\n")
+	default:
+		children()
+	}
+}
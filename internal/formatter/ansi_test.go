@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatANSI(t *testing.T) {
+	buf := bytes.NewBufferString(`<h2>Section</h2><p>Some <strong>bold</strong> and <em>italic</em> text with a <a href="https://example.com">link</a>.</p><pre>x := 1
+y := 2</pre><ul><li>one</li><li>two</li></ul>`)
+	rec := httptest.NewRecorder()
+	formatANSI(rec, readability.Article{}, buf, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q; want text/plain; charset=utf-8", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		ansiBold + ansiUnderline + "Section" + ansiUnderlineOff + ansiBoldOff,
+		"Some " + ansiBold + "bold" + ansiBoldOff + " and",
+		ansiItalic + "italic" + ansiItalicOff,
+		"link (" + ansiBlue + "https://example.com" + ansiColorOff + ")",
+		ansiReverse + "x := 1\ny := 2" + ansiReverseOff,
+		"  • one\n  • two",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatANSI output missing %q, got: %q", want, body)
+		}
+	}
+	if strings.Contains(body, "<") {
+		t.Errorf("formatANSI output contains HTML: %q", body)
+	}
+}
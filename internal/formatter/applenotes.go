@@ -0,0 +1,241 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatAppleNotes returns the article as HTML that Apple Notes imports cleanly
+ * when pasted: only <h1>, <h2>, <p>, <ul>, <ol>, <li>, <b>, <i>, <a> and <img>,
+ * without class, style or any attribute but href, src and alt.
+ *
+ * The title is the only <h1>, and headings of the content become <h2> (or bold
+ * paragraphs below h2). Containers are unwrapped, code blocks and table rows are
+ * split into paragraphs, and loose text is wrapped in <p>.
+ */
+func formatAppleNotes(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for apple notes: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
+		return
+	}
+	var a appleNotesWriter
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		a.sb.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	}
+	a.block(doc)
+	a.closePara()
+	if _, err := io.WriteString(w, a.sb.String()); err != nil {
+		log.Printf("error writing apple notes response: %v", err)
+	}
+}
+
+/**
+ * appleNotesWriter accumulates the HTML of formatAppleNotes. Like docBookWriter,
+ * it collects the inline content found between blocks into a paragraph.
+ */
+type appleNotesWriter struct {
+	sb strings.Builder
+	// loose holds the inline nodes met since the last block.
+	loose []*html.Node
+}
+
+// closePara writes the loose inline content collected so far as a <p>.
+func (a *appleNotesWriter) closePara() {
+	a.para(a.loose...)
+	a.loose = nil
+}
+
+// para writes nodes as the inline content of a <p>, skipping it when blank.
+func (a *appleNotesWriter) para(nodes ...*html.Node) {
+	var content appleNotesWriter
+	for _, n := range nodes {
+		content.inline(n)
+	}
+	if text := strings.TrimSpace(content.sb.String()); text != "" {
+		a.sb.WriteString("<p>" + text + "</p>\n")
+	}
+}
+
+/**
+ * block writes the children of n as Apple Notes blocks.
+ */
+func (a *appleNotesWriter) block(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode || c.Type == html.ElementNode && (slices.Contains(meta.InlineElements, c.Data) || c.Data == "br" || c.Data == "img" || c.Data == "del"):
+			a.loose = append(a.loose, c)
+			continue
+		case c.Type != html.ElementNode:
+			a.block(c)
+			continue
+		}
+
+		switch c.Data {
+		case "script", "style", "noscript", "template", "hr":
+			continue
+		}
+		a.closePara()
+		switch c.Data {
+		case "h1", "h2":
+			var content appleNotesWriter
+			content.inline(c)
+			if text := strings.TrimSpace(content.sb.String()); text != "" {
+				a.sb.WriteString("<h2>" + text + "</h2>\n")
+			}
+		case "h3", "h4", "h5", "h6":
+			var content appleNotesWriter
+			content.inline(c)
+			if text := strings.TrimSpace(content.sb.String()); text != "" {
+				a.sb.WriteString("<p><b>" + text + "</b></p>\n")
+			}
+		case "p":
+			var children []*html.Node
+			for child := c.FirstChild; child != nil; child = child.NextSibling {
+				children = append(children, child)
+			}
+			a.para(children...)
+		case "pre":
+			for line := range strings.SplitSeq(strings.Trim(dom.TextContent(c), "\n"), "\n") {
+				if strings.TrimSpace(line) != "" {
+					a.sb.WriteString("<p>" + html.EscapeString(line) + "</p>\n")
+				}
+			}
+		case "ul", "ol":
+			a.list(c)
+		case "tr":
+			var cells []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					var content appleNotesWriter
+					content.inline(cell)
+					cells = append(cells, strings.TrimSpace(content.sb.String()))
+				}
+			}
+			if row := strings.Join(cells, " | "); strings.Trim(row, " |") != "" {
+				a.sb.WriteString("<p>" + row + "</p>\n")
+			}
+		default:
+			a.block(c)
+			a.closePara()
+		}
+	}
+}
+
+// list writes a <ul> or <ol> and its items.
+func (a *appleNotesWriter) list(n *html.Node) {
+	a.sb.WriteString("<" + n.Data + ">\n")
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type == html.ElementNode && li.Data == "li" {
+			a.item(li)
+		}
+	}
+	a.sb.WriteString("</" + n.Data + ">\n")
+}
+
+/**
+ * item writes a list item: its text inline, followed by its nested lists.
+ */
+func (a *appleNotesWriter) item(li *html.Node) {
+	var content, nested appleNotesWriter
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol"):
+				nested.list(c)
+			case c.Type == html.ElementNode && slices.Contains([]string{"p", "div", "section", "blockquote", "figure"}, c.Data):
+				if content.sb.Len() > 0 && !strings.HasSuffix(content.sb.String(), " ") {
+					content.sb.WriteString(" ")
+				}
+				walk(c)
+			default:
+				content.inline(c)
+			}
+		}
+	}
+	walk(li)
+	a.sb.WriteString("<li>" + strings.TrimSpace(content.sb.String()))
+	if nested.sb.Len() > 0 {
+		a.sb.WriteString("\n" + nested.sb.String())
+	}
+	a.sb.WriteString("</li>\n")
+}
+
+/**
+ * inline writes n as Apple Notes inline content, unwrapping every element but
+ * <b>, <i>, <a> and <img>.
+ */
+func (a *appleNotesWriter) inline(n *html.Node) {
+	children := func() {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			a.inline(c)
+		}
+	}
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if strings.TrimLeft(n.Data, " \t\n\r") != n.Data && !strings.HasSuffix(a.sb.String(), " ") {
+			text = " " + text
+		}
+		if text != " " && strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+			text += " "
+		}
+		a.sb.WriteString(html.EscapeString(text))
+		return
+	case html.ElementNode:
+	default:
+		children()
+		return
+	}
+
+	wrap := func(tag string) {
+		a.sb.WriteString("<" + tag + ">")
+		children()
+		a.sb.WriteString("</" + tag + ">")
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "b", "strong":
+		wrap("b")
+	case "i", "em":
+		wrap("i")
+	case "a":
+		href, err := url.Parse(strings.TrimSpace(dom.Attr(n, "href")))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+			children()
+			return
+		}
+		a.sb.WriteString(`<a href="` + html.EscapeString(href.String()) + `">`)
+		children()
+		a.sb.WriteString("</a>")
+	case "img":
+		src, err := url.Parse(strings.TrimSpace(dom.Attr(n, "src")))
+		if err != nil || (src.Scheme != "http" && src.Scheme != "https" && src.Scheme != "data") {
+			return
+		}
+		a.sb.WriteString(`<img src="` + html.EscapeString(src.String()) + `"`)
+		if alt := strings.TrimSpace(dom.Attr(n, "alt")); alt != "" {
+			a.sb.WriteString(` alt="` + html.EscapeString(alt) + `"`)
+		}
+		a.sb.WriteString(">")
+	case "br":
+		a.sb.WriteString(" ")
+	default:
+		children()
+	}
+}
@@ -0,0 +1,66 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatAppleNotes(t *testing.T) {
+	input := `<div class="wrapper" style="color:red"><h1 class="x">Top</h1><h3>Minor</h3>
+<p style="margin:0">Some <strong>bold</strong>, <em class="e">italic</em>, <span>span</span> and <a href="https://example.com/" target="_blank" class="l">link</a>.</p>
+<section>Loose <code>code</code> text<br>after</section>
+<ul><li><p>Item</p><ol><li>Nested</li></ol></li></ul>
+<pre>line one
+line two</pre>
+<table><tr><td>a</td><td>b</td></tr></table>
+<figure><img src="https://example.com/a.png" alt="A" class="i" width="10"><figcaption>Caption</figcaption></figure>
+<a href="javascript:alert(1)">script</a>
+</div>`
+	rec := httptest.NewRecorder()
+	formatAppleNotes(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+
+	allowedTags := []string{"h1", "h2", "ul", "ol", "li", "b", "i", "a", "p", "img"}
+	allowedAttrs := []string{"href", "src", "alt"}
+	tokens := html.NewTokenizer(strings.NewReader(body))
+	for tokens.Next() != html.ErrorToken {
+		token := tokens.Token()
+		if token.Type != html.StartTagToken && token.Type != html.EndTagToken && token.Type != html.SelfClosingTagToken {
+			continue
+		}
+		if !slices.Contains(allowedTags, token.Data) {
+			t.Errorf("disallowed tag <%s> in %s", token.Data, body)
+		}
+		for _, attr := range token.Attr {
+			if !slices.Contains(allowedAttrs, attr.Key) {
+				t.Errorf("disallowed attribute %s on <%s>", attr.Key, token.Data)
+			}
+		}
+	}
+
+	for _, want := range []string{
+		"<h2>Top</h2>",
+		"<p><b>Minor</b></p>",
+		`<p>Some <b>bold</b>, <i>italic</i>, span and <a href="https://example.com/">link</a>.</p>`,
+		"<p>Loose code text after</p>",
+		"<li>Item\n<ol>\n<li>Nested</li>\n</ol>\n</li>",
+		"<p>line one</p>\n<p>line two</p>",
+		"<p>a | b</p>",
+		`<p><img src="https://example.com/a.png" alt="A"></p>`,
+		"<p>Caption</p>",
+		"<p>script</p>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output missing %q:\n%s", want, body)
+		}
+	}
+}
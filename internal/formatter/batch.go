@@ -0,0 +1,95 @@
+package formatter
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+/**
+ * BatchResult is the outcome of one batch item.
+ *
+ * Content holds the rendered article exactly as Handler would have returned it for
+ * that format, with its ContentType. Failed items have Error set instead.
+ *
+ * JSON strings can't hold the output of binaryFormats, so in JSON their Content is
+ * base64-encoded and ContentEncoding is "base64".
+ */
+type BatchResult struct {
+	URL             string `json:"url"`
+	Format          string `json:"format"`
+	Status          int    `json:"status"`
+	ContentType     string `json:"content_type,omitempty"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	Content         string `json:"content,omitempty"`
+	Error           string `json:"error,omitempty"`
+
+	// Link is the normalized item URL, unset for items that failed validation.
+	Link *url.URL `json:"-"`
+	// Entry is the item's link for instapaper batches (see WriteReadingList).
+	Entry *ReadingListEntry `json:"-"`
+}
+
+/**
+ * binaryFormats are the formats whose output is not text.
+ */
+var binaryFormats = []string{"epub", "kindle", "pdf", "zip"}
+
+func (b BatchResult) MarshalJSON() ([]byte, error) {
+	type plain BatchResult // avoids recursing into this method
+	if b.Content != "" && slices.Contains(binaryFormats, b.Format) {
+		b.Content = base64.StdEncoding.EncodeToString([]byte(b.Content))
+		b.ContentEncoding = "base64"
+	}
+	return json.Marshal(plain(b))
+}
+
+/**
+ * WriteBatchMultipart writes the batch results as a multipart/mixed response, so
+ * large articles don't have to be embedded in a single JSON document.
+ *
+ * Each part holds one result in input order: its rendered content with the
+ * Content-Type of its format, or a JSON error. The item URL, format and status are
+ * given by the Content-Location, X-Format and X-Status part headers. The first two
+ * are only set once the URL and format passed validation, as part headers are
+ * written verbatim and the raw values could carry line breaks.
+ */
+func WriteBatchMultipart(w http.ResponseWriter, results []BatchResult) {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	for _, result := range results {
+		header := textproto.MIMEHeader{}
+		if result.Link != nil {
+			header.Set("Content-Location", result.Link.String())
+		}
+		if _, found := Formatters[result.Format]; found {
+			header.Set("X-Format", result.Format)
+		}
+		header.Set("X-Status", strconv.Itoa(result.Status))
+		content := []byte(result.Content)
+		if result.Error != "" {
+			header.Set("Content-Type", "application/json")
+			content, _ = json.Marshal(map[string]string{"error": result.Error})
+		} else {
+			header.Set("Content-Type", cmp.Or(result.ContentType, "application/octet-stream"))
+		}
+		part, err := mw.CreatePart(header)
+		if err == nil {
+			_, err = part.Write(content)
+		}
+		if err != nil {
+			log.Printf("error writing batch part: %v", err)
+			return
+		}
+	}
+	if err := mw.Close(); err != nil {
+		log.Printf("error closing batch response: %v", err)
+	}
+}
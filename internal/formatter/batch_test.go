@@ -0,0 +1,110 @@
+package formatter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteBatchMultipart(t *testing.T) {
+	link := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	results := []BatchResult{
+		{URL: "https://example.com/a", Link: link("https://example.com/a"), Format: "md", Status: http.StatusOK, ContentType: "text/markdown; charset=utf-8", Content: "# A\n"},
+		{URL: "https://example.com/b", Link: link("https://example.com/b"), Format: "json", Status: http.StatusOK, ContentType: "application/json", Content: `{"title":"B"}`},
+		{URL: "ftp://example.com", Format: "md", Status: http.StatusBadRequest, Error: "Invalid URL provided"},
+		{URL: "https://example.com/\r\nX-Injected: 1", Format: "md\r\nX-Injected: 2", Status: http.StatusBadRequest, Error: "invalid format"},
+	}
+	rec := httptest.NewRecorder()
+	WriteBatchMultipart(rec, results)
+
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" || params["boundary"] == "" {
+		t.Fatalf("Content-Type = %q (%v)", rec.Header().Get("Content-Type"), err)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "--"+params["boundary"]+"\r\n") {
+		t.Errorf("body does not start with the boundary: %q", rec.Body.String())
+	}
+
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	for i, want := range results {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		wantLocation := ""
+		if want.Link != nil {
+			wantLocation = want.URL
+		}
+		if got := part.Header.Get("Content-Location"); got != wantLocation {
+			t.Errorf("part %d Content-Location = %q; want %q", i, got, wantLocation)
+		}
+		if got := part.Header.Get("X-Injected"); got != "" {
+			t.Errorf("part %d has an injected header: %q", i, got)
+		}
+		if got := part.Header.Get("X-Status"); got != strconv.Itoa(want.Status) {
+			t.Errorf("part %d X-Status = %q; want %d", i, got, want.Status)
+		}
+		if want.Error != "" {
+			var payload map[string]string
+			if err := json.Unmarshal(body, &payload); err != nil || payload["error"] != want.Error {
+				t.Errorf("part %d error body = %q (%v)", i, body, err)
+			}
+			continue
+		}
+		if got := part.Header.Get("Content-Type"); got != want.ContentType {
+			t.Errorf("part %d Content-Type = %q; want %q", i, got, want.ContentType)
+		}
+		if string(body) != want.Content {
+			t.Errorf("part %d body = %q; want %q", i, body, want.Content)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected exactly %d parts, got error %v", len(results), err)
+	}
+}
+
+func TestBatchResultJSONEncodesBinaryContent(t *testing.T) {
+	binary := string([]byte{'P', 'K', 3, 4, 0xff, 0xfe})
+	for _, tt := range []struct {
+		result   BatchResult
+		content  string
+		encoding string
+	}{
+		{BatchResult{Format: "zip", Status: http.StatusOK, Content: binary}, base64.StdEncoding.EncodeToString([]byte(binary)), "base64"},
+		{BatchResult{Format: "md", Status: http.StatusOK, Content: "# A\n"}, "# A\n", ""},
+		{BatchResult{Format: "pdf", Status: http.StatusBadRequest, Error: "invalid format"}, "", ""},
+	} {
+		data, err := json.Marshal(tt.result)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.result.Format, err)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: invalid JSON %s: %v", tt.result.Format, data, err)
+		}
+		if content, _ := got["content"].(string); content != tt.content {
+			t.Errorf("%s content = %q; want %q", tt.result.Format, content, tt.content)
+		}
+		if encoding, _ := got["content_encoding"].(string); encoding != tt.encoding {
+			t.Errorf("%s content_encoding = %q; want %q", tt.result.Format, encoding, tt.encoding)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatBearBlog(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/title_only.html")
+	input := `<h1>Why Go Wins</h1><p>Some <strong>bold</strong> text.</p><h2>Details</h2><ul><li>One</li><li>Two</li></ul>`
+	rec := httptest.NewRecorder()
+	formatBearBlog(rec, article, bytes.NewBufferString(input), Options{})
+	body := rec.Body.String()
+
+	if !strings.HasPrefix(body, "# Why Go Wins\n\n") {
+		t.Errorf("output doesn't start with the title heading: %q", body)
+	}
+	if strings.Contains(body, "---") {
+		t.Errorf("output has frontmatter: %q", body)
+	}
+	var h1 int
+	for line := range strings.SplitSeq(body, "\n") {
+		if strings.HasPrefix(line, "# ") {
+			h1++
+		}
+	}
+	if h1 != 1 {
+		t.Errorf("got %d top level headings; want only the title: %q", h1, body)
+	}
+	if !strings.Contains(body, "Details") || !strings.Contains(body, "bold") {
+		t.Errorf("markdown body missing: %q", body)
+	}
+}
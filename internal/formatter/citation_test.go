@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatCitations(t *testing.T) {
+	link, _ := url.Parse("https://www.example.com/post")
+	tests := []struct {
+		name        string
+		format      formatHandler
+		contentType string
+		prefix      string
+	}{
+		{"bibtex", formatBibTeX, "application/x-bibtex; charset=utf-8", "@article{"},
+		{"ris", formatRIS, "application/x-research-info-systems; charset=utf-8", "TY  - JOUR\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.format(rec, readability.Article{}, &bytes.Buffer{}, Options{Link: link})
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if body := rec.Body.String(); !strings.HasPrefix(body, tt.prefix) || !strings.Contains(body, "example.com") {
+				t.Errorf("body = %q", body)
+			}
+		})
+	}
+}
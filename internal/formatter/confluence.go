@@ -0,0 +1,129 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatConfluence returns the article in the Confluence storage format, the
+ * XHTML Confluence keeps pages in, ready for its REST API or the source editor.
+ *
+ * Paragraphs, headings, lists, tables and inline markup stay as XHTML; code blocks
+ * become code macros, images <ac:image> and <details> expand macros whose content
+ * is an <ac:rich-text-body>. As storage format content is a fragment, it is wrapped
+ * in an <ac:confluence> root declaring the ac: and ri: namespaces.
+ */
+func formatConfluence(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for confluence: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<ac:confluence xmlns="http://www.w3.org/1999/xhtml" xmlns:ac="http://www.atlassian.com/schema/confluence/4/ac/" xmlns:ri="http://www.atlassian.com/schema/confluence/4/ri/">` + "\n")
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		sb.WriteString("<h1>" + xmlTextEscaper.Replace(title) + "</h1>\n")
+	}
+	renderConfluence(&sb, doc)
+	sb.WriteString("\n</ac:confluence>\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing confluence response: %v", err)
+	}
+}
+
+/**
+ * confluenceElements maps the HTML elements kept in the Confluence storage format
+ * to their storage name. Other elements are unwrapped, keeping their content.
+ */
+var confluenceElements = map[string]string{
+	"p": "p", "h1": "h1", "h2": "h2", "h3": "h3", "h4": "h4", "h5": "h5", "h6": "h6",
+	"ul": "ul", "ol": "ol", "li": "li", "blockquote": "blockquote",
+	"b": "strong", "strong": "strong", "i": "em", "em": "em", "u": "u",
+	"s": "s", "del": "s", "strike": "s", "sub": "sub", "sup": "sup", "code": "code",
+	"table": "table", "thead": "thead", "tbody": "tbody", "tr": "tr", "th": "th", "td": "td",
+}
+
+/**
+ * renderConfluence writes the children of n to sb in the Confluence storage format.
+ */
+func renderConfluence(sb *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			sb.WriteString(xmlTextEscaper.Replace(c.Data))
+			continue
+		case html.ElementNode:
+		default:
+			renderConfluence(sb, c)
+			continue
+		}
+
+		switch c.Data {
+		case "script", "style", "noscript", "template":
+		case "pre":
+			sb.WriteString(`<ac:structured-macro ac:name="code">`)
+			if lang := codeLanguage(c); lang != "" {
+				sb.WriteString(`<ac:parameter ac:name="language">` + xmlTextEscaper.Replace(lang) + "</ac:parameter>")
+			}
+			// CDATA can't hold its own terminator, so it is split around it
+			code := strings.ReplaceAll(strings.Trim(dom.TextContent(c), "\n"), "]]>", "]]]]><![CDATA[>")
+			sb.WriteString("<ac:plain-text-body><![CDATA[" + code + "]]></ac:plain-text-body></ac:structured-macro>\n")
+		case "img":
+			src, err := url.Parse(strings.TrimSpace(dom.Attr(c, "src")))
+			if err != nil || (src.Scheme != "http" && src.Scheme != "https") {
+				continue
+			}
+			sb.WriteString("<ac:image")
+			if alt := strings.TrimSpace(dom.Attr(c, "alt")); alt != "" {
+				sb.WriteString(` ac:alt="` + html.EscapeString(alt) + `"`)
+			}
+			sb.WriteString(`><ri:url ri:value="` + html.EscapeString(src.String()) + `"/></ac:image>`)
+		case "a":
+			href, err := url.Parse(strings.TrimSpace(dom.Attr(c, "href")))
+			if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+				renderConfluence(sb, c)
+				continue
+			}
+			sb.WriteString(`<a href="` + html.EscapeString(href.String()) + `">`)
+			renderConfluence(sb, c)
+			sb.WriteString("</a>")
+		case "br":
+			sb.WriteString("<br/>")
+		case "hr":
+			sb.WriteString("<hr/>\n")
+		case "details":
+			sb.WriteString(`<ac:structured-macro ac:name="expand">`)
+			if summary := dom.FindElement(c, "summary"); summary != nil {
+				title := strings.Join(strings.Fields(dom.TextContent(summary)), " ")
+				sb.WriteString(`<ac:parameter ac:name="title">` + xmlTextEscaper.Replace(title) + "</ac:parameter>")
+			}
+			sb.WriteString("<ac:rich-text-body>")
+			renderConfluence(sb, c)
+			sb.WriteString("</ac:rich-text-body></ac:structured-macro>\n")
+		case "summary":
+		default:
+			tag, found := confluenceElements[c.Data]
+			if !found {
+				renderConfluence(sb, c)
+				continue
+			}
+			sb.WriteString("<" + tag + ">")
+			renderConfluence(sb, c)
+			sb.WriteString("</" + tag + ">")
+		}
+	}
+}
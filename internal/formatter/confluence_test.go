@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+const confluenceNamespace = "http://www.atlassian.com/schema/confluence/4/ac/"
+
+func TestFormatConfluence(t *testing.T) {
+	input := `<div class="page"><h2>Intro</h2><p>Some <b>bold</b> &amp; <a href="https://example.com/?a=1&amp;b=2" class="x">link</a><br>next</p>
+<pre><code class="language-go">if a &lt; b &amp;&amp; c[d[0]]&gt;0 {
+	return "]]&gt;"
+}</code></pre>
+<img src="https://example.com/a.png" alt="A &quot;quoted&quot; image">
+<details><summary>More</summary><p>Hidden</p></details>
+<table><tr><th>H</th></tr><tr><td>C</td></tr></table></div>`
+	rec := httptest.NewRecorder()
+	formatConfluence(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+	body := rec.Body.String()
+
+	var root struct {
+		XMLName xml.Name
+		Macros  []struct {
+			Name       string `xml:"http://www.atlassian.com/schema/confluence/4/ac/ name,attr"`
+			Parameters []struct {
+				Name  string `xml:"http://www.atlassian.com/schema/confluence/4/ac/ name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"http://www.atlassian.com/schema/confluence/4/ac/ parameter"`
+			PlainText string `xml:"http://www.atlassian.com/schema/confluence/4/ac/ plain-text-body"`
+			RichText  *struct {
+				Inner string `xml:",innerxml"`
+			} `xml:"http://www.atlassian.com/schema/confluence/4/ac/ rich-text-body"`
+		} `xml:"http://www.atlassian.com/schema/confluence/4/ac/ structured-macro"`
+		Images []struct {
+			Alt string `xml:"http://www.atlassian.com/schema/confluence/4/ac/ alt,attr"`
+			URL struct {
+				Value string `xml:"http://www.atlassian.com/schema/confluence/4/ri/ value,attr"`
+			} `xml:"http://www.atlassian.com/schema/confluence/4/ri/ url"`
+		} `xml:"http://www.atlassian.com/schema/confluence/4/ac/ image"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &root); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, body)
+	}
+	if root.XMLName.Space != confluenceNamespace || root.XMLName.Local != "confluence" {
+		t.Errorf("root = %+v", root.XMLName)
+	}
+	if !strings.Contains(body, `<ac:structured-macro ac:name="code">`) || !strings.Contains(body, `xmlns:ac="`+confluenceNamespace+`"`) {
+		t.Errorf("ac: prefix missing:\n%s", body)
+	}
+
+	if len(root.Macros) != 2 {
+		t.Fatalf("got %d macros; want code and expand", len(root.Macros))
+	}
+	code := root.Macros[0]
+	if code.Name != "code" || len(code.Parameters) != 1 || code.Parameters[0].Name != "language" || code.Parameters[0].Value != "go" {
+		t.Errorf("code macro = %+v", code)
+	}
+	if want := "if a < b && c[d[0]]>0 {\n\treturn \"]]>\"\n}"; code.PlainText != want {
+		t.Errorf("code body = %q; want %q", code.PlainText, want)
+	}
+	expand := root.Macros[1]
+	if expand.Name != "expand" || expand.Parameters[0].Value != "More" || expand.RichText == nil || !strings.Contains(expand.RichText.Inner, "<p>Hidden</p>") {
+		t.Errorf("expand macro = %+v", expand)
+	}
+
+	if len(root.Images) != 1 || root.Images[0].URL.Value != "https://example.com/a.png" || root.Images[0].Alt != `A "quoted" image` {
+		t.Errorf("images = %+v", root.Images)
+	}
+	for _, want := range []string{"<h2>Intro</h2>", "<strong>bold</strong> &amp; ", `<a href="https://example.com/?a=1&amp;b=2">link</a><br/>next`, "<table><tbody><tr><th>H</th></tr>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "class=") || strings.Contains(body, "<div") {
+		t.Errorf("HTML attributes or containers kept:\n%s", body)
+	}
+}
@@ -0,0 +1,237 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatDiscordMD renders the article as Discord flavored Markdown, for posting in Discord.
+ *
+ * Headings become bold lines, as chat messages shouldn't shout. Besides **bold**,
+ * *italic* and ~~strike~~, Discord has __underline__, and code blocks keep their
+ * language for syntax highlighting. Links are written as plain URLs after their
+ * text, since Discord embeds bare URLs but not Markdown links in every client.
+ */
+func formatDiscordMD(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for discord: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := article.Title(); title != "" {
+		sb.WriteString("**" + discordEscape(title) + "**\n\n")
+	}
+	renderDiscord(&sb, doc, "")
+	if _, err := io.WriteString(w, strings.TrimSpace(NormalizeWhitespace(sb.String()))+"\n"); err != nil {
+		log.Printf("error writing discord response: %v", err)
+	}
+}
+
+// discordEscaper backslash-escapes the characters Discord reads as markup.
+var discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`)
+
+// discordEscape escapes text for Discord Markdown.
+func discordEscape(text string) string {
+	return discordEscaper.Replace(text)
+}
+
+/**
+ * renderDiscord writes n and its children to sb as Discord Markdown. List items are
+ * prefixed with indent, which grows with each nested list.
+ */
+func renderDiscord(sb *strings.Builder, n *html.Node, indent string) {
+	switch n.Type {
+	case html.TextNode:
+		writeCollapsedText(sb, n.Data, discordEscape)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderDiscord(sb, c, indent)
+		}
+		return
+	}
+
+	children := func(sb *strings.Builder) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderDiscord(sb, c, indent)
+		}
+	}
+	inline := func(marker string) {
+		var inner strings.Builder
+		children(&inner)
+		writeWrapped(sb, inner.String(), marker, marker)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+			sb.WriteString("\n\n**" + discordEscape(text) + "**\n\n")
+		}
+	case "b", "strong":
+		inline("**")
+	case "i", "em":
+		inline("*")
+	case "u":
+		inline("__")
+	case "s", "del", "strike":
+		inline("~~")
+	case "code":
+		marker := "`"
+		if strings.Contains(dom.TextContent(n), "`") {
+			marker = "``"
+		}
+		writeWrapped(sb, dom.TextContent(n), marker, marker)
+	case "pre":
+		sb.WriteString("\n\n```" + codeLanguage(n) + "\n" + strings.Trim(dom.TextContent(n), "\n") + "\n```\n\n")
+	case "a":
+		var inner strings.Builder
+		children(&inner)
+		href, err := url.Parse(dom.Attr(n, "href"))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") {
+			sb.WriteString(inner.String())
+			return
+		}
+		// URLs are written unescaped, so Discord still recognizes them
+		if text := strings.TrimSpace(inner.String()); text == "" || text == discordEscape(href.String()) {
+			writeWrapped(sb, strings.Replace(inner.String(), text, href.String(), 1), "", "")
+		} else {
+			writeWrapped(sb, inner.String(), "", " ("+href.String()+")")
+		}
+	case "img":
+		if src, err := url.Parse(dom.Attr(n, "src")); err == nil && (src.Scheme == "http" || src.Scheme == "https") {
+			sb.WriteString("\n" + src.String() + "\n")
+		}
+	case "br":
+		sb.WriteString("\n")
+	case "ul", "ol":
+		number := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				renderDiscord(sb, c, indent)
+				continue
+			}
+			bullet := "-"
+			if n.Data == "ol" {
+				number++
+				bullet = strconv.Itoa(number) + "."
+			}
+			sb.WriteString("\n" + indent + bullet + " ")
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				renderDiscord(sb, gc, indent+"  ")
+			}
+		}
+		sb.WriteString("\n\n")
+	case "blockquote":
+		var inner strings.Builder
+		children(&inner)
+		sb.WriteString("\n\n" + quoteLines(inner.String()) + "\n\n")
+	case "tr":
+		sb.WriteString("\n" + tableRow(n, " | ", func(sb *strings.Builder, n *html.Node) { renderDiscord(sb, n, indent) }))
+	case "p", "div", "section", "article", "table", "figure", "hr":
+		sb.WriteString("\n\n")
+		children(sb)
+		sb.WriteString("\n\n")
+	default:
+		children(sb)
+	}
+}
+
+/**
+ * codeLanguage returns the language of a <pre> block, taken from the
+ * "language-xxx" class of its <code> element, or "" when it declares none.
+ */
+func codeLanguage(pre *html.Node) string {
+	if code := dom.FindElement(pre, "code"); code != nil {
+		for class := range strings.FieldsSeq(dom.Attr(code, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+/**
+ * writeCollapsedText writes the text node data to sb with its whitespace collapsed
+ * like a browser would, escaping the words with escape.
+ */
+func writeCollapsedText(sb *strings.Builder, data string, escape func(string) string) {
+	text := strings.Join(strings.Fields(data), " ")
+	if text == "" {
+		if data != "" && sb.Len() > 0 && !strings.HasSuffix(sb.String(), " ") && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString(" ")
+		}
+		return
+	}
+	text = escape(text)
+	if strings.TrimLeft(data, " \t\n\r") != data && !strings.HasSuffix(sb.String(), "\n") && !strings.HasSuffix(sb.String(), " ") {
+		text = " " + text
+	}
+	if strings.TrimRight(data, " \t\n\r") != data {
+		text += " "
+	}
+	sb.WriteString(text)
+}
+
+/**
+ * writeWrapped writes inner to sb between the open and close markers.
+ *
+ * Chat markups only recognize markers hugging the text ("*bold*", not "* bold *"),
+ * so surrounding whitespace is moved outside of them, and nothing but the
+ * whitespace is written when inner is blank.
+ */
+func writeWrapped(sb *strings.Builder, inner, open, close string) {
+	text := strings.TrimSpace(inner)
+	if strings.TrimLeft(inner, " \t\n") != inner && sb.Len() > 0 && !strings.HasSuffix(sb.String(), " ") && !strings.HasSuffix(sb.String(), "\n") {
+		sb.WriteString(" ")
+	}
+	if text == "" {
+		return
+	}
+	sb.WriteString(open + text + close)
+	if strings.TrimRight(inner, " \t\n") != inner {
+		sb.WriteString(" ")
+	}
+}
+
+/**
+ * tableRow renders the td and th cells of the table row tr with render and joins
+ * them with sep, so cells don't run into each other in formats without tables.
+ * Whitespace inside a cell is collapsed to keep the row on one line.
+ */
+func tableRow(tr *html.Node, sep string, render func(*strings.Builder, *html.Node)) string {
+	var cells []string
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || (c.Data != "td" && c.Data != "th") {
+			continue
+		}
+		var cell strings.Builder
+		render(&cell, c)
+		cells = append(cells, strings.Join(strings.Fields(cell.String()), " "))
+	}
+	return strings.Join(cells, sep)
+}
+
+/**
+ * quoteLines normalizes the whitespace of text and prefixes each of its lines with "> ".
+ */
+func quoteLines(text string) string {
+	lines := strings.Split(strings.TrimSpace(NormalizeWhitespace(text)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,46 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatDiscordMD(t *testing.T) {
+	buf := bytes.NewBufferString(`<h1>Title</h1><h3>Deep <em>heading</em></h3>
+<p>Some <strong>bold</strong>, <em>italic</em>, <u>underlined</u> and <s>struck</s> text with 2*3_4 and a <a href="https://example.com/a_b">link</a>.</p>
+<p>See <a href="https://example.com/x_y">https://example.com/x_y</a> and <code>a := b</code>.</p>
+<pre><code class="language-go">fmt.Println("hi")
+</code></pre>
+<pre>plain</pre>
+<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>
+<blockquote>Quoted words</blockquote>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>`)
+	rec := httptest.NewRecorder()
+	formatDiscordMD(rec, readability.Article{}, buf, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/markdown; charset=utf-8" {
+		t.Errorf("Content-Type = %q; want text/markdown; charset=utf-8", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"**Title**\n\n**Deep heading**\n",
+		"Some **bold**, *italic*, __underlined__ and ~~struck~~ text with 2\\*3\\_4 and a link (https://example.com/a_b).",
+		"See https://example.com/x_y and `a := b`.",
+		"```go\nfmt.Println(\"hi\")\n```",
+		"```\nplain\n```",
+		"- one\n- two\n  - nested",
+		"> Quoted words",
+		"A | B\n1 | 2",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatDiscordMD output missing %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "#") {
+		t.Errorf("headings were not converted to bold text:\n%s", body)
+	}
+}
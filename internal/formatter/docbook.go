@@ -0,0 +1,264 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatDocBook returns the article as a DocBook 5.1 <article>, for technical
+ * documentation toolchains.
+ *
+ * Headings open nested <section>s, paragraphs become <para>, code blocks
+ * <programlisting>, lists <itemizedlist>/<orderedlist>, images <mediaobject> and
+ * links <link xlink:href>. Bold and italic text become <emphasis> with a role.
+ */
+func formatDocBook(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/docbook+xml")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for docbook: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
+		return
+	}
+
+	var d docBookWriter
+	d.sb.WriteString(xml.Header)
+	d.sb.WriteString(`<article xmlns="http://docbook.org/ns/docbook" xmlns:xlink="http://www.w3.org/1999/xlink" version="5.1">` + "\n")
+	d.sb.WriteString("<info>\n<title>")
+	xml.EscapeText(&d.sb, []byte(strings.Join(strings.Fields(article.Title()), " ")))
+	d.sb.WriteString("</title>\n")
+	if byline := strings.TrimSpace(article.Byline()); byline != "" {
+		d.sb.WriteString("<author><personname>")
+		xml.EscapeText(&d.sb, []byte(byline))
+		d.sb.WriteString("</personname></author>\n")
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		d.sb.WriteString("<pubdate>" + published.Format(time.DateOnly) + "</pubdate>\n")
+	}
+	d.sb.WriteString("</info>\n")
+	d.block(doc)
+	d.closePara()
+	for range d.sections {
+		d.sb.WriteString("</section>\n")
+	}
+	d.sb.WriteString("</article>\n")
+	if _, err := io.WriteString(w, d.sb.String()); err != nil {
+		log.Printf("error writing docbook response: %v", err)
+	}
+}
+
+// xmlTextEscaper escapes text for XML, keeping its line breaks and tabs as is.
+var xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+/**
+ * docBookWriter accumulates the DocBook markup of formatDocBook.
+ *
+ * DocBook only allows text inside block elements, so inline content found between
+ * blocks (loose text in a <div>, the text of a <li>) is collected and wrapped in a
+ * <para> when the next block starts.
+ */
+type docBookWriter struct {
+	sb strings.Builder
+	// sections holds the heading levels of the open <section>s, innermost last.
+	sections []int
+	// loose holds the inline nodes met since the last block.
+	loose []*html.Node
+}
+
+// closePara writes the loose inline content collected so far as a <para>.
+func (d *docBookWriter) closePara() {
+	d.para(d.loose...)
+	d.loose = nil
+}
+
+// para writes nodes as the inline content of a <para>, skipping it when blank.
+func (d *docBookWriter) para(nodes ...*html.Node) {
+	var content docBookWriter
+	for _, n := range nodes {
+		content.inline(n)
+	}
+	if text := strings.TrimSpace(content.sb.String()); text != "" {
+		d.sb.WriteString("<para>" + text + "</para>\n")
+	}
+}
+
+/**
+ * block writes the children of n as DocBook block elements.
+ */
+func (d *docBookWriter) block(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode || c.Type == html.ElementNode && (slices.Contains(meta.InlineElements, c.Data) || c.Data == "del" || c.Data == "br"):
+			d.loose = append(d.loose, c)
+			continue
+		case c.Type != html.ElementNode:
+			d.block(c)
+			continue
+		}
+
+		switch c.Data {
+		case "script", "style", "noscript", "template", "hr":
+			continue
+		}
+		d.closePara()
+		switch c.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level := int(c.Data[1] - '0')
+			for len(d.sections) > 0 && d.sections[len(d.sections)-1] >= level {
+				d.sb.WriteString("</section>\n")
+				d.sections = d.sections[:len(d.sections)-1]
+			}
+			d.sections = append(d.sections, level)
+			d.sb.WriteString("<section>\n<title>")
+			d.inlineChildren(c)
+			d.sb.WriteString("</title>\n")
+		case "p":
+			var children []*html.Node
+			for child := c.FirstChild; child != nil; child = child.NextSibling {
+				children = append(children, child)
+			}
+			d.para(children...)
+		case "pre":
+			if lang := codeLanguage(c); lang != "" {
+				d.sb.WriteString(`<programlisting language="`)
+				xml.EscapeText(&d.sb, []byte(lang))
+				d.sb.WriteString(`">`)
+			} else {
+				d.sb.WriteString("<programlisting>")
+			}
+			// unlike xml.EscapeText, keeps the line breaks and tabs readable
+			xmlTextEscaper.WriteString(&d.sb, strings.Trim(dom.TextContent(c), "\n"))
+			d.sb.WriteString("</programlisting>\n")
+		case "ul", "ol":
+			tag := "itemizedlist"
+			if c.Data == "ol" {
+				tag = "orderedlist"
+			}
+			d.sb.WriteString("<" + tag + ">\n")
+			for li := c.FirstChild; li != nil; li = li.NextSibling {
+				if li.Type != html.ElementNode || li.Data != "li" {
+					continue
+				}
+				d.sb.WriteString("<listitem>\n")
+				d.block(li)
+				d.closePara()
+				d.sb.WriteString("</listitem>\n")
+			}
+			d.sb.WriteString("</" + tag + ">\n")
+		case "blockquote":
+			d.sb.WriteString("<blockquote>\n")
+			d.block(c)
+			d.closePara()
+			d.sb.WriteString("</blockquote>\n")
+		case "img":
+			d.media(c, "mediaobject")
+			d.sb.WriteString("\n")
+		default:
+			d.block(c)
+			d.closePara()
+		}
+	}
+}
+
+// inlineChildren writes the children of n as DocBook inline content.
+func (d *docBookWriter) inlineChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		d.inline(c)
+	}
+}
+
+/**
+ * inline writes n as DocBook inline content. Block elements met here (a <p> in a
+ * <a>) are flattened into their text.
+ */
+func (d *docBookWriter) inline(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if strings.TrimLeft(n.Data, " \t\n\r") != n.Data {
+			text = " " + text
+		}
+		if text != " " && strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+			text += " "
+		}
+		xml.EscapeText(&d.sb, []byte(text))
+		return
+	case html.ElementNode:
+	default:
+		d.inlineChildren(n)
+		return
+	}
+
+	wrap := func(open, close string) {
+		d.sb.WriteString(open)
+		d.inlineChildren(n)
+		d.sb.WriteString(close)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "b", "strong":
+		wrap(`<emphasis role="bold">`, "</emphasis>")
+	case "i", "em":
+		wrap(`<emphasis role="italic">`, "</emphasis>")
+	case "s", "del":
+		wrap(`<emphasis role="strikethrough">`, "</emphasis>")
+	case "sub":
+		wrap("<subscript>", "</subscript>")
+	case "sup":
+		wrap("<superscript>", "</superscript>")
+	case "code", "kbd", "samp":
+		d.sb.WriteString("<code>")
+		xml.EscapeText(&d.sb, []byte(dom.TextContent(n)))
+		d.sb.WriteString("</code>")
+	case "a":
+		href := strings.TrimSpace(dom.Attr(n, "href"))
+		if href == "" {
+			d.inlineChildren(n)
+			return
+		}
+		d.sb.WriteString(`<link xlink:href="`)
+		xml.EscapeText(&d.sb, []byte(href))
+		d.sb.WriteString(`">`)
+		d.inlineChildren(n)
+		d.sb.WriteString("</link>")
+	case "img":
+		d.media(n, "inlinemediaobject")
+	case "br":
+		d.sb.WriteString(" ")
+	default:
+		d.inlineChildren(n)
+	}
+}
+
+/**
+ * media writes an image as a DocBook media object (tag is mediaobject or
+ * inlinemediaobject), with its alt text as the text alternative.
+ */
+func (d *docBookWriter) media(img *html.Node, tag string) {
+	src := strings.TrimSpace(dom.Attr(img, "src"))
+	if src == "" {
+		return
+	}
+	d.sb.WriteString("<" + tag + `><imageobject><imagedata fileref="`)
+	xml.EscapeText(&d.sb, []byte(src))
+	d.sb.WriteString(`"/></imageobject>`)
+	if alt := strings.TrimSpace(dom.Attr(img, "alt")); alt != "" {
+		d.sb.WriteString("<textobject><phrase>")
+		xml.EscapeText(&d.sb, []byte(alt))
+		d.sb.WriteString("</phrase></textobject>")
+	}
+	d.sb.WriteString("</" + tag + ">")
+}
@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestFormatDocBookGolden(t *testing.T) {
+	input, err := os.ReadFile("testdata/docbook.html")
+	if err != nil {
+		t.Fatalf("failed to read input: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	formatDocBook(rec, readability.Article{}, bytes.NewBuffer(input), Options{})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/docbook+xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	got := rec.Body.Bytes()
+	if *updateGolden {
+		if err := os.WriteFile("testdata/docbook.golden", got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile("testdata/docbook.golden")
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("formatDocBook() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatDocBookIsValidXML(t *testing.T) {
+	input := `<p>A &amp; B <a href="/x?a=1&amp;b=2">link</a></p><h1>One</h1><h2>Two</h2><h1>Three</h1><li>stray</li>text`
+	rec := httptest.NewRecorder()
+	formatDocBook(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+
+	var root struct {
+		XMLName  xml.Name
+		Version  string `xml:"version,attr"`
+		Sections []struct {
+			Title    string     `xml:"title"`
+			Sections []struct{} `xml:"section"`
+		} `xml:"section"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &root); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, rec.Body.String())
+	}
+	if root.XMLName.Space != "http://docbook.org/ns/docbook" || root.XMLName.Local != "article" || root.Version != "5.1" {
+		t.Errorf("root = %+v, version %q", root.XMLName, root.Version)
+	}
+	if len(root.Sections) != 2 || root.Sections[0].Title != "One" || len(root.Sections[0].Sections) != 1 || root.Sections[1].Title != "Three" {
+		t.Errorf("sections = %+v; want One (with Two) and Three", root.Sections)
+	}
+
+	// every element must be properly nested and closed
+	dec := xml.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid XML: %v", err)
+		}
+	}
+	if !strings.Contains(rec.Body.String(), `xlink:href="/x?a=1&amp;b=2"`) {
+		t.Errorf("link not escaped: %s", rec.Body.String())
+	}
+}
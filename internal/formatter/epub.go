@@ -0,0 +1,321 @@
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/**
+ * epubContainer points EPUB readers to the package document.
+ */
+const epubContainer = `<?xml version="1.0" encoding="utf-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+	<rootfiles>
+		<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+	</rootfiles>
+</container>
+`
+
+/**
+ * epubUnsupportedElements are dropped from EPUB content: scripts and embeds are
+ * not allowed by Kindle's conversion, and forms do nothing in a book.
+ */
+var epubUnsupportedElements = []string{
+	"script", "style", "noscript", "template", "iframe", "object", "embed",
+	"form", "input", "button", "select", "textarea", "audio", "video", "canvas",
+}
+
+/**
+ * BuildEPUB packages the article as an EPUB 3 book with a single XHTML5 chapter.
+ *
+ * The book is kept within what Kindle (Send to Kindle, Kindle Previewer) accepts:
+ *   - no scripts, embeds or forms, and no stylesheets or style attributes, as Kindle
+ *     ignores or rejects most CSS and the reading system styles the book itself;
+ *   - remote images are dropped, since books can't load resources from the network
+ *     (images embedded with `?include-images-as-base64=true` are kept);
+ *   - the content is well-formed XHTML with a navigation document, as Kindle
+ *     refuses books whose chapters don't parse as XML.
+ */
+func BuildEPUB(article readability.Article, buf *bytes.Buffer, opts Options) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content: %w", err)
+	}
+	body := dom.FindElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+	cleanEPUBContent(body)
+	var toc []meta.TOCEntry
+	if opts.EPUBCompat {
+		toc = append([]meta.TOCEntry{{Level: 1, Text: cmp.Or(article.Title(), "Article")}}, anchorHeadings(body)...)
+	}
+	var content strings.Builder
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&content, c); err != nil {
+			return nil, fmt.Errorf("rendering content: %w", err)
+		}
+	}
+
+	title := xmlEscape(cmp.Or(article.Title(), "Article"))
+	identifier := "urn:article:untitled"
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		identifier = link.String()
+	}
+	var creator string
+	if byline := article.Byline(); byline != "" {
+		creator = "\n\t\t<dc:creator>" + xmlEscape(byline) + "</dc:creator>"
+	}
+	var ncxItem, spineTOC string
+	if opts.EPUBCompat {
+		ncxItem = "\n\t\t<item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>"
+		spineTOC = ` toc="ncx"`
+	}
+
+	files := []struct{ name, content string }{
+		{"META-INF/container.xml", epubContainer},
+		{"OEBPS/content.opf", `<?xml version="1.0" encoding="utf-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id">
+	<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+		<dc:identifier id="id">` + xmlEscape(identifier) + `</dc:identifier>
+		<dc:title>` + title + `</dc:title>
+		<dc:language>und</dc:language>` + creator + `
+		<meta property="dcterms:modified">` + time.Now().UTC().Format("2006-01-02T15:04:05Z") + `</meta>
+	</metadata>
+	<manifest>
+		<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+		<item id="article" href="article.xhtml" media-type="application/xhtml+xml"/>` + ncxItem + `
+	</manifest>
+	<spine` + spineTOC + `>
+		<itemref idref="article"/>
+	</spine>
+</package>
+`},
+		{"OEBPS/nav.xhtml", `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+	<meta charset="utf-8"/>
+	<title>` + title + `</title>
+</head>
+<body>
+	<nav epub:type="toc">
+		<ol>
+			<li><a href="article.xhtml">` + title + `</a></li>
+		</ol>
+	</nav>
+</body>
+</html>
+`},
+		{"OEBPS/article.xhtml", `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+	<meta charset="utf-8"/>
+	<title>` + title + `</title>
+</head>
+<body>
+	<h1>` + title + `</h1>
+	` + content.String() + `
+</body>
+</html>
+`},
+	}
+	if opts.EPUBCompat {
+		files = append(files, struct{ name, content string }{"OEBPS/toc.ncx", GenerateNCX(toc, identifier)})
+	}
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	// the mimetype must come first and uncompressed, so readers can sniff it
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+/**
+ * anchorHeadings returns the TOC of doc (see meta.ExtractTOC), marking the heading of
+ * its nth entry with the id "toc-n" for GenerateNCX to link to. Headings that
+ * already have an id keep it, the anchor goes in an empty <span> inside them.
+ */
+func anchorHeadings(doc *html.Node) []meta.TOCEntry {
+	var toc []meta.TOCEntry
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && len(n.Data) == 2 && n.Data[0] == 'h' && n.Data[1] >= '1' && n.Data[1] <= '6' {
+			if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+				toc = append(toc, meta.TOCEntry{Level: int(n.Data[1] - '0'), Text: text})
+				anchor := html.Attribute{Key: "id", Val: fmt.Sprintf("toc-%d", len(toc))}
+				if dom.HasAttr(n, "id") {
+					n.InsertBefore(&html.Node{Type: html.ElementNode, Data: "span", DataAtom: atom.Span, Attr: []html.Attribute{anchor}}, n.FirstChild)
+				} else {
+					n.Attr = append(n.Attr, anchor)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return toc
+}
+
+/**
+ * GenerateNCX returns the EPUB 2 navigation file (toc.ncx) of a book identified
+ * by uid whose single chapter, article.xhtml, has the headings in toc. EPUB 3
+ * readers use nav.xhtml instead, but older ones (some Kindles) need this file.
+ *
+ * The first entry is the book title, linking to the start of the chapter; the
+ * nth one after it links to the "toc-n" anchor set by anchorHeadings. Entries
+ * nest under the closest previous entry of a lower level.
+ */
+func GenerateNCX(toc []meta.TOCEntry, uid string) string {
+	var title string
+	if len(toc) > 0 {
+		title = toc[0].Text
+	}
+	var navMap strings.Builder
+	var open []int
+	depth := 0
+	for i, entry := range toc {
+		for len(open) > 0 && open[len(open)-1] >= entry.Level {
+			open = open[:len(open)-1]
+			navMap.WriteString(strings.Repeat("\t", len(open)+2) + "</navPoint>\n")
+		}
+		indent := strings.Repeat("\t", len(open)+2)
+		src := "article.xhtml"
+		if i > 0 {
+			src += fmt.Sprintf("#toc-%d", i)
+		}
+		fmt.Fprintf(&navMap, "%s<navPoint id=\"navpoint-%d\" playOrder=\"%d\">\n", indent, i+1, i+1)
+		navMap.WriteString(indent + "\t<navLabel><text>" + xmlEscape(entry.Text) + "</text></navLabel>\n")
+		navMap.WriteString(indent + "\t<content src=\"" + xmlEscape(src) + "\"/>\n")
+		open = append(open, entry.Level)
+		depth = max(depth, len(open))
+	}
+	for len(open) > 0 {
+		open = open[:len(open)-1]
+		navMap.WriteString(strings.Repeat("\t", len(open)+2) + "</navPoint>\n")
+	}
+
+	return `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE ncx PUBLIC "-//NISO//DTD ncx 2005-1//EN" "http://www.daisy.org/z3986/2005/ncx-2005-1.dtd">
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+	<head>
+		<meta name="dtb:uid" content="` + xmlEscape(uid) + `"/>
+		<meta name="dtb:depth" content="` + strconv.Itoa(max(depth, 1)) + `"/>
+		<meta name="dtb:totalPageCount" content="0"/>
+		<meta name="dtb:maxPageNumber" content="0"/>
+	</head>
+	<docTitle><text>` + xmlEscape(title) + `</text></docTitle>
+	<navMap>
+` + navMap.String() + `	</navMap>
+</ncx>
+`
+}
+
+/**
+ * cleanEPUBContent removes what EPUB readers (Kindle in particular) don't support
+ * from the tree, in place: see BuildEPUB.
+ */
+func cleanEPUBContent(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == html.CommentNode:
+			n.RemoveChild(c)
+		case c.Type != html.ElementNode:
+		case slices.Contains(epubUnsupportedElements, c.Data):
+			n.RemoveChild(c)
+		case c.Data == "img" && !strings.HasPrefix(dom.Attr(c, "src"), "data:"):
+			n.RemoveChild(c)
+		default:
+			c.Attr = slices.DeleteFunc(c.Attr, func(a html.Attribute) bool {
+				return a.Namespace != "" || a.Key == "style" || a.Key == "srcset" || strings.HasPrefix(a.Key, "on") || strings.Contains(a.Key, ":")
+			})
+			cleanEPUBContent(c)
+		}
+		c = next
+	}
+}
+
+/**
+ * xmlEscape escapes text for XML character data and attribute values.
+ */
+func xmlEscape(text string) string {
+	var sb strings.Builder
+	if err := xml.EscapeText(&sb, []byte(text)); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+/**
+ * writeEPUB writes the article as an EPUB download for format.
+ */
+func writeEPUB(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options, format string) {
+	book, err := BuildEPUB(article, buf, opts)
+	if err != nil {
+		log.Printf("error building epub: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to build EPUB")
+		return
+	}
+	w.Header().Set("Content-Type", "application/epub+zip")
+	SetContentDisposition(w, format, article.Title())
+	if _, err := w.Write(book); err != nil {
+		log.Printf("error writing epub: %v", err)
+	}
+}
+
+/**
+ * formatEPUB returns the article as an EPUB 3 book (see BuildEPUB), for e-readers.
+ */
+func formatEPUB(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	writeEPUB(w, article, buf, opts, "epub")
+}
+
+/**
+ * formatKindle returns the same EPUB as formatEPUB, which Send to Kindle converts
+ * for Kindle devices (generating AZW3/KFX ourselves isn't worth it).
+ * X-Kindle-Compatible tells clients the book follows Kindle's constraints.
+ */
+func formatKindle(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("X-Kindle-Compatible", "true")
+	writeEPUB(w, article, buf, opts, "kindle")
+}
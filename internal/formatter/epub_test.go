@@ -0,0 +1,197 @@
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func readEPUB(t *testing.T, book []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	if len(zr.File) == 0 || zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Fatalf("mimetype is not the first, uncompressed entry")
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func TestFormatEPUB(t *testing.T) {
+	buf := bytes.NewBufferString(`<p style="color:red" onclick="x()">Text<br>with &amp; break &nbsp;and <img src="https://example.com/a.png"> <img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" alt="dot"></p><script>alert(1)</script><iframe src="https://example.com"></iframe>`)
+	rec := httptest.NewRecorder()
+	formatEPUB(rec, readability.Article{}, buf, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/epub+zip" {
+		t.Errorf("Content-Type = %q; want application/epub+zip", ct)
+	}
+	files := readEPUB(t, rec.Body.Bytes())
+	if files["mimetype"] != "application/epub+zip" {
+		t.Errorf("mimetype = %q", files["mimetype"])
+	}
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/article.xhtml"} {
+		dec := xml.NewDecoder(strings.NewReader(files[name]))
+		for {
+			_, err := dec.Token()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Errorf("%s is not well-formed XML: %v\n%s", name, err, files[name])
+				break
+			}
+		}
+	}
+	chapter := files["OEBPS/article.xhtml"]
+	for _, unwanted := range []string{"<script", "<iframe", "style=", "onclick", "https://example.com/a.png"} {
+		if strings.Contains(chapter, unwanted) {
+			t.Errorf("chapter contains %q:\n%s", unwanted, chapter)
+		}
+	}
+	if !strings.Contains(chapter, `<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" alt="dot"/>`) {
+		t.Errorf("embedded image missing from chapter:\n%s", chapter)
+	}
+}
+
+func TestFormatEPUBCompat(t *testing.T) {
+	input := `<h2>Intro</h2><p>Text</p><h3 id="details">Details</h3><h3>More</h3><h2>End</h2>`
+	for _, compat := range []bool{false, true} {
+		rec := httptest.NewRecorder()
+		formatEPUB(rec, readability.Article{}, bytes.NewBufferString(input), Options{EPUBCompat: compat})
+		files := readEPUB(t, rec.Body.Bytes())
+		ncx, found := files["OEBPS/toc.ncx"]
+		if found != compat {
+			t.Fatalf("epub-compat=%v: toc.ncx included = %v", compat, found)
+		}
+		if opf := files["OEBPS/content.opf"]; strings.Contains(opf, `toc="ncx"`) != compat || strings.Contains(opf, `href="toc.ncx"`) != compat {
+			t.Errorf("epub-compat=%v: content.opf =\n%s", compat, opf)
+		}
+		if !compat {
+			continue
+		}
+		chapter := files["OEBPS/article.xhtml"]
+		for _, anchor := range []string{`<h2 id="toc-1">Intro</h2>`, `<h3 id="details"><span id="toc-2"></span>Details</h3>`, `<h3 id="toc-3">More</h3>`} {
+			if !strings.Contains(chapter, anchor) {
+				t.Errorf("chapter lacks %q:\n%s", anchor, chapter)
+			}
+		}
+
+		var doc struct {
+			XMLName xml.Name
+			Meta    []struct {
+				Name    string `xml:"name,attr"`
+				Content string `xml:"content,attr"`
+			} `xml:"head>meta"`
+			Title  string        `xml:"docTitle>text"`
+			Points []ncxNavPoint `xml:"navMap>navPoint"`
+		}
+		if err := xml.Unmarshal([]byte(ncx), &doc); err != nil {
+			t.Fatalf("invalid toc.ncx: %v\n%s", err, ncx)
+		}
+		if !strings.Contains(ncx, `<!DOCTYPE ncx PUBLIC "-//NISO//DTD ncx 2005-1//EN" "http://www.daisy.org/z3986/2005/ncx-2005-1.dtd">`) {
+			t.Errorf("toc.ncx lacks the doctype:\n%s", ncx)
+		}
+		if doc.XMLName.Space != "http://www.daisy.org/z3986/2005/ncx/" || doc.Title != "Article" {
+			t.Errorf("root = %v, title = %q", doc.XMLName, doc.Title)
+		}
+		if len(doc.Meta) < 2 || doc.Meta[0].Name != "dtb:uid" || doc.Meta[0].Content != "urn:article:untitled" || doc.Meta[1].Content != "3" {
+			t.Errorf("head = %+v", doc.Meta)
+		}
+		want := "1:Article(article.xhtml)[2:Intro(article.xhtml#toc-1)[3:Details(article.xhtml#toc-2) 4:More(article.xhtml#toc-3)] 5:End(article.xhtml#toc-4)]"
+		if got := formatNavPoints(doc.Points); got != want {
+			t.Errorf("navMap = %s; want %s", got, want)
+		}
+	}
+}
+
+type ncxNavPoint struct {
+	PlayOrder string `xml:"playOrder,attr"`
+	Label     string `xml:"navLabel>text"`
+	Content   struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []ncxNavPoint `xml:"navPoint"`
+}
+
+// formatNavPoints summarizes points as "playOrder:label(src)[children]".
+func formatNavPoints(points []ncxNavPoint) string {
+	var parts []string
+	for _, p := range points {
+		part := p.PlayOrder + ":" + p.Label + "(" + p.Content.Src + ")"
+		if len(p.Children) > 0 {
+			part += "[" + formatNavPoints(p.Children) + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestFormatKindle(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatKindle(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), Options{})
+
+	if got := rec.Header().Get("X-Kindle-Compatible"); got != "true" {
+		t.Errorf("X-Kindle-Compatible = %q; want true", got)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.epub"` {
+		t.Errorf("Content-Disposition = %q; want the article.epub fallback", cd)
+	}
+	readEPUB(t, rec.Body.Bytes())
+}
+
+func TestArticleFilename(t *testing.T) {
+	tests := map[string]string{
+		"My Article":             "My_Article.epub",
+		"A/B: \"quoted\"\tname":  "A_B___quoted__name.epub",
+		"  ":                     "article.epub",
+		"Ação":                   "A__o.epub",
+		"../../../etc/passwd":    "_.._.._etc_passwd.epub",
+		strings.Repeat("x", 150): strings.Repeat("x", 100) + ".epub",
+	}
+	for title, want := range tests {
+		if got := articleFilename(title, ".epub"); got != want {
+			t.Errorf("articleFilename(%q) = %q; want %q", title, got, want)
+		}
+	}
+}
+
+func TestSetContentDisposition(t *testing.T) {
+	tests := []struct {
+		format, want string
+	}{
+		{"epub", `attachment; filename="My_Article.epub"`},
+		{"kindle", `attachment; filename="My_Article.epub"`},
+		{"pdf", `attachment; filename="My_Article.pdf"`},
+		{"reader", `attachment; filename="My_Article.html"`},
+		{"html", ""},
+		{"json", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		SetContentDisposition(rec, tt.format, "My Article")
+		if got := rec.Header().Get("Content-Disposition"); got != tt.want {
+			t.Errorf("SetContentDisposition(%q) = %q; want %q", tt.format, got, tt.want)
+		}
+	}
+}
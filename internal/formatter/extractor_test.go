@@ -0,0 +1,24 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatJSONExtractor(t *testing.T) {
+	for _, extractor := range []string{"", "largest-block"} {
+		rec := httptest.NewRecorder()
+		formatJSON(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), Options{Extractor: extractor})
+		var resp map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if got, _ := resp["extractor"].(string); got != extractor {
+			t.Errorf("extractor = %q; want %q", got, extractor)
+		}
+	}
+}
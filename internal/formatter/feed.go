@@ -0,0 +1,217 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+/**
+ * rssItem is a single RSS 2.0 <item> element.
+ */
+type rssItem struct {
+	XMLName     xml.Name `xml:"item"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description struct {
+		Text string `xml:",cdata"`
+	} `xml:"description"`
+	PubDate string `xml:"pubDate,omitempty"`
+}
+
+/**
+ * formatRSSItem returns the article as a bare RSS <item> fragment, without the
+ * surrounding <rss> and <channel> elements.
+ * Useful for feed pipelines that assemble their own feeds from individual items.
+ */
+func formatRSSItem(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/xml")
+	item := rssItem{Title: article.Title()}
+	if opts.Link != nil {
+		item.Link = opts.Link.String()
+	}
+	item.Description.Text = buf.String()
+	if published, err := article.PublishedTime(); err == nil {
+		item.PubDate = published.Format(time.RFC1123Z)
+	}
+	if err := xml.NewEncoder(w).Encode(item); err != nil {
+		log.Printf("error encoding rss item: %v", err)
+	}
+}
+
+/**
+ * atomEntry is a single Atom <entry> element.
+ */
+type atomEntry struct {
+	XMLName xml.Name  `xml:"http://www.w3.org/2005/Atom entry"`
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Updated string    `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Content struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	} `xml:"content"`
+}
+
+// atomLink is an Atom <link> element pointing at the original article.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+/**
+ * formatAtomEntry returns the article as a bare Atom <entry> fragment, without the <feed> wrapper.
+ *
+ * Atom requires id, updated and author, so they fall back to the article URL,
+ * the current time and the site name/host respectively when the page lacks them.
+ */
+func formatAtomEntry(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/xml")
+	entry := atomEntry{Title: article.Title()}
+	host := ""
+	if opts.Link != nil {
+		entry.ID = opts.Link.String()
+		entry.Link = &atomLink{Href: opts.Link.String()}
+		host = opts.Link.Hostname()
+	}
+	updated, err := article.ModifiedTime()
+	if err != nil {
+		updated, err = article.PublishedTime()
+	}
+	if err != nil {
+		updated = time.Now()
+	}
+	entry.Updated = updated.UTC().Format(time.RFC3339)
+	entry.Author.Name = cmp.Or(article.Byline(), article.SiteName(), host)
+	entry.Content.Type = "html"
+	entry.Content.Body = buf.String()
+	if err := xml.NewEncoder(w).Encode(entry); err != nil {
+		log.Printf("error encoding atom entry: %v", err)
+	}
+}
+
+/**
+ * jsonFeed is a JSON Feed 1.1 document (https://jsonfeed.org/version/1.1).
+ */
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// jsonFeedItem is a single item of a jsonFeed.
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	// Author is the JSON Feed 1.0 field, kept for readers that predate "authors".
+	Author *jsonFeedAuthor `json:"author,omitempty"`
+}
+
+// jsonFeedAuthor is the author object of a jsonFeedItem.
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+/**
+ * formatJSONFeed returns the article as a JSON Feed 1.1 document with a single item.
+ *
+ * Like formatAtomEntry, fields the feed can't do without fall back to what is known:
+ * the item id to the article URL, the feed title to the site name or host, and the
+ * author to the site name or host.
+ */
+func formatJSONFeed(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/feed+json")
+	host := ""
+	item := jsonFeedItem{
+		Title:       article.Title(),
+		ContentHTML: buf.String(),
+		Summary:     articleExcerpt(article, buf),
+	}
+	feed := jsonFeed{Version: "https://jsonfeed.org/version/1.1"}
+	if opts.Link != nil {
+		item.ID = opts.Link.String()
+		item.URL = opts.Link.String()
+		host = opts.Link.Hostname()
+		feed.HomePageURL = (&url.URL{Scheme: opts.Link.Scheme, Host: opts.Link.Host, Path: "/"}).String()
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		item.DatePublished = published.UTC().Format(time.RFC3339)
+	}
+	if modified, err := article.ModifiedTime(); err == nil {
+		item.DateModified = modified.UTC().Format(time.RFC3339)
+	}
+	if name := cmp.Or(article.Byline(), article.SiteName(), host); name != "" {
+		item.Authors = []jsonFeedAuthor{{Name: name}}
+		item.Author = &item.Authors[0]
+	}
+	feed.Title = cmp.Or(article.SiteName(), host, article.Title())
+	feed.Items = []jsonFeedItem{item}
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		log.Printf("error encoding json feed: %v", err)
+	}
+}
+
+/**
+ * opmlDocument is an OPML 2.0 document holding a single link outline.
+ */
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated"`
+	} `xml:"head"`
+	Outline opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutline is an OPML <outline> of type "link".
+type opmlOutline struct {
+	Type        string `xml:"type,attr"`
+	Text        string `xml:"text,attr"`
+	URL         string `xml:"url,attr"`
+	Description string `xml:"description,attr,omitempty"`
+}
+
+/**
+ * formatOPML wraps the article link as an OPML 2.0 document with a single outline.
+ * Useful for exchanging links with podcast and RSS aggregation tools.
+ */
+func formatOPML(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/x-opml")
+	doc := opmlDocument{Version: "2.0"}
+	doc.Head.Title = article.Title()
+	doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123Z)
+	doc.Outline = opmlOutline{
+		Type:        "link",
+		Text:        article.Title(),
+		Description: article.Excerpt(),
+	}
+	if opts.Link != nil {
+		doc.Outline.URL = opts.Link.String()
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		log.Printf("error writing opml: %v", err)
+		return
+	}
+	if err := xml.NewEncoder(w).Encode(doc); err != nil {
+		log.Printf("error encoding opml: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatRSSItem(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post?a=1&b=2")
+	article := testutil.ArticleFromFixture(t, "testdata/rss_item.html")
+	buf := testutil.MustRenderHTML(t, article)
+
+	rec := httptest.NewRecorder()
+	formatRSSItem(rec, article, buf, Options{Link: link})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Content-Type = %q; want application/xml", ct)
+	}
+	fragment := rec.Body.String()
+	if strings.Contains(fragment, "<rss") || strings.Contains(fragment, "<channel") {
+		t.Errorf("fragment must not include the feed wrapper: %q", fragment)
+	}
+
+	var feed struct {
+		Channel struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	envelope := `<rss version="2.0"><channel><title>t</title>` + fragment + `</channel></rss>`
+	if err := xml.Unmarshal([]byte(envelope), &feed); err != nil {
+		t.Fatalf("fragment is not valid inside an RSS envelope: %v\n%s", err, envelope)
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("got %d items; want 1", len(feed.Channel.Items))
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "Feed Title & More" {
+		t.Errorf("title = %q", item.Title)
+	}
+	if item.Link != link.String() {
+		t.Errorf("link = %q; want %q", item.Link, link.String())
+	}
+	if !strings.Contains(item.Description, "Body with ]]&gt; inside") {
+		t.Errorf("description lost content: %q", item.Description)
+	}
+	if item.PubDate != "Wed, 01 May 2024 10:00:00 +0000" {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+}
+
+func TestFormatAtomEntry(t *testing.T) {
+	link, _ := url.Parse("https://example.com/atom")
+	article := testutil.ArticleFromFixture(t, "testdata/atom_entry.html")
+	buf := testutil.MustRenderHTML(t, article)
+
+	rec := httptest.NewRecorder()
+	formatAtomEntry(rec, article, buf, Options{Link: link})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Content-Type = %q; want application/xml", ct)
+	}
+	if strings.Contains(rec.Body.String(), "<feed") {
+		t.Errorf("fragment must not include the feed wrapper: %q", rec.Body.String())
+	}
+
+	var entry struct {
+		XMLName xml.Name
+		Title   string `xml:"title"`
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Author  struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Content struct {
+			Type string `xml:"type,attr"`
+			Body string `xml:",chardata"`
+		} `xml:"content"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &entry); err != nil {
+		t.Fatalf("entry is not valid XML: %v", err)
+	}
+	if entry.XMLName.Local != "entry" || entry.XMLName.Space != "http://www.w3.org/2005/Atom" {
+		t.Errorf("root element = %+v; want Atom entry", entry.XMLName)
+	}
+	for field, value := range map[string]string{
+		"title":        entry.Title,
+		"id":           entry.ID,
+		"updated":      entry.Updated,
+		"author/name":  entry.Author.Name,
+		"content":      entry.Content.Body,
+		"content@type": entry.Content.Type,
+	} {
+		if value == "" {
+			t.Errorf("required Atom field %s is empty", field)
+		}
+	}
+	if entry.Updated != "2024-06-02T08:30:00Z" {
+		t.Errorf("updated = %q; want modified time", entry.Updated)
+	}
+	if entry.Author.Name != "Jane Doe" {
+		t.Errorf("author = %q; want %q", entry.Author.Name, "Jane Doe")
+	}
+	if !strings.Contains(entry.Content.Body, "<p>Atom body") {
+		t.Errorf("content = %q; want article HTML", entry.Content.Body)
+	}
+}
+
+func TestFormatJSONFeed(t *testing.T) {
+	link, _ := url.Parse("https://example.com/posts/json?a=1")
+	article := testutil.ArticleFromFixture(t, "testdata/json_feed.html")
+	buf := testutil.MustRenderHTML(t, article)
+
+	rec := httptest.NewRecorder()
+	formatJSONFeed(rec, article, buf, Options{Link: link})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/feed+json" {
+		t.Errorf("Content-Type = %q; want application/feed+json", ct)
+	}
+	var feed struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url"`
+		Items       []struct {
+			ID            string `json:"id"`
+			URL           string `json:"url"`
+			Title         string `json:"title"`
+			ContentHTML   string `json:"content_html"`
+			DatePublished string `json:"date_published"`
+			Author        struct {
+				Name string `json:"name"`
+			} `json:"author"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("feed is not valid JSON: %v", err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %q", feed.Version)
+	}
+	if len(feed.Items) != 1 {
+		t.Fatalf("got %d items; want 1", len(feed.Items))
+	}
+	item := feed.Items[0]
+	for field, value := range map[string]string{
+		"title":                 feed.Title,
+		"home_page_url":         feed.HomePageURL,
+		"items[0].id":           item.ID,
+		"items[0].url":          item.URL,
+		"items[0].title":        item.Title,
+		"items[0].content_html": item.ContentHTML,
+		"items[0].author.name":  item.Author.Name,
+	} {
+		if value == "" {
+			t.Errorf("JSON Feed field %s is empty", field)
+		}
+	}
+	if feed.HomePageURL != "https://example.com/" {
+		t.Errorf("home_page_url = %q", feed.HomePageURL)
+	}
+	if item.ID != link.String() {
+		t.Errorf("id = %q; want %q", item.ID, link.String())
+	}
+	if item.DatePublished != "2024-05-01T10:00:00Z" {
+		t.Errorf("date_published = %q", item.DatePublished)
+	}
+	if item.Author.Name != "Jane Doe" {
+		t.Errorf("author = %q; want %q", item.Author.Name, "Jane Doe")
+	}
+}
+
+func TestFormatOPML(t *testing.T) {
+	link, _ := url.Parse("https://example.com/opml?a=1&b=2")
+	article := testutil.ArticleFromFixture(t, "testdata/opml.html")
+
+	rec := httptest.NewRecorder()
+	formatOPML(rec, article, &bytes.Buffer{}, Options{Link: link})
+	if ct := rec.Header().Get("Content-Type"); ct != "text/x-opml" {
+		t.Errorf("Content-Type = %q; want text/x-opml", ct)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, `"and"`) || strings.Contains(body, "a=1&b=2") {
+		t.Errorf("outline attributes are not escaped: %q", body)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+		Version string `xml:"version,attr"`
+		Head    struct {
+			Title string `xml:"title"`
+		} `xml:"head"`
+		Outlines []struct {
+			Type        string `xml:"type,attr"`
+			Text        string `xml:"text,attr"`
+			URL         string `xml:"url,attr"`
+			Description string `xml:"description,attr"`
+		} `xml:"body>outline"`
+	}
+	if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("OPML is not valid XML: %v", err)
+	}
+	if doc.XMLName.Local != "opml" || doc.Version != "2.0" {
+		t.Errorf("root = <%s version=%q>; want OPML 2.0", doc.XMLName.Local, doc.Version)
+	}
+	if len(doc.Outlines) != 1 {
+		t.Fatalf("got %d outlines; want 1", len(doc.Outlines))
+	}
+	o := doc.Outlines[0]
+	if o.Type != "link" {
+		t.Errorf("type = %q; want link", o.Type)
+	}
+	if o.Text != `Quotes "and" <tags> & more` {
+		t.Errorf("text = %q", o.Text)
+	}
+	if o.URL != link.String() {
+		t.Errorf("url = %q; want %q", o.URL, link.String())
+	}
+	if o.Description != `An excerpt with "quotes" & ampersands` {
+		t.Errorf("description = %q", o.Description)
+	}
+}
@@ -0,0 +1,311 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/testutil"
+	"golang.org/x/net/html"
+)
+
+func TestFormatTextRendersPlainText(t *testing.T) {
+	// Minimal article node: <p>Plain body</p>
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	p.AppendChild(&html.Node{Type: html.TextNode, Data: "Plain body"})
+	article := readability.Article{Node: p}
+
+	rec := httptest.NewRecorder()
+	// Pass HTML-looking buffer deliberately: formatText must ignore it.
+	htmlBuf := bytes.NewBufferString("<p>should not appear</p>")
+	formatText(rec, article, htmlBuf, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Content-Type = %q; want text/plain", ct)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "<p>") || strings.Contains(body, "should not appear") {
+		t.Fatalf("formatText returned HTML or buffer contents: %q", body)
+	}
+	if !strings.Contains(body, "Plain body") {
+		t.Fatalf("formatText missing plain text, got: %q", body)
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"crlf", "one\r\ntwo\r\n\r\nthree", "one\ntwo\n\nthree"},
+		{"lone cr", "one\rtwo", "one\ntwo"},
+		{"trailing spaces", "one  \t\ntwo \n", "one\ntwo"},
+		{"leading indentation kept", "  indented\n\tcode", "  indented\n\tcode"},
+		{"blank runs", "one\n\n\n\ntwo\n \n\t\nthree", "one\n\ntwo\n\nthree"},
+		{"edges", "\n\n  \none\n\n\n", "one"},
+		{"empty", " \r\n ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeWhitespace(tt.in); got != tt.want {
+				t.Errorf("NormalizeWhitespace(%q) = %q; want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTextNormalizesWhitespace(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<div><p>First  </p>\r\n\r\n\r\n<p>Second</p><div><div><p>Third</p></div></div></div>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	formatText(rec, readability.Article{Node: doc}, &bytes.Buffer{}, Options{})
+	body := rec.Body.String()
+	if strings.Contains(body, "\r") || strings.Contains(body, "\n\n\n") || strings.Contains(body, " \n") {
+		t.Errorf("formatText left unnormalized whitespace: %q", body)
+	}
+	for _, want := range []string{"First", "Second", "Third"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatText missing %q, got: %q", want, body)
+		}
+	}
+}
+
+func TestFormatTextPreservesWhitespace(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<pre>line one\n\n\n\nline two  \n</pre>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	formatText(rec, readability.Article{Node: doc}, &bytes.Buffer{}, Options{Text: TextOptions{PreserveWhitespace: true}})
+	if body := rec.Body.String(); !strings.Contains(body, "line one\n\n\n\nline two  \n") {
+		t.Errorf("formatText changed whitespace with dedupe-whitespace=false: %q", body)
+	}
+}
+
+func TestFormatTextStructure(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+		width             int
+	}{
+		{"paragraphs", "<p>One\n  two</p><p>Three</p>", "One two\n\nThree\n", 0},
+		{"headings", "<h1>Title</h1><h2>Section</h2><h3>Sub</h3><p>Body</p>", "Title\n=====\n\nSection\n-------\n\nSub\n\nBody\n", 0},
+		{"lists", `<ul><li>One</li><li>Two<ol start="3"><li>Three</li><li>Four</li></ol></li></ul>`, "- One\n- Two\n  3. Three\n  4. Four\n", 0},
+		{"link footnotes", `<p>See <a href="https://a.example/">A</a>, <a href="/rel">B</a> and <a href="https://a.example/">A again</a>.</p>`, "See A [1], B and A again [1].\n\nLinks:\n[1] https://a.example/\n", 0},
+		{"quote", "<blockquote><p>First</p><p>Second</p></blockquote>", "> First\n>\n> Second\n", 0},
+		{"line breaks and images", `<p>Line one<br>line <img src="x.png" alt="a cat"> two</p>`, "Line one\nline [a cat] two\n", 0},
+		{"code", "<pre>if x {\n\treturn\n}</pre>", "if x {\n\treturn\n}\n", 0},
+		{"table", "<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>", "A | B\n1 | 2\n", 0},
+		{"wrapped", "<p>The quick brown fox jumps over the lazy dog</p><ul><li>a list item wrapping under its marker</li></ul>", "The quick brown fox\njumps over the lazy\ndog\n\n- a list item\n  wrapping under its\n  marker\n", 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			formatText(rec, readability.Article{Node: doc}, &bytes.Buffer{}, Options{Text: TextOptions{Width: tt.width}})
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("formatText() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTypography(t *testing.T) {
+	tests := []struct {
+		query      string
+		fontSize   int
+		lineHeight float64
+		shouldErr  bool
+	}{
+		{"", 0, 0, false},
+		{"font-size=10", 10, 0, false},
+		{"font-size=32", 32, 0, false},
+		{"font-size=18px", 18, 0, false},
+		{"line-height=1.0", 0, 1.0, false},
+		{"line-height=3", 0, 3.0, false},
+		{"font-size=16&line-height=1.5", 16, 1.5, false},
+		{"font-size=9", 0, 0, true},
+		{"font-size=33", 0, 0, true},
+		{"font-size=16.5", 0, 0, true},
+		{"font-size=abc", 0, 0, true},
+		{"line-height=0.99", 0, 0, true},
+		{"line-height=3.01", 0, 0, true},
+		{"line-height=NaN", 0, 0, true},
+		{"font-size=16%3Bcolor%3Ared", 0, 0, true},
+		{"line-height=1.5%3Bbackground%3Aurl(x)", 0, 0, true},
+	}
+	for _, tt := range tests {
+		q, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("failed to parse query %q: %v", tt.query, err)
+		}
+		got, err := ParseTypography(q)
+		if tt.shouldErr {
+			if err == nil {
+				t.Errorf("parseTypography(%q) expected error, got none", tt.query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTypography(%q) unexpected error: %v", tt.query, err)
+			continue
+		}
+		if tt.fontSize == 0 && tt.lineHeight == 0 {
+			if got != nil {
+				t.Errorf("parseTypography(%q) = %+v; want nil", tt.query, got)
+			}
+			continue
+		}
+		if got == nil || got.FontSize != tt.fontSize || got.LineHeight != tt.lineHeight {
+			t.Errorf("parseTypography(%q) = %+v; want font-size %d, line-height %v", tt.query, got, tt.fontSize, tt.lineHeight)
+		}
+	}
+}
+
+func TestFormatHTMLTypography(t *testing.T) {
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	article := readability.Article{Node: p}
+
+	rec := httptest.NewRecorder()
+	sakura, _ := LookupTheme("sakura")
+	formatHTML(rec, article, bytes.NewBufferString("<p>body</p>"), Options{
+		Theme:      sakura,
+		Typography: &typography{FontSize: 20, LineHeight: 1.8},
+		Nonce:      "abc123",
+	})
+	body := rec.Body.String()
+	want := `<style nonce="abc123">body{font-size:20px;line-height:1.8;}</style>`
+	if !strings.Contains(body, want) {
+		t.Fatalf("formatHTML missing typography style %q, got: %q", want, body)
+	}
+	if strings.Index(body, want) < strings.Index(body, "sakura.css") {
+		t.Errorf("typography style must come after the theme stylesheet to override it")
+	}
+
+	rec = httptest.NewRecorder()
+	formatHTML(rec, article, bytes.NewBufferString("<p>body</p>"), Options{})
+	if strings.Contains(rec.Body.String(), "<style") {
+		t.Errorf("formatHTML injected a style block without typography options: %q", rec.Body.String())
+	}
+}
+
+func TestFormatHTMLReadingMeta(t *testing.T) {
+	// 450 words in two paragraphs plus a script that must not be counted
+	doc, err := html.Parse(strings.NewReader("<div><p>" + strings.Repeat("word ", 300) + "</p><p>" +
+		strings.Repeat("more <b>words</b> ", 75) + "</p><script>var ignored = true;</script></div>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	article := readability.Article{Node: doc}
+	link, _ := url.Parse("https://example.com/post?id=1&ref=x")
+
+	rec := httptest.NewRecorder()
+	formatHTML(rec, article, bytes.NewBufferString("<p>body</p>"), Options{Link: link})
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<meta name="article:word-count" content="450">`,
+		`<meta name="article:reading-time-minutes" content="3">`,
+		`<meta name="article:canonical-url" content="https://example.com/post?id=1&amp;ref=x">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatHTML missing %s, got: %q", want, body)
+		}
+	}
+	if strings.Index(body, "article:word-count") > strings.Index(body, "</head>") {
+		t.Errorf("reading meta must be in the head")
+	}
+
+	rec = httptest.NewRecorder()
+	formatHTML(rec, readability.Article{}, bytes.NewBufferString(""), Options{})
+	if strings.Contains(rec.Body.String(), "article:") {
+		t.Errorf("formatHTML emitted reading meta for an empty article: %q", rec.Body.String())
+	}
+}
+
+func TestArticleExcerpt(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/excerpt.html")
+	if got := articleExcerpt(article, &bytes.Buffer{}); got != "Readability excerpt." {
+		t.Errorf("articleExcerpt() = %q; want readability excerpt", got)
+	}
+
+	// Without a readability excerpt, the first paragraph of the rendered content is used.
+	buf := bytes.NewBufferString("<h2>Heading</h2><p>  Fallback   <b>paragraph</b> </p><p>Second</p>")
+	if got := articleExcerpt(readability.Article{}, buf); got != "Fallback paragraph" {
+		t.Errorf("articleExcerpt() fallback = %q; want %q", got, "Fallback paragraph")
+	}
+
+	long := strings.Repeat("é", maxExcerptLength+50)
+	buf = bytes.NewBufferString("<p>" + long + "</p>")
+	if got := articleExcerpt(readability.Article{}, buf); len([]rune(got)) != maxExcerptLength {
+		t.Errorf("articleExcerpt() length = %d; want %d", len([]rune(got)), maxExcerptLength)
+	}
+}
+
+func TestFormatJSONExcerpt(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatJSON(rec, readability.Article{}, bytes.NewBufferString("<p>Only paragraph</p>"), Options{})
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["excerpt"] != "Only paragraph" {
+		t.Errorf("excerpt = %v; want %q", got["excerpt"], "Only paragraph")
+	}
+}
+
+func TestFormatJSONMetadata(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html lang="pt-BR"><head>
+<meta name="Author" content="Ana Lima">
+<meta property="og:site_name" content="Maré">
+<meta property="og:image" content="https://example.com/lead.jpg">
+<meta name="twitter:image" content="https://example.com/card.jpg">
+<meta property="article:published_time" content="2024-05-01T10:00:00-03:00">
+</head><body><p>Three little words</p></body></html>`))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	formatJSON(rec, readability.Article{Node: dom.FindElement(doc, "body")}, bytes.NewBufferString("<p>Three little words</p>"), Options{Document: doc, Link: testutil.FixtureBaseURL})
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]any{
+		"byline":         "Ana Lima",
+		"site_name":      "Maré",
+		"image":          "https://example.com/lead.jpg",
+		"language":       "pt-BR",
+		"published_time": "2024-05-01T10:00:00-03:00",
+		"word_count":     3.0,
+		"canonical_url":  "https://example.com/",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v; want %v", key, got[key], value)
+		}
+	}
+
+	// unknown metadata is left out rather than sent empty
+	rec = httptest.NewRecorder()
+	formatJSON(rec, readability.Article{}, &bytes.Buffer{}, Options{})
+	got = map[string]any{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, key := range []string{"byline", "site_name", "image", "language", "published_time"} {
+		if _, found := got[key]; found {
+			t.Errorf("%s present without metadata: %v", key, got[key])
+		}
+	}
+	if got["word_count"] != 0.0 {
+		t.Errorf("word_count = %v; want 0", got["word_count"])
+	}
+}
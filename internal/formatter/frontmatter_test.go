@@ -0,0 +1,84 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatMarkdownFrontmatter(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head>
+<meta name="Keywords" content="go, web , Go,,">
+<meta property="article:tag" content="Tag &quot;quoted&quot;">
+</head>`))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	link, _ := url.Parse("https://example.com/post")
+	rec := httptest.NewRecorder()
+	formatMarkdownFrontmatter(rec, readability.Article{}, bytes.NewBufferString("<p>Body text</p>"), Options{Link: link, Document: doc})
+
+	body := rec.Body.String()
+	front, content, found := strings.Cut(strings.TrimPrefix(body, "---\n"), "\n---\n\n")
+	if !strings.HasPrefix(body, "---\n") || !found {
+		t.Fatalf("missing --- delimiters: %q", body)
+	}
+	if !strings.Contains(content, "Body text") {
+		t.Errorf("markdown body missing: %q", content)
+	}
+
+	// every line must be a key with a JSON value, which YAML reads the same way
+	fields := map[string]any{}
+	for line := range strings.SplitSeq(front, "\n") {
+		key, raw, ok := strings.Cut(line, ": ")
+		if !ok {
+			t.Fatalf("invalid frontmatter line %q", line)
+		}
+		var value any
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			t.Fatalf("invalid value for %s: %q (%v)", key, raw, err)
+		}
+		fields[key] = value
+	}
+	if fields["title"] != "" || fields["canonical_url"] != "https://example.com/post" || fields["source"] != "https://example.com/post" {
+		t.Errorf("fields = %v", fields)
+	}
+	if _, found := fields["date"]; found {
+		t.Errorf("date set without a published time: %v", fields["date"])
+	}
+	want := []any{"go", "web", `Tag "quoted"`}
+	if tags, _ := fields["tags"].([]any); !slices.Equal(tags, want) {
+		t.Errorf("tags = %v; want %v", fields["tags"], want)
+	}
+}
+
+func TestFormatMarkdownFrontmatterWithoutKeywords(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatMarkdownFrontmatter(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), Options{})
+	if !strings.Contains(rec.Body.String(), "\ntags: []\n") {
+		t.Errorf("want an empty tags list: %q", rec.Body.String())
+	}
+}
+
+func TestFormatMarkdownFrontmatterOption(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post?ref=feed")
+	rec := httptest.NewRecorder()
+	formatMarkdown(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), Options{Link: link, Frontmatter: true})
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "---\ntitle: ") || !strings.Contains(body, "\nsource: \"https://example.com/post?ref=feed\"\n---\n\n") {
+		t.Errorf("frontmatter missing: %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	formatMarkdown(rec, readability.Article{}, bytes.NewBufferString("<p>Body</p>"), Options{Link: link})
+	if strings.HasPrefix(rec.Body.String(), "---") {
+		t.Errorf("frontmatter without the option: %q", rec.Body.String())
+	}
+}
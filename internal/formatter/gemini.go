@@ -0,0 +1,283 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatGemini renders the article as gemtext (text/gemini), the line oriented
+ * format of the Gemini protocol.
+ *
+ * Gemtext has no inline markup: headings become "#" lines (h4 and deeper are
+ * "###"), paragraphs single text lines, list items "* " lines, quotes "> " lines
+ * and <pre> blocks ``` fences. Tables become preformatted blocks with a line per
+ * row and their cells separated by " | ". Links can't be inline either, so each
+ * paragraph is followed by a "=> url text" line per link it contains.
+ */
+func formatGemini(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/gemini; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for gemini: %v", err)
+		return
+	}
+	var g geminiWriter
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		g.block("# " + title)
+	}
+	g.render(doc)
+	if _, err := io.WriteString(w, strings.Join(g.lines, "\n")+"\n"); err != nil {
+		log.Printf("error writing gemini response: %v", err)
+	}
+}
+
+/**
+ * geminiWriter accumulates the gemtext lines of formatGemini.
+ */
+type geminiWriter struct {
+	lines []string
+}
+
+/**
+ * block appends a group of lines, separated from the previous group by a blank line.
+ */
+func (g *geminiWriter) block(lines ...string) {
+	if len(lines) == 0 {
+		return
+	}
+	if len(g.lines) > 0 {
+		g.lines = append(g.lines, "")
+	}
+	g.lines = append(g.lines, lines...)
+}
+
+/**
+ * render writes the blocks under n. Runs of text and inline elements outside of
+ * paragraphs are written as paragraphs of their own.
+ */
+func (g *geminiWriter) render(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if geminiInline(c) {
+			run := []*html.Node{c}
+			for c.NextSibling != nil && geminiInline(c.NextSibling) {
+				c = c.NextSibling
+				run = append(run, c)
+			}
+			g.paragraph("", run...)
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "script", "style", "noscript", "template":
+		case "h1":
+			g.paragraph("# ", c)
+		case "h2":
+			g.paragraph("## ", c)
+		case "h3", "h4", "h5", "h6":
+			g.paragraph("### ", c)
+		case "p":
+			g.paragraph("", c)
+		case "blockquote":
+			var quote []string
+			for _, text := range blockTexts(c) {
+				quote = append(quote, "> "+text)
+			}
+			g.block(quote...)
+			g.block(geminiLinks(c)...)
+		case "ul", "ol":
+			var items []string
+			for _, li := range listItems(c) {
+				var sb strings.Builder
+				for part := li.FirstChild; part != nil; part = part.NextSibling {
+					if part.Type != html.ElementNode || (part.Data != "ul" && part.Data != "ol") {
+						sb.WriteString(dom.TextContent(part))
+					}
+				}
+				if text := strings.Join(strings.Fields(sb.String()), " "); text != "" {
+					items = append(items, "* "+text)
+				}
+			}
+			g.block(items...)
+			g.block(geminiLinks(c)...)
+		case "pre":
+			g.block("```"+codeLanguage(c), strings.Trim(dom.TextContent(c), "\n"), "```")
+		case "table":
+			g.table(c)
+			g.block(geminiLinks(c)...)
+		case "img":
+			g.block(geminiLinks(c)...)
+		case "br", "hr":
+		default:
+			g.render(c)
+		}
+	}
+}
+
+/**
+ * table writes the caption of table as a paragraph and its rows as a preformatted
+ * block, one row per line with the cells separated by " | ".
+ */
+func (g *geminiWriter) table(table *html.Node) {
+	if caption := dom.FindElement(table, "caption"); caption != nil {
+		g.paragraph("", caption)
+	}
+	rows := []string{"```"}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type != html.ElementNode:
+			case c.Data == "tr":
+				if row := geminiTableRow(c); row != "" {
+					rows = append(rows, row)
+				}
+			case c.Data == "thead", c.Data == "tbody", c.Data == "tfoot":
+				walk(c)
+			}
+		}
+	}
+	walk(table)
+	if len(rows) > 1 {
+		g.block(append(rows, "```")...)
+	}
+}
+
+/**
+ * paragraph writes the text of nodes as a single line starting with prefix,
+ * followed by the links found in them.
+ */
+func (g *geminiWriter) paragraph(prefix string, nodes ...*html.Node) {
+	var sb strings.Builder
+	var links []string
+	for _, n := range nodes {
+		sb.WriteString(dom.TextContent(n))
+		links = append(links, geminiLinks(n)...)
+	}
+	text := strings.Join(strings.Fields(sb.String()), " ")
+	if text != "" {
+		if prefix == "" && slices.ContainsFunc([]string{"#", "=>", "```", "* ", ">"}, func(marker string) bool { return strings.HasPrefix(text, marker) }) {
+			// plain text must not start like a gemtext line type
+			text = " " + text
+		}
+		g.block(prefix + text)
+	}
+	g.block(links...)
+}
+
+/**
+ * geminiLinks returns a "=> url text" line for every http(s) link and image under n.
+ */
+func geminiLinks(n *html.Node) []string {
+	var links []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			var target, label string
+			switch n.Data {
+			case "a":
+				target, label = dom.Attr(n, "href"), dom.TextContent(n)
+			case "img":
+				target, label = dom.Attr(n, "src"), cmp.Or(dom.Attr(n, "alt"), "Image")
+			}
+			if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				line := "=> " + u.String()
+				if label = strings.Join(strings.Fields(label), " "); label != "" {
+					line += " " + label
+				}
+				links = append(links, line)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return links
+}
+
+/**
+ * listItems returns the <li> elements of list, including those of nested lists, in document order.
+ * Gemtext has no nesting, so nested items end up as siblings of their parent item.
+ */
+func listItems(list *html.Node) []*html.Node {
+	var items []*html.Node
+	for c := list.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "li" {
+			items = append(items, c)
+			for nested := c.FirstChild; nested != nil; nested = nested.NextSibling {
+				if nested.Type == html.ElementNode && (nested.Data == "ul" || nested.Data == "ol") {
+					items = append(items, listItems(nested)...)
+				}
+			}
+		}
+	}
+	return items
+}
+
+/**
+ * blockTexts returns the collapsed text of each block under n, one line per block.
+ * Runs of text and inline elements and table rows make lines of their own, and
+ * every other element is split into its blocks the same way.
+ */
+func blockTexts(n *html.Node) []string {
+	var texts []string
+	var run strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(run.String()), " "); text != "" {
+			texts = append(texts, text)
+		}
+		run.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case geminiInline(c):
+			run.WriteString(dom.TextContent(c))
+		case c.Type != html.ElementNode:
+		case c.Data == "script", c.Data == "style", c.Data == "noscript", c.Data == "template":
+		case c.Data == "tr":
+			flush()
+			if row := geminiTableRow(c); row != "" {
+				texts = append(texts, row)
+			}
+		default:
+			flush()
+			texts = append(texts, blockTexts(c)...)
+		}
+	}
+	flush()
+	return texts
+}
+
+/**
+ * geminiInline reports whether n is text or an inline element, which flow into the
+ * line of the text around them.
+ */
+func geminiInline(n *html.Node) bool {
+	return n.Type == html.TextNode || (n.Type == html.ElementNode && (slices.Contains(meta.InlineElements, n.Data) || n.Data == "cite" || n.Data == "q"))
+}
+
+/**
+ * geminiTableRow returns the cells of the table row tr separated by " | ", or ""
+ * when they are all empty.
+ */
+func geminiTableRow(tr *html.Node) string {
+	row := tableRow(tr, " | ", func(sb *strings.Builder, n *html.Node) { sb.WriteString(dom.TextContent(n)) })
+	if strings.Trim(row, " |") == "" {
+		return ""
+	}
+	return row
+}
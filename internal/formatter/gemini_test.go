@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatGemini(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"headings", `<h1>One</h1><h2>Two</h2><h3>Three</h3><h5>Five</h5>`, "# One\n\n## Two\n\n### Three\n\n### Five\n"},
+		{"paragraph", "<p>Some\n  wrapped   text</p>", "Some wrapped text\n"},
+		{"links after paragraph", `<p>See <a href="https://a.example/">A</a> and <a href="/rel">B</a></p><p>Next</p>`, "See A and B\n\n=> https://a.example/ A\n\nNext\n"},
+		{"unordered list", `<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>`, "* One\n* Two\n* Nested\n"},
+		{"ordered list", `<ol><li>First</li><li><a href="https://x.example">Second</a></li></ol>`, "* First\n* Second\n\n=> https://x.example Second\n"},
+		{"code block", `<pre><code class="language-go">func main() {
+	println("hi")
+}
+</code></pre>`, "```go\nfunc main() {\n\tprintln(\"hi\")\n}\n```\n"},
+		{"quote", `<blockquote><p>First</p><p>Second</p></blockquote>`, "> First\n> Second\n"},
+		{"quote with loose text", `<blockquote><p>First</p>Loose <em>text</em><ul><li>Item</li></ul><footer>— <cite>Someone</cite></footer></blockquote>`, "> First\n> Loose text\n> Item\n> — Someone\n"},
+		{"quote with table", `<blockquote><table><tr><td>A</td><td>B</td></tr><tr><td>1</td><td>2</td></tr></table></blockquote>`, "> A | B\n> 1 | 2\n"},
+		{"table", `<table><caption>Scores</caption><thead><tr><th>Name</th><th>Score</th></tr></thead><tbody><tr><td><a href="https://a.example/">Ann</a></td><td>1</td></tr><tr><td></td><td></td></tr></tbody></table>`, "Scores\n\n```\nName | Score\nAnn | 1\n```\n\n=> https://a.example/ Ann\n"},
+		{"image", `<img src="https://img.example/a.png" alt="A cat">`, "=> https://img.example/a.png A cat\n"},
+		{"loose inline text", `<div>Loose <em>text</em><p>After</p></div>`, "Loose text\n\nAfter\n"},
+		{"line type escaped", `<p>* not a list</p><p># not a heading</p>`, " * not a list\n\n # not a heading\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			formatGemini(rec, readability.Article{}, bytes.NewBufferString(tt.input), Options{})
+			if ct := rec.Header().Get("Content-Type"); ct != "text/gemini; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("formatGemini() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
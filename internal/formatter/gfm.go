@@ -0,0 +1,367 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatGFM returns the article as GitHub Flavored Markdown.
+ *
+ * Besides plain Markdown, it writes tables as pipe tables, struck text as
+ * ~~strikethrough~~, checkboxes as task list markers (`- [x] done`) and footnotes
+ * as `[^1]` references, whose definitions are collected at the end of the document.
+ * A footnote is a link to the id of a list item, like most blog engines emit.
+ */
+func formatGFM(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for gfm: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		sb.WriteString("# " + gfmEscape(title) + "\n\n")
+	}
+	newGFMWriter(doc).document(&sb, doc)
+	if _, err := io.WriteString(w, strings.TrimSpace(NormalizeWhitespace(sb.String()))+"\n"); err != nil {
+		log.Printf("error writing gfm response: %v", err)
+	}
+}
+
+/**
+ * document writes doc to sb, followed by the definitions of its footnotes.
+ */
+func (g *gfmWriter) document(sb *strings.Builder, doc *html.Node) {
+	g.render(sb, doc, "")
+	for i, note := range g.notes {
+		var inner strings.Builder
+		g.children(&inner, note, "    ")
+		lines := strings.Split(strings.TrimSpace(NormalizeWhitespace(inner.String())), "\n")
+		sb.WriteString("\n\n[^" + strconv.Itoa(i+1) + "]: " + lines[0])
+		for _, line := range lines[1:] {
+			sb.WriteString("\n")
+			if line != "" {
+				sb.WriteString("    " + line)
+			}
+		}
+	}
+}
+
+// gfmEscaper backslash-escapes the characters GFM reads as inline markup.
+var gfmEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "~", `\~`, "<", `\<`)
+
+// gfmEscape escapes text for GitHub Flavored Markdown.
+func gfmEscape(text string) string {
+	return gfmEscaper.Replace(text)
+}
+
+/**
+ * gfmWriter holds the state of formatGFM while it walks the content.
+ */
+type gfmWriter struct {
+	// notes lists the footnote definitions (list items), in the order of their
+	// first reference; footnote [^n] is notes[n-1].
+	notes []*html.Node
+	// refs maps the id of each footnote to its number.
+	refs map[string]int
+	// backrefs holds the ids of the footnote references, which the "↩" links of
+	// the definitions point back to.
+	backrefs map[string]bool
+	// cell is set while rendering a table cell, where line breaks and pipes must
+	// not end the row.
+	cell bool
+	// escape escapes the text of the content (gfmEscape unless changed).
+	escape func(string) string
+	// headingShift is added to the level of every heading, up to h6.
+	headingShift int
+}
+
+/**
+ * newGFMWriter finds the footnotes of doc: links to the id of a list item.
+ */
+func newGFMWriter(doc *html.Node) *gfmWriter {
+	g := &gfmWriter{refs: map[string]int{}, backrefs: map[string]bool{}, escape: gfmEscape}
+	ids := map[string]*html.Node{}
+	var links []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id := dom.Attr(n, "id"); id != "" {
+				if _, found := ids[id]; !found {
+					ids[id] = n
+				}
+			}
+			if n.Data == "a" && strings.HasPrefix(dom.Attr(n, "href"), "#") {
+				links = append(links, n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, link := range links {
+		id := strings.TrimPrefix(dom.Attr(link, "href"), "#")
+		target := ids[id]
+		if target == nil || target.Data != "li" {
+			continue
+		}
+		if _, found := g.refs[id]; !found {
+			g.notes = append(g.notes, target)
+			g.refs[id] = len(g.notes)
+		}
+		// the definition may link back to the anchor or to its <sup>
+		for n := link; n != nil && n.Type == html.ElementNode; n = n.Parent {
+			if id := dom.Attr(n, "id"); id != "" {
+				g.backrefs[id] = true
+			}
+			if n != link && n.Data != "sup" {
+				break
+			}
+		}
+	}
+	return g
+}
+
+// isNote reports whether n is a footnote definition, rendered at the end instead.
+func (g *gfmWriter) isNote(n *html.Node) bool {
+	return slices.Contains(g.notes, n)
+}
+
+// children writes the children of n to sb.
+func (g *gfmWriter) children(sb *strings.Builder, n *html.Node, indent string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		g.render(sb, c, indent)
+	}
+}
+
+/**
+ * render writes n and its children to sb as GitHub Flavored Markdown. List items
+ * are prefixed with indent, which grows with each nested list.
+ */
+func (g *gfmWriter) render(sb *strings.Builder, n *html.Node, indent string) {
+	switch n.Type {
+	case html.TextNode:
+		escape := g.escape
+		if g.cell {
+			escape = func(text string) string { return strings.ReplaceAll(g.escape(text), "|", `\|`) }
+		}
+		writeCollapsedText(sb, n.Data, escape)
+		return
+	case html.ElementNode:
+	default:
+		g.children(sb, n, indent)
+		return
+	}
+
+	inline := func(marker string) {
+		var inner strings.Builder
+		g.children(&inner, n, indent)
+		writeWrapped(sb, inner.String(), marker, marker)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		var inner strings.Builder
+		g.children(&inner, n, indent)
+		if text := strings.Join(strings.Fields(inner.String()), " "); text != "" {
+			level := min(int(n.Data[1]-'0')+g.headingShift, 6)
+			sb.WriteString("\n\n" + strings.Repeat("#", level) + " " + text + "\n\n")
+		}
+	case "b", "strong":
+		inline("**")
+	case "i", "em":
+		inline("*")
+	case "s", "del", "strike":
+		inline("~~")
+	case "code":
+		text := dom.TextContent(n)
+		if g.cell {
+			text = strings.ReplaceAll(text, "|", `\|`)
+		}
+		marker := "`"
+		if strings.Contains(text, "`") {
+			marker = "``"
+		}
+		writeWrapped(sb, text, marker, marker)
+	case "pre":
+		code := strings.Trim(dom.TextContent(n), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		sb.WriteString("\n\n" + fence + codeLanguage(n) + "\n" + code + "\n" + fence + "\n\n")
+	case "a":
+		href := strings.TrimSpace(dom.Attr(n, "href"))
+		id := strings.TrimPrefix(href, "#")
+		switch {
+		case g.refs[id] > 0 && id != href:
+			sb.WriteString("[^" + strconv.Itoa(g.refs[id]) + "]")
+		case g.backrefs[id] && id != href:
+		case href == "":
+			g.children(sb, n, indent)
+		default:
+			var inner strings.Builder
+			g.children(&inner, n, indent)
+			if strings.TrimSpace(inner.String()) == "" {
+				inner.WriteString(gfmEscape(href))
+			}
+			writeWrapped(sb, inner.String(), "[", "]("+gfmLinkDestination(href)+")")
+		}
+	case "img":
+		if src := strings.TrimSpace(dom.Attr(n, "src")); src != "" {
+			sb.WriteString("![" + gfmEscape(strings.TrimSpace(dom.Attr(n, "alt"))) + "](" + gfmLinkDestination(src) + ")")
+		}
+	case "input":
+		if strings.EqualFold(dom.Attr(n, "type"), "checkbox") {
+			if dom.HasAttr(n, "checked") {
+				sb.WriteString("[x] ")
+			} else {
+				sb.WriteString("[ ] ")
+			}
+		}
+	case "br":
+		if g.cell {
+			sb.WriteString("<br>")
+		} else {
+			sb.WriteString("\\\n" + indent)
+		}
+	case "ul", "ol":
+		number := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				g.render(sb, c, indent)
+				continue
+			}
+			if g.isNote(c) {
+				continue
+			}
+			bullet := "-"
+			if n.Data == "ol" {
+				number++
+				bullet = strconv.Itoa(number) + "."
+			}
+			var inner strings.Builder
+			nested := indent + strings.Repeat(" ", len(bullet)+1)
+			g.children(&inner, c, nested)
+			// continuation paragraphs must be indented to stay in the item
+			lines := strings.Split(strings.Trim(inner.String(), " \n"), "\n")
+			for i, line := range lines[1:] {
+				if line != "" && !strings.HasPrefix(line, nested) {
+					lines[i+1] = nested + line
+				}
+			}
+			sb.WriteString("\n" + indent + bullet + " " + strings.Join(lines, "\n"))
+		}
+		sb.WriteString("\n\n")
+	case "blockquote":
+		var inner strings.Builder
+		g.children(&inner, n, "")
+		sb.WriteString("\n\n" + quoteLines(inner.String()) + "\n\n")
+	case "table":
+		g.table(sb, n)
+	case "hr":
+		sb.WriteString("\n\n---\n\n")
+	case "p", "div", "section", "article", "figure", "figcaption", "dl", "dt", "dd":
+		sb.WriteString("\n\n")
+		g.children(sb, n, indent)
+		sb.WriteString("\n\n")
+	default:
+		g.children(sb, n, indent)
+	}
+}
+
+/**
+ * table writes a table as a GFM pipe table. The first row is the header, as GFM
+ * tables require one, and shorter rows are padded with empty cells. The align
+ * attribute of the header cells sets the column alignment.
+ */
+func (g *gfmWriter) table(sb *strings.Builder, table *html.Node) {
+	var rows [][]string
+	var aligns []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data == "table" {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+					continue
+				}
+				var inner strings.Builder
+				g.cell = true
+				g.children(&inner, cell, "")
+				g.cell = false
+				row = append(row, strings.Join(strings.Fields(inner.String()), " "))
+				if len(rows) == 0 {
+					aligns = append(aligns, strings.ToLower(dom.Attr(cell, "align")))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(table)
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := range columns {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n\n")
+	writeRow(rows[0])
+	separators := make([]string, columns)
+	for i := range separators {
+		align := ""
+		if i < len(aligns) {
+			align = aligns[i]
+		}
+		switch align {
+		case "left":
+			separators[i] = ":---"
+		case "center":
+			separators[i] = ":---:"
+		case "right":
+			separators[i] = "---:"
+		default:
+			separators[i] = "---"
+		}
+	}
+	writeRow(separators)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	sb.WriteString("\n")
+}
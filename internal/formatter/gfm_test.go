@@ -0,0 +1,40 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatGFM(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"strikethrough", `<p>Was <del>wrong</del> and <s>old</s></p>`, "Was ~~wrong~~ and ~~old~~\n"},
+		{"table", `<table><thead><tr><th>Name</th><th align="right">Size</th></tr></thead><tbody><tr><td>a|b</td><td><code>1|2</code></td></tr><tr><td>short</td></tr></tbody></table>`,
+			"| Name | Size |\n| --- | ---: |\n| a\\|b | `1\\|2` |\n| short |  |\n"},
+		{"task list", `<ul><li><input type="checkbox" checked> Done</li><li><input type="checkbox"> Todo</li></ul>`, "- [x] Done\n- [ ] Todo\n"},
+		{"footnotes", `<p>Claim<sup id="fnref1"><a href="#fn1">1</a></sup> and another<sup><a href="#fn2">2</a></sup>.</p>` +
+			`<ol class="footnotes"><li id="fn1">First source. <a href="#fnref1">↩</a></li><li id="fn2">Second <em>source</em>.</li></ol>`,
+			"Claim[^1] and another[^2].\n\n[^1]: First source.\n\n[^2]: Second *source*.\n"},
+		{"inline markup", `<h2>Sub <em>title</em></h2><p><b>Bold</b> <a href="https://example.com/a b">link</a> <img src="/x.png" alt="X"> 2*3</p>`,
+			"## Sub *title*\n\n**Bold** [link](<https://example.com/a b>) ![X](/x.png) 2\\*3\n"},
+		{"nested list", `<ol><li>One<ul><li>Inner</li></ul></li><li>Two</li></ol>`, "1. One\n   - Inner\n2. Two\n"},
+		{"paragraphs in item", `<ul><li><p>First</p><p>Second</p></li></ul>`, "- First\n\n  Second\n"},
+		{"code block", "<pre><code class=\"language-md\">```\nfenced\n```</code></pre>", "````md\n```\nfenced\n```\n````\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			formatGFM(rec, readability.Article{}, bytes.NewBufferString(tt.input), Options{})
+			if ct := rec.Header().Get("Content-Type"); ct != "text/markdown; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("formatGFM() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
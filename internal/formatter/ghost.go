@@ -0,0 +1,337 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * GhostLexical is a Lexical editor state, the document format of Ghost's editor,
+ * as accepted in the lexical field of the Ghost Admin API.
+ */
+type GhostLexical struct {
+	Root LexicalNode `json:"root"`
+}
+
+/**
+ * LexicalNode is a serialized Lexical node. Element nodes (root, paragraph,
+ * heading, quote, list, listitem, link) carry a LexicalElement, text nodes a
+ * LexicalText and Ghost cards (image, codeblock) a LexicalCard; linebreak and
+ * horizontalrule nodes carry none.
+ */
+type LexicalNode struct {
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+	// Format is a string alignment for elements and a bitmask of lexicalBold... for text.
+	Format any `json:"format,omitempty"`
+	*LexicalElement
+	*LexicalText
+	*LexicalCard
+}
+
+// LexicalElement holds the fields of element nodes.
+type LexicalElement struct {
+	Children  []LexicalNode `json:"children"`
+	Direction string        `json:"direction"`
+	Indent    int           `json:"indent"`
+	Tag       string        `json:"tag,omitempty"`
+	ListType  string        `json:"listType,omitempty"`
+	Start     int           `json:"start,omitempty"`
+	Value     int           `json:"value,omitempty"`
+	URL       string        `json:"url,omitempty"`
+}
+
+// LexicalText holds the fields of text nodes.
+type LexicalText struct {
+	Detail int    `json:"detail"`
+	Mode   string `json:"mode"`
+	Style  string `json:"style"`
+	Text   string `json:"text"`
+}
+
+// LexicalCard holds the fields of the Ghost image and code block cards.
+type LexicalCard struct {
+	Src      string `json:"src,omitempty"`
+	Alt      string `json:"alt,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// Lexical text format flags.
+const (
+	lexicalBold = 1 << iota
+	lexicalItalic
+	lexicalStrikethrough
+	lexicalUnderline
+	lexicalCode
+	lexicalSubscript
+	lexicalSuperscript
+)
+
+// lexicalElement returns an element node of the given type holding children.
+func lexicalElement(kind string, children []LexicalNode) LexicalNode {
+	if children == nil {
+		children = []LexicalNode{}
+	}
+	return LexicalNode{Type: kind, Version: 1, Format: "", LexicalElement: &LexicalElement{Children: children, Direction: "ltr"}}
+}
+
+/**
+ * HTMLToLexical converts article HTML into a Lexical document for Ghost.
+ *
+ * Headings keep their level as the tag of heading nodes, paragraphs become
+ * paragraph, <blockquote> quote and lists list nodes with listitem children
+ * (a nested list goes in a listitem of its own, as Lexical expects). <pre> and
+ * images become Ghost's codeblock and image cards; images inside text blocks
+ * follow the block, as cards can't be inlined.
+ */
+func HTMLToLexical(node *html.Node) GhostLexical {
+	var walk func(n *html.Node) []LexicalNode
+	text := func(kind string, inline []LexicalNode) []LexicalNode {
+		if len(inline) == 0 {
+			return nil
+		}
+		return []LexicalNode{lexicalElement(kind, inline)}
+	}
+	image := func(img *html.Node, caption string) []LexicalNode {
+		src, err := url.Parse(strings.TrimSpace(dom.Attr(img, "src")))
+		if err != nil || (src.Scheme != "http" && src.Scheme != "https") {
+			return nil
+		}
+		card := &LexicalCard{Src: src.String(), Alt: strings.TrimSpace(dom.Attr(img, "alt")), Caption: caption}
+		return []LexicalNode{{Type: "image", Version: 1, LexicalCard: card}}
+	}
+	images := func(n *html.Node) []LexicalNode {
+		var cards []LexicalNode
+		for _, img := range cascadia.QueryAll(n, imgSelector) {
+			cards = append(cards, image(img, "")...)
+		}
+		return cards
+	}
+	list := func(n *html.Node) LexicalNode {
+		block := lexicalElement("list", nil)
+		block.Tag, block.ListType, block.Start = "ul", "bullet", 1
+		if n.Data == "ol" {
+			block.Tag, block.ListType = "ol", "number"
+			if start, err := strconv.Atoi(dom.Attr(n, "start")); err == nil {
+				block.Start = start
+			}
+		}
+		return block
+	}
+	var listItems func(n *html.Node, parent *LexicalNode)
+	listItems = func(n *html.Node, parent *LexicalNode) {
+		item := func(children []LexicalNode) {
+			li := lexicalElement("listitem", children)
+			li.Value = parent.Start + len(parent.Children)
+			parent.Children = append(parent.Children, li)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+			item(lexicalInline(c))
+			for nested := c.FirstChild; nested != nil; nested = nested.NextSibling {
+				if nested.Type == html.ElementNode && (nested.Data == "ul" || nested.Data == "ol") {
+					sub := list(nested)
+					listItems(nested, &sub)
+					item([]LexicalNode{sub})
+				}
+			}
+		}
+	}
+	walk = func(n *html.Node) []LexicalNode {
+		var blocks []LexicalNode
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			// loose text and inline elements are gathered into a single paragraph
+			if c.Type == html.TextNode || (c.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.Data)) {
+				run := []*html.Node{c}
+				for c.NextSibling != nil && (c.NextSibling.Type == html.TextNode || (c.NextSibling.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.NextSibling.Data))) {
+					c = c.NextSibling
+					run = append(run, c)
+				}
+				blocks = append(blocks, text("paragraph", lexicalInline(run...))...)
+				continue
+			}
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "script", "style", "noscript", "template":
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if heading := text("heading", lexicalInline(c)); heading != nil {
+					heading[0].Tag = c.Data
+					blocks = append(blocks, heading...)
+				}
+			case "p":
+				blocks = append(blocks, text("paragraph", lexicalInline(c))...)
+				blocks = append(blocks, images(c)...)
+			case "blockquote":
+				blocks = append(blocks, text("quote", lexicalInline(c))...)
+			case "pre":
+				card := &LexicalCard{Code: strings.Trim(dom.TextContent(c), "\n"), Language: codeLanguage(c)}
+				blocks = append(blocks, LexicalNode{Type: "codeblock", Version: 1, LexicalCard: card})
+			case "hr":
+				blocks = append(blocks, LexicalNode{Type: "horizontalrule", Version: 1})
+			case "img":
+				blocks = append(blocks, image(c, "")...)
+			case "figure":
+				var caption string
+				if figcaption := dom.FindElement(c, "figcaption"); figcaption != nil {
+					caption = html.EscapeString(strings.Join(strings.Fields(dom.TextContent(figcaption)), " "))
+				}
+				if img := dom.FindElement(c, "img"); img != nil {
+					blocks = append(blocks, image(img, caption)...)
+				} else {
+					blocks = append(blocks, walk(c)...)
+				}
+			case "ul", "ol":
+				block := list(c)
+				listItems(c, &block)
+				if len(block.Children) > 0 {
+					blocks = append(blocks, block)
+				}
+				blocks = append(blocks, images(c)...)
+			default:
+				blocks = append(blocks, walk(c)...)
+			}
+		}
+		return blocks
+	}
+	return GhostLexical{Root: lexicalElement("root", walk(node))}
+}
+
+/**
+ * lexicalInline converts the inline content of nodes into Lexical text, link and
+ * linebreak nodes, merging runs with the same format. Nested lists and images are
+ * skipped, as they become nodes of their own; paragraphs inside the content (of a
+ * quote, say) are separated by line breaks.
+ */
+func lexicalInline(nodes ...*html.Node) []LexicalNode {
+	add := func(into *[]LexicalNode, content string, format int) {
+		if last := len(*into) - 1; last >= 0 && (*into)[last].LexicalText != nil && (*into)[last].Format == format {
+			prev := (*into)[last].LexicalText
+			if strings.HasSuffix(prev.Text, " ") {
+				content = strings.TrimLeft(content, " ")
+			}
+			prev.Text += content
+			return
+		}
+		*into = append(*into, LexicalNode{Type: "text", Version: 1, Format: format, LexicalText: &LexicalText{Mode: "normal", Text: content}})
+	}
+	var walk func(n *html.Node, format int, into *[]LexicalNode)
+	walk = func(n *html.Node, format int, into *[]LexicalNode) {
+		if n.Type == html.TextNode {
+			content := strings.Join(strings.Fields(n.Data), " ")
+			if strings.TrimLeft(n.Data, " \t\n\r") != n.Data {
+				content = " " + content
+			}
+			if content != " " && strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+				content += " "
+			}
+			if content != "" {
+				add(into, content, format)
+			}
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template", "img", "ul", "ol":
+				return
+			case "b", "strong":
+				format |= lexicalBold
+			case "i", "em":
+				format |= lexicalItalic
+			case "s", "del", "strike":
+				format |= lexicalStrikethrough
+			case "u":
+				format |= lexicalUnderline
+			case "code":
+				format |= lexicalCode
+			case "sub":
+				format |= lexicalSubscript
+			case "sup":
+				format |= lexicalSuperscript
+			case "br":
+				*into = append(*into, LexicalNode{Type: "linebreak", Version: 1})
+				return
+			case "p", "div":
+				if len(*into) > 0 {
+					*into = append(*into, LexicalNode{Type: "linebreak", Version: 1})
+				}
+			case "a":
+				if href, err := url.Parse(dom.Attr(n, "href")); err == nil && (href.Scheme == "http" || href.Scheme == "https" || href.Scheme == "mailto") {
+					link := lexicalElement("link", nil)
+					link.URL = href.String()
+					for c := n.FirstChild; c != nil; c = c.NextSibling {
+						walk(c, format, &link.Children)
+					}
+					if len(link.Children) > 0 {
+						*into = append(*into, link)
+					}
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, format, into)
+		}
+	}
+	var inline []LexicalNode
+	for _, n := range nodes {
+		walk(n, 0, &inline)
+	}
+
+	// trim the edges, dropping text and line breaks left empty
+	for len(inline) > 0 {
+		if first := inline[0]; first.Type == "text" {
+			if first.Text = strings.TrimLeft(first.Text, " "); first.Text != "" {
+				break
+			}
+		} else if first.Type != "linebreak" {
+			break
+		}
+		inline = inline[1:]
+	}
+	for len(inline) > 0 {
+		last := inline[len(inline)-1]
+		if last.Type == "text" {
+			if last.Text = strings.TrimRight(last.Text, " "); last.Text != "" {
+				break
+			}
+		} else if last.Type != "linebreak" {
+			break
+		}
+		inline = inline[:len(inline)-1]
+	}
+	return inline
+}
+
+/**
+ * formatGhost returns the article as a Lexical document (see HTMLToLexical), to be
+ * imported into Ghost through the lexical field of its Admin API.
+ */
+func formatGhost(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for ghost: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	if err := json.NewEncoder(w).Encode(HTMLToLexical(doc)); err != nil {
+		log.Printf("error encoding lexical document: %v", err)
+	}
+}
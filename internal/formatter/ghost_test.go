@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestHTMLToLexical(t *testing.T) {
+	input := `<div><h1>Title</h1><h3>Part</h3><p>Some <b>bold <i>both</i></b> and <a href="https://example.com/">a link</a><br>next <img src="https://example.com/a.png" alt="A"></p>
+<ol start="3"><li>Three<ul><li>Nested</li></ul></li><li>Four</li></ol>
+<blockquote><p>First</p><p>Second</p></blockquote>
+<pre><code class="language-go">fmt.Println("hi")</code></pre></div>`
+	doc, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("failed to parse content: %v", err)
+	}
+	root := HTMLToLexical(doc).Root
+
+	if root.Type != "root" || root.Version != 1 || root.Direction != "ltr" {
+		t.Errorf("root = %+v", root)
+	}
+	var types []string
+	for _, n := range root.Children {
+		types = append(types, n.Type)
+	}
+	if got := strings.Join(types, ","); got != "heading,heading,paragraph,image,list,quote,codeblock" {
+		t.Fatalf("block types = %s", got)
+	}
+	if h1, h3 := root.Children[0], root.Children[1]; h1.Tag != "h1" || h3.Tag != "h3" || h3.Children[0].Text != "Part" {
+		t.Errorf("headings = %+v, %+v", h1, h3)
+	}
+
+	para := root.Children[2].Children
+	var formats []string
+	for _, n := range para {
+		switch n.Type {
+		case "text":
+			formats = append(formats, fmt.Sprintf("%s=%v", n.Text, n.Format))
+		case "link":
+			formats = append(formats, "link:"+n.URL+":"+n.Children[0].Text)
+		default:
+			formats = append(formats, n.Type)
+		}
+	}
+	want := "Some =0,bold =1,both=3, and =0,link:https://example.com/:a link,linebreak,next=0"
+	if got := strings.Join(formats, ","); got != want {
+		t.Errorf("paragraph = %s; want %s", got, want)
+	}
+
+	list := root.Children[4]
+	if list.Tag != "ol" || list.ListType != "number" || list.Start != 3 || len(list.Children) != 3 {
+		t.Fatalf("list = %+v", list)
+	}
+	if nested := list.Children[1].Children[0]; nested.Type != "list" || nested.ListType != "bullet" || nested.Children[0].Children[0].Text != "Nested" {
+		t.Errorf("nested list = %+v", nested)
+	}
+	if last := list.Children[2]; last.Value != 5 || last.Children[0].Text != "Four" {
+		t.Errorf("last item = %+v", last)
+	}
+	if quote := root.Children[5].Children; len(quote) != 3 || quote[1].Type != "linebreak" {
+		t.Errorf("quote = %+v", quote)
+	}
+	if code := root.Children[6]; code.Code != `fmt.Println("hi")` || code.Language != "go" {
+		t.Errorf("code block = %+v", code)
+	}
+}
+
+func TestFormatGhost(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatGhost(rec, readability.Article{}, bytes.NewBufferString("<h2>Heading</h2><p>Text</p>"), Options{})
+
+	var doc struct {
+		Root struct {
+			Type      string           `json:"type"`
+			Version   int              `json:"version"`
+			Direction string           `json:"direction"`
+			Format    *string          `json:"format"`
+			Indent    *int             `json:"indent"`
+			Children  []map[string]any `json:"children"`
+		} `json:"root"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	root := doc.Root
+	if root.Type != "root" || root.Version != 1 || root.Direction != "ltr" || root.Format == nil || *root.Format != "" || root.Indent == nil || *root.Indent != 0 {
+		t.Errorf("root = %+v", root)
+	}
+	if len(root.Children) != 2 || root.Children[0]["type"] != "heading" || root.Children[0]["tag"] != "h2" {
+		t.Fatalf("children = %v", root.Children)
+	}
+	text := root.Children[1]["children"].([]any)[0].(map[string]any)
+	if text["type"] != "text" || text["text"] != "Text" || text["format"] != 0.0 || text["mode"] != "normal" || text["version"] != 1.0 {
+		t.Errorf("text node = %v", text)
+	}
+}
@@ -0,0 +1,29 @@
+package formatter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+func TestFormatGraph(t *testing.T) {
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	p.AppendChild(&html.Node{Type: html.TextNode, Data: "nothing named here."})
+	rec := httptest.NewRecorder()
+	formatGraph(rec, readability.Article{Node: p}, nil, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	if got := rec.Body.String(); got != `{"nodes":[],"edges":[]}`+"\n" {
+		t.Errorf("body = %s; want empty node and edge lists", got)
+	}
+	var graph meta.Graph
+	if err := json.Unmarshal(rec.Body.Bytes(), &graph); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+/**
+ * HASTNode is a node of a hast (Hypertext Abstract Syntax Tree), the HTML syntax tree
+ * of the unified/rehype ecosystem: https://github.com/syntax-tree/hast.
+ */
+type HASTNode struct {
+	// Type is "root", "element", "text", "comment" or "doctype".
+	Type string `json:"type"`
+	// TagName and Properties are only set on elements.
+	TagName    string         `json:"tagName,omitempty"`
+	Properties map[string]any `json:"properties,omitzero"`
+	// Children is set on roots and elements, even when empty.
+	Children []HASTNode `json:"children,omitzero"`
+	// Value is the content of text and comment nodes.
+	Value string `json:"value,omitempty"`
+}
+
+/**
+ * hastPropertyNames maps the attributes whose hast property name is not the attribute
+ * name itself; data-* and aria-* attributes are camel cased instead.
+ */
+var hastPropertyNames = map[string]string{
+	"class":           "className",
+	"for":             "htmlFor",
+	"accept-charset":  "acceptCharset",
+	"http-equiv":      "httpEquiv",
+	"tabindex":        "tabIndex",
+	"colspan":         "colSpan",
+	"rowspan":         "rowSpan",
+	"srcset":          "srcSet",
+	"datetime":        "dateTime",
+	"crossorigin":     "crossOrigin",
+	"referrerpolicy":  "referrerPolicy",
+	"contenteditable": "contentEditable",
+}
+
+/**
+ * NodeToHAST converts node and its descendants into a hast tree.
+ *
+ * Documents become roots. Attributes become properties named like hast does
+ * (class is the className list, data-foo is dataFoo, ...); other values are
+ * kept as the attribute strings.
+ */
+func NodeToHAST(node *html.Node) HASTNode {
+	var out HASTNode
+	switch node.Type {
+	case html.DocumentNode:
+		out.Type = "root"
+	case html.ElementNode:
+		out.Type = "element"
+		out.TagName = node.Data
+		out.Properties = map[string]any{}
+		for _, attr := range node.Attr {
+			name := attr.Key
+			switch {
+			case hastPropertyNames[name] != "":
+				name = hastPropertyNames[name]
+			case strings.HasPrefix(name, "data-") || strings.HasPrefix(name, "aria-"):
+				name = camelCase(name)
+			}
+			if name == "className" {
+				out.Properties[name] = strings.Fields(attr.Val)
+			} else {
+				out.Properties[name] = attr.Val
+			}
+		}
+	case html.TextNode:
+		return HASTNode{Type: "text", Value: node.Data}
+	case html.CommentNode:
+		return HASTNode{Type: "comment", Value: node.Data}
+	case html.DoctypeNode:
+		return HASTNode{Type: "doctype"}
+	default:
+		out.Type = "root"
+	}
+	out.Children = []HASTNode{}
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		out.Children = append(out.Children, NodeToHAST(c))
+	}
+	return out
+}
+
+/**
+ * camelCase turns a dashed attribute name into its camel cased property name (data-foo-bar to dataFooBar).
+ */
+func camelCase(name string) string {
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+/**
+ * formatHAST returns the article content as a hast root (see NodeToHAST), for
+ * unified/rehype pipelines.
+ */
+func formatHAST(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/json")
+	root := HASTNode{Type: "root", Children: []HASTNode{}}
+	switch {
+	case article.Node == nil:
+	case article.Node.Type == html.DocumentNode:
+		root = NodeToHAST(article.Node)
+	default:
+		root.Children = append(root.Children, NodeToHAST(article.Node))
+	}
+	if err := json.NewEncoder(w).Encode(root); err != nil {
+		log.Printf("error encoding hast: %v", err)
+	}
+}
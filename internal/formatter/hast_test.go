@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatHAST(t *testing.T) {
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	a := &html.Node{Type: html.ElementNode, Data: "a", Attr: []html.Attribute{{Key: "href", Val: "x"}}}
+	a.AppendChild(&html.Node{Type: html.TextNode, Data: "t"})
+	p.AppendChild(a)
+
+	rec := httptest.NewRecorder()
+	formatHAST(rec, readability.Article{Node: p}, nil, Options{})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+
+	want := `{"type":"root","children":[{"type":"element","tagName":"p","properties":{},"children":[` +
+		`{"type":"element","tagName":"a","properties":{"href":"x"},"children":[{"type":"text","value":"t"}]}]}]}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatHAST() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNodeToHASTProperties(t *testing.T) {
+	div := &html.Node{Type: html.ElementNode, Data: "div", Attr: []html.Attribute{
+		{Key: "class", Val: "note  wide"},
+		{Key: "data-user-id", Val: "42"},
+		{Key: "aria-label", Val: "Note"},
+		{Key: "tabindex", Val: "0"},
+	}}
+	div.AppendChild(&html.Node{Type: html.CommentNode, Data: " hidden "})
+
+	got, err := json.Marshal(NodeToHAST(div))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"element","tagName":"div","properties":{"ariaLabel":"Note","className":["note","wide"],"dataUserId":"42","tabIndex":"0"},` +
+		`"children":[{"type":"comment","value":" hidden "}]}`
+	if string(got) != want {
+		t.Errorf("NodeToHAST() =\n%s\nwant\n%s", got, want)
+	}
+}
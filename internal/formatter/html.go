@@ -0,0 +1,416 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/lucasew/readability-web/internal/stats"
+)
+
+const (
+	minFontSize   = 10
+	maxFontSize   = 32
+	minLineHeight = 1.0
+	maxLineHeight = 3.0
+
+	// inlined stylesheets (`?format=reader`) limits
+	maxCSSSize      = 100 << 10 // 100 KiB
+	cssFetchTimeout = 5 * time.Second
+)
+
+/**
+ * Template is the raw HTML template string used for rendering the article.
+ *
+ * It provides a minimal HTML5 structure and includes the Sakura CSS library
+ * for a clean, typography-focused reading experience without distractions.
+ * The template expects a struct with Title and Content fields, plus the ThemeURL
+ * stylesheet (empty for no theme) and optional Typography overrides rendered as
+ * a nonce-protected inline style after the theme.
+ */
+const Template = `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8"/>
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	{{- with .ThemeURL}}
+	<link id="theme" rel="stylesheet" href="{{.}}">
+	{{- end}}
+	{{- if .WordCount}}
+	<meta name="article:word-count" content="{{.WordCount}}">
+	<meta name="article:reading-time-minutes" content="{{.ReadingTimeMinutes}}">
+	{{- end}}
+	{{- with .CanonicalURL}}
+	<meta name="article:canonical-url" content="{{.}}">
+	{{- end}}
+	{{- with .Typography}}
+	<style nonce="{{$.Nonce}}">body{ {{- if .FontSize}}font-size:{{.FontSize}}px;{{end}}{{if .LineHeight}}line-height:{{.LineHeight}};{{end -}} }</style>
+	{{- end}}
+</head>
+<body>
+	<script src="https://bookmarklet-theme.vercel.app/script.js"></script>
+	<h1>{{.Title}}</h1>
+	{{.Content}}
+</body>
+</html>
+`
+
+var (
+	/**
+	 * DefaultTemplate is the parsed Go template instance.
+	 *
+	 * It is initialized at startup to avoid the overhead of parsing the template
+	 * on every request, ensuring faster response times.
+	 * Self-hosted deployments can replace it by pointing ARTICLE_TEMPLATE_PATH
+	 * at a template file (see LoadTemplate), and reload it by sending SIGHUP.
+	 */
+	DefaultTemplate = LoadTemplate(os.Getenv("ARTICLE_TEMPLATE_PATH"))
+
+	// templateMu guards DefaultTemplate, which is swapped on SIGHUP (see reloadTemplate).
+	templateMu sync.RWMutex
+)
+
+/**
+ * parseTemplate parses the HTML template used by formatHTML.
+ *
+ * When path is set, the file is parsed with template.ParseFiles and must use the same
+ * {{.Title}} and {{.Content}} fields as the built-in Template. Otherwise the
+ * built-in Template is returned.
+ */
+func parseTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.New("article").Parse(Template)
+	}
+	return template.ParseFiles(path)
+}
+
+/**
+ * LoadTemplate returns the template for path, falling back to the built-in one.
+ *
+ * A missing or invalid file is logged and the built-in Template is used instead,
+ * so a bad deployment setting never takes the service down.
+ */
+func LoadTemplate(path string) *template.Template {
+	tmpl, err := parseTemplate(path)
+	if err != nil {
+		log.Printf("error loading template from ARTICLE_TEMPLATE_PATH %q, using built-in: %v", path, err)
+		return template.Must(parseTemplate(""))
+	}
+	return tmpl
+}
+
+/**
+ * currentTemplate returns DefaultTemplate, guarded against concurrent reloads.
+ */
+func currentTemplate() *template.Template {
+	templateMu.RLock()
+	defer templateMu.RUnlock()
+	return DefaultTemplate
+}
+
+/**
+ * SetTemplate replaces DefaultTemplate and returns the one it replaces.
+ */
+func SetTemplate(tmpl *template.Template) *template.Template {
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	old := DefaultTemplate
+	DefaultTemplate = tmpl
+	return old
+}
+
+/**
+ * reloadTemplate re-reads the template from ARTICLE_TEMPLATE_PATH (or the built-in string).
+ *
+ * Unlike LoadTemplate, a parse failure keeps the template currently in use, so an
+ * operator mistake while editing the file doesn't degrade a running service.
+ */
+func reloadTemplate() {
+	path := os.Getenv("ARTICLE_TEMPLATE_PATH")
+	tmpl, err := parseTemplate(path)
+	if err != nil {
+		log.Printf("error reloading template from ARTICLE_TEMPLATE_PATH %q, keeping current: %v", path, err)
+		return
+	}
+	SetTemplate(tmpl)
+	log.Printf("reloaded HTML template from %q", cmp.Or(path, "built-in"))
+}
+
+/**
+ * init installs a SIGHUP handler that hot-reloads the HTML template,
+ * letting operators tweak it without restarting the service.
+ */
+func init() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadTemplate()
+		}
+	}()
+}
+
+/**
+ * ContentSecurityPolicy builds the Content-Security-Policy header value.
+ *
+ * Inline scripts and styles are only allowed when they carry nonce. Formatters
+ * that need extra script hosts (e.g. a presentation framework) pass them in
+ * scriptSources and override the header set by the api security headers middleware.
+ */
+func ContentSecurityPolicy(nonce string, scriptSources ...string) string {
+	scriptSrc := append([]string{"'self'", "https://bookmarklet-theme.vercel.app"}, scriptSources...)
+	styleSrc := []string{"'self'", "https://unpkg.com"}
+	if nonce != "" {
+		scriptSrc = append(scriptSrc, "'nonce-"+nonce+"'")
+		styleSrc = append(styleSrc, "'nonce-"+nonce+"'")
+	}
+	return fmt.Sprintf("default-src 'self'; script-src %s; style-src %s; img-src 'self' data:;", strings.Join(scriptSrc, " "), strings.Join(styleSrc, " "))
+}
+
+/**
+ * ThemeEntry describes a stylesheet the HTML output can be rendered with.
+ */
+type ThemeEntry struct {
+	Name string `json:"name"`
+	// PreviewURL is the CDN URL of the stylesheet (empty for "none").
+	PreviewURL string `json:"preview_url"`
+}
+
+// DefaultTheme is used when the request doesn't pick a theme.
+const DefaultTheme = "sakura"
+
+/**
+ * themes lists the built-in stylesheets, selectable with `?theme=name`.
+ *
+ * All of them are classless, so they style the plain article markup as is.
+ * New CDN hosts must also be allowed in the style-src of the api security headers middleware.
+ */
+var themes = []ThemeEntry{
+	{Name: "sakura", PreviewURL: "https://unpkg.com/sakura.css/css/sakura.css"},
+	{Name: "dark", PreviewURL: "https://unpkg.com/sakura.css/css/sakura-dark.css"},
+	{Name: "water", PreviewURL: "https://unpkg.com/water.css@2/out/water.css"},
+	{Name: "classless", PreviewURL: "https://unpkg.com/@picocss/pico@2/css/pico.classless.min.css"},
+	{Name: "none", PreviewURL: ""},
+}
+
+/**
+ * AvailableThemes returns the built-in themes in display order.
+ */
+func AvailableThemes() []ThemeEntry {
+	return slices.Clone(themes)
+}
+
+/**
+ * LookupTheme returns the built-in theme with the given name.
+ */
+func LookupTheme(name string) (ThemeEntry, bool) {
+	i := slices.IndexFunc(themes, func(t ThemeEntry) bool { return t.Name == name })
+	if i < 0 {
+		return ThemeEntry{}, false
+	}
+	return themes[i], true
+}
+
+/**
+ * typography holds the validated base font settings requested by the client.
+ * Zero values mean "keep the theme default".
+ */
+type typography struct {
+	FontSize   int
+	LineHeight float64
+}
+
+/**
+ * ParseTypography validates the `font-size` and `line-height` query parameters.
+ *
+ * Values are parsed as numbers and checked against strict ranges, so nothing
+ * other than the two CSS properties can ever reach the injected style block.
+ * Returns nil when neither parameter is present.
+ */
+func ParseTypography(q url.Values) (*typography, error) {
+	var t typography
+	if raw := q.Get("font-size"); raw != "" {
+		size, err := strconv.Atoi(strings.TrimSuffix(raw, "px"))
+		if err != nil || size < minFontSize || size > maxFontSize {
+			return nil, fmt.Errorf("font-size must be an integer between %d and %d", minFontSize, maxFontSize)
+		}
+		t.FontSize = size
+	}
+	if raw := q.Get("line-height"); raw != "" {
+		height, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(height >= minLineHeight && height <= maxLineHeight) {
+			return nil, fmt.Errorf("line-height must be a number between %.1f and %.1f", minLineHeight, maxLineHeight)
+		}
+		t.LineHeight = height
+	}
+	if t == (typography{}) {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+/**
+ * templateData is the value passed to DefaultTemplate.
+ *
+ * Custom templates loaded from ARTICLE_TEMPLATE_PATH receive the same fields.
+ */
+type templateData struct {
+	Title      string
+	Content    template.HTML
+	ThemeURL   string
+	Typography *typography
+	Nonce      string
+	// WordCount and ReadingTimeMinutes let reader apps track progress (see stats.WordCount).
+	WordCount          int
+	ReadingTimeMinutes int
+	// CanonicalURL is the URL the article was fetched from, empty when unknown.
+	CanonicalURL string
+}
+
+/**
+ * formatHTML renders the article using the standard HTML template.
+ * This is the default view for human consumption.
+ */
+func formatHTML(w http.ResponseWriter, article readability.Article, contentBuf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := currentTemplate().Execute(w, newTemplateData(article, contentBuf, opts)); err != nil {
+		// at this point, we can't write a JSON error, so we log it
+		log.Printf("error executing HTML template: %v", err)
+	}
+}
+
+/**
+ * newTemplateData builds the template fields shared by the HTML based formats.
+ */
+func newTemplateData(article readability.Article, contentBuf *bytes.Buffer, opts Options) templateData {
+	// inject safe HTML content
+	data := templateData{
+		Title:      article.Title(),
+		Content:    template.HTML(contentBuf.String()),
+		ThemeURL:   opts.Theme.PreviewURL,
+		Typography: opts.Typography,
+		Nonce:      opts.Nonce,
+	}
+	if article.Node != nil {
+		data.WordCount = stats.WordCount(article.Node)
+		data.ReadingTimeMinutes = stats.ReadingTimeMinutes(data.WordCount)
+	}
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		data.CanonicalURL = canonical.String()
+	}
+	return data
+}
+
+/**
+ * formatReader renders the HTML page as a single self-contained file for offline
+ * reading: the theme stylesheet is inlined (see InlineCSS) and the
+ * Content-Security-Policy travels inside the document as a <meta> tag.
+ *
+ * When the stylesheet can't be fetched, the page keeps linking to it.
+ */
+func formatReader(w http.ResponseWriter, art readability.Article, contentBuf *bytes.Buffer, opts Options) {
+	var page strings.Builder
+	if err := currentTemplate().Execute(&page, newTemplateData(art, contentBuf, opts)); err != nil {
+		log.Printf("error executing HTML template: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article page")
+		return
+	}
+	doc := page.String()
+	if opts.Theme.PreviewURL != "" {
+		inlined, err := InlineCSS(doc, opts.Theme.PreviewURL, article.HTTPClient)
+		if err != nil {
+			log.Printf("error inlining stylesheet %q: %v", opts.Theme.PreviewURL, err)
+		} else {
+			doc = inlined
+		}
+	}
+
+	policy := readerContentSecurityPolicy()
+	if head := strings.Index(doc, "<head>"); head >= 0 {
+		head += len("<head>")
+		doc = doc[:head] + "\n\t" + `<meta http-equiv="Content-Security-Policy" content="` + template.HTMLEscapeString(policy) + `">` + doc[head:]
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", policy)
+	SetContentDisposition(w, "reader", art.Title())
+	if _, err := io.WriteString(w, doc); err != nil {
+		log.Printf("error writing reader page: %v", err)
+	}
+}
+
+/**
+ * readerContentSecurityPolicy is the policy of offline reader pages.
+ *
+ * A saved file outlives the response nonce, so inline styles (the inlined theme
+ * and typography overrides) are allowed as a whole; scripts stay restricted.
+ */
+func readerContentSecurityPolicy() string {
+	return "default-src 'self'; script-src 'self' https://bookmarklet-theme.vercel.app; style-src 'self' https://unpkg.com 'unsafe-inline'; img-src 'self' data:;"
+}
+
+// linkTagPattern matches the <link> tags InlineCSS looks for the stylesheet in.
+var linkTagPattern = regexp.MustCompile(`(?i)<link\b[^>]*>`)
+
+/**
+ * InlineCSS replaces the <link> to the stylesheet at cssURL in the rendered page
+ * templateStr with a <style> element holding the stylesheet itself.
+ *
+ * The stylesheet is fetched with client, waiting at most cssFetchTimeout and
+ * reading at most maxCSSSize bytes. An error is returned when the page does not
+ * link to cssURL or the stylesheet can't be fetched.
+ */
+func InlineCSS(templateStr string, cssURL string, client *http.Client) (string, error) {
+	href := `href="` + template.HTMLEscapeString(cssURL) + `"`
+	links := linkTagPattern.FindAllStringIndex(templateStr, -1)
+	i := slices.IndexFunc(links, func(m []int) bool {
+		return strings.Contains(templateStr[m[0]:m[1]], href)
+	})
+	if i < 0 {
+		return "", fmt.Errorf("no <link> to %q found", cssURL)
+	}
+	tag := links[i]
+
+	ctx, cancel := context.WithTimeout(context.Background(), cssFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", cssURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/css,*/*;q=0.1")
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", res.Status)
+	}
+	css, err := io.ReadAll(http.MaxBytesReader(nil, res.Body, maxCSSSize))
+	if err != nil {
+		return "", err
+	}
+
+	// "</style" would end the element early; the CSS escape keeps the same meaning
+	style := "<style>" + strings.ReplaceAll(string(css), "</", `<\/`) + "</style>"
+	return templateStr[:tag[0]] + style + templateStr[tag[1]:], nil
+}
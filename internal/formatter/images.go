@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+const (
+	// embedded images (`?include-images-as-base64=true`) limits
+	maxEmbeddedImages = 10
+	MaxImageSize      = 500 << 10 // 500 KiB
+	imageFetchTimeout = 3 * time.Second
+)
+
+/**
+ * EmbedImages replaces the src of the first maxEmbeddedImages <img> elements with
+ * data URIs, so the article can be read offline.
+ *
+ * Images are fetched concurrently with client (which should be the SSRF-safe
+ * article.HTTPClient), each within imageFetchTimeout and MaxImageSize. Images that fail to
+ * download, are too large or are not images keep their original src. The srcset of
+ * embedded images is dropped so browsers do not fetch them anyway.
+ * The only error returned is the cancellation of ctx.
+ */
+func EmbedImages(ctx context.Context, node *html.Node, client *http.Client) error {
+	var imgs []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if len(imgs) == maxEmbeddedImages {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "img" {
+			if src, err := url.Parse(dom.Attr(n, "src")); err == nil && (src.Scheme == "http" || src.Scheme == "https") {
+				imgs = append(imgs, n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	dataURIs := make([]string, len(imgs))
+	var wg sync.WaitGroup
+	for i, img := range imgs {
+		wg.Go(func() {
+			uri, err := fetchImageDataURI(ctx, client, dom.Attr(img, "src"))
+			if err != nil {
+				log.Printf("warning: not embedding image %q: %v", dom.Attr(img, "src"), err)
+				return
+			}
+			dataURIs[i] = uri
+		})
+	}
+	wg.Wait()
+
+	for i, img := range imgs {
+		if dataURIs[i] == "" {
+			continue
+		}
+		img.Attr = slices.DeleteFunc(img.Attr, func(a html.Attribute) bool { return a.Key == "srcset" })
+		for j := range img.Attr {
+			if img.Attr[j].Key == "src" {
+				img.Attr[j].Val = dataURIs[i]
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+/**
+ * fetchImageDataURI downloads the image at src and returns it as a base64 data URI.
+ */
+func fetchImageDataURI(ctx context.Context, client *http.Client, src string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, imageFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", src, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", article.RandomUserAgent())
+	req.Header.Set("Accept", "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", res.Status)
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(nil, res.Body, MaxImageSize))
+	if err != nil {
+		return "", err
+	}
+	mimeType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mimeType, "image/") {
+		// servers often send images as application/octet-stream
+		mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("not an image (%s)", mimeType)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+/**
+ * onlyImages reports whether n holds images and nothing else but whitespace.
+ */
+func onlyImages(n *html.Node) bool {
+	found := false
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode && strings.TrimSpace(c.Data) == "":
+		case c.Type == html.ElementNode && (c.Data == "img" || c.Data == "picture"):
+			found = true
+		case c.Type == html.ElementNode && (c.Data == "a" || c.Data == "div" || c.Data == "span") && onlyImages(c):
+			found = true
+		default:
+			return false
+		}
+	}
+	return found
+}
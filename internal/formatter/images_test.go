@@ -0,0 +1,123 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+func TestEmbedImages(t *testing.T) {
+	srv := newImageServer(t, nil)
+	defer srv.Close()
+
+	doc, err := html.Parse(strings.NewReader(fmt.Sprintf(`<div>
+<img id="png" src="%[1]s/pixel.png" srcset="%[1]s/pixel.png 2x">
+<img id="sniffed" src="%[1]s/sniffed">
+<img id="huge" src="%[1]s/huge.png">
+<img id="html" src="%[1]s/page.html">
+<img id="missing" src="%[1]s/missing.png">
+<img id="data" src="data:image/gif;base64,R0lGODlhAQABAAAAACw=">
+</div>`, srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedImages(context.Background(), doc, srv.Client()); err != nil {
+		t.Fatalf("EmbedImages: %v", err)
+	}
+
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngPixel)
+	tests := map[string]string{
+		"png":     want,
+		"sniffed": want,
+		"huge":    srv.URL + "/huge.png",
+		"html":    srv.URL + "/page.html",
+		"missing": srv.URL + "/missing.png",
+		"data":    "data:image/gif;base64,R0lGODlhAQABAAAAACw=",
+	}
+	for id, expected := range tests {
+		img := findByID(doc, id)
+		if got := dom.Attr(img, "src"); got != expected {
+			t.Errorf("%s: src = %q; want %q", id, got, expected)
+		}
+	}
+	if srcset := dom.Attr(findByID(doc, "png"), "srcset"); srcset != "" {
+		t.Errorf("embedded image kept srcset %q", srcset)
+	}
+}
+
+func TestEmbedImagesLimit(t *testing.T) {
+	var hits atomic.Int32
+	srv := newImageServer(t, &hits)
+	defer srv.Close()
+
+	page := strings.Repeat(fmt.Sprintf(`<img src="%s/pixel.png">`, srv.URL), maxEmbeddedImages+5)
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedImages(context.Background(), doc, srv.Client()); err != nil {
+		t.Fatalf("EmbedImages: %v", err)
+	}
+	if got := hits.Load(); got != maxEmbeddedImages {
+		t.Errorf("fetched %d images; want %d", got, maxEmbeddedImages)
+	}
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), `src="data:image/png;base64,`); got != maxEmbeddedImages {
+		t.Errorf("embedded %d images; want %d", got, maxEmbeddedImages)
+	}
+}
+
+func findByID(n *html.Node, id string) *html.Node {
+	if n.Type == html.ElementNode && dom.Attr(n, "id") == id {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findByID(c, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// pngPixel is a 1x1 transparent PNG.
+var pngPixel, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=")
+
+func newImageServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			hits.Add(1)
+		}
+		var err error
+		switch r.URL.Path {
+		case "/pixel.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, err = w.Write(pngPixel)
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, err = w.Write(pngPixel)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, err = w.Write(append(pngPixel, make([]byte, MaxImageSize)...))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			_, err = w.Write([]byte("<html><body>not an image</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+		if err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
@@ -0,0 +1,27 @@
+package formatter
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatInstapaper(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post?a=1&b=2")
+	rec := httptest.NewRecorder()
+	formatInstapaper(rec, readability.Article{}, nil, Options{Link: link})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q; want text/html; charset=utf-8", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="reading-list.html"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	want := `<li><a href="https://example.com/post?a=1&amp;b=2">https://example.com/post?a=1&amp;b=2</a></li>`
+	if body := rec.Body.String(); strings.Count(body, "<li>") != 1 || !strings.Contains(body, want) {
+		t.Errorf("reading list = %s; want a single %s", body, want)
+	}
+}
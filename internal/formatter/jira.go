@@ -0,0 +1,205 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatJira renders the article as Jira wiki markup, for pasting into issue
+ * descriptions and comments.
+ *
+ * Headings become "h1." to "h6." lines, bold *text*, italic _text_, underline
+ * +text+, strikethrough -text- (Jira reads ~text~ as subscript), inline code
+ * {{text}}, links [text|url], images !url!, code blocks {code:lang}, quotes {quote},
+ * list items "*" or "#" (repeated for each nesting level) and tables ||header||
+ * and |cell| rows.
+ */
+func formatJira(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for jira: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		sb.WriteString("h1. " + jiraEscape(title) + "\n\n")
+	}
+	renderJira(&sb, doc, "")
+	if _, err := io.WriteString(w, strings.TrimSpace(NormalizeWhitespace(sb.String()))+"\n"); err != nil {
+		log.Printf("error writing jira response: %v", err)
+	}
+}
+
+// jiraEscaper backslash-escapes the characters Jira reads as markup.
+var jiraEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "+", `\+`, "~", `\~`, "^", `\^`,
+	"{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`, "!", `\!`)
+
+// jiraEscape escapes text for Jira wiki markup.
+func jiraEscape(text string) string {
+	return jiraEscaper.Replace(text)
+}
+
+/**
+ * renderJira writes n and its children to sb as Jira wiki markup. bullets holds
+ * the markers of the enclosing lists ("*#" for a numbered list in a bulleted one).
+ */
+func renderJira(sb *strings.Builder, n *html.Node, bullets string) {
+	switch n.Type {
+	case html.TextNode:
+		writeCollapsedText(sb, n.Data, jiraEscape)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderJira(sb, c, bullets)
+		}
+		return
+	}
+
+	children := func(sb *strings.Builder) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderJira(sb, c, bullets)
+		}
+	}
+	inline := func(open, close string) {
+		var inner strings.Builder
+		children(&inner)
+		writeWrapped(sb, inner.String(), open, close)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		var inner strings.Builder
+		children(&inner)
+		if text := strings.Join(strings.Fields(inner.String()), " "); text != "" {
+			sb.WriteString("\n\n" + n.Data + ". " + text + "\n\n")
+		}
+	case "b", "strong":
+		inline("*", "*")
+	case "i", "em":
+		inline("_", "_")
+	case "u", "ins":
+		inline("+", "+")
+	case "s", "del", "strike":
+		inline("-", "-")
+	case "sup":
+		inline("^", "^")
+	case "sub":
+		inline("~", "~")
+	case "code":
+		writeWrapped(sb, strings.NewReplacer("{", `\{`, "}", `\}`).Replace(dom.TextContent(n)), "{{", "}}")
+	case "pre":
+		open := "{code}"
+		if lang := codeLanguage(n); lang != "" {
+			open = "{code:" + lang + "}"
+		}
+		sb.WriteString("\n\n" + open + "\n" + strings.Trim(dom.TextContent(n), "\n") + "\n{code}\n\n")
+	case "a":
+		var inner strings.Builder
+		children(&inner)
+		href, err := url.Parse(strings.TrimSpace(dom.Attr(n, "href")))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+			sb.WriteString(inner.String())
+			return
+		}
+		if strings.TrimSpace(inner.String()) == "" {
+			writeWrapped(sb, inner.String()+href.String(), "[", "]")
+			return
+		}
+		writeWrapped(sb, inner.String(), "[", "|"+href.String()+"]")
+	case "img":
+		if src, err := url.Parse(dom.Attr(n, "src")); err == nil && (src.Scheme == "http" || src.Scheme == "https") {
+			sb.WriteString("!" + src.String() + "!")
+		}
+	case "br":
+		sb.WriteString("\n")
+	case "hr":
+		sb.WriteString("\n\n----\n\n")
+	case "ul", "ol":
+		marker := "*"
+		if n.Data == "ol" {
+			marker = "#"
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+			var inner strings.Builder
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				renderJira(&inner, gc, bullets+marker)
+			}
+			sb.WriteString("\n" + bullets + marker + " " + strings.TrimLeft(inner.String(), " \n"))
+		}
+		sb.WriteString("\n\n")
+	case "blockquote":
+		var inner strings.Builder
+		children(&inner)
+		sb.WriteString("\n\n{quote}\n" + strings.TrimSpace(NormalizeWhitespace(inner.String())) + "\n{quote}\n\n")
+	case "table":
+		sb.WriteString("\n\n")
+		writeJiraTable(sb, n)
+		sb.WriteString("\n\n")
+	case "p", "div", "section", "article", "figure":
+		// a blank line would end the list the paragraph is in
+		if bullets != "" {
+			sb.WriteString(" ")
+			children(sb)
+			sb.WriteString(" ")
+			return
+		}
+		sb.WriteString("\n\n")
+		children(sb)
+		sb.WriteString("\n\n")
+	default:
+		children(sb)
+	}
+}
+
+/**
+ * writeJiraTable writes the rows of table, with ||header|| cells for <th> and
+ * |cell| ones for <td>. Nested tables are flattened into their cell.
+ */
+func writeJiraTable(sb *strings.Builder, table *html.Node) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+			var row strings.Builder
+			delimiter := ""
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+					continue
+				}
+				delimiter = "|"
+				if cell.Data == "th" {
+					delimiter = "||"
+				}
+				var inner strings.Builder
+				renderJira(&inner, cell, "")
+				// an empty cell would merge with its neighbor
+				row.WriteString(delimiter + cmp.Or(strings.Join(strings.Fields(inner.String()), " "), " "))
+			}
+			if delimiter != "" {
+				sb.WriteString(row.String() + delimiter + "\n")
+			}
+		}
+	}
+	walk(table)
+}
@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatJiraGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/jira/*.html")
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no jira fixtures found (%v)", err)
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".html")
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatalf("failed to read input: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			formatJira(rec, readability.Article{}, bytes.NewBuffer(content), Options{})
+			if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			golden := strings.TrimSuffix(input, ".html") + ".golden"
+			got := rec.Body.Bytes()
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("formatJira() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/testutil"
+	"golang.org/x/net/html"
+)
+
+func TestFormatJSONExtended(t *testing.T) {
+	data, err := os.ReadFile("testdata/json_extended.html")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	article := testutil.ArticleFromFixture(t, "testdata/json_extended.html")
+	content := dom.FindElement(doc, "article")
+	var buf bytes.Buffer
+	if err := html.Render(&buf, content); err != nil {
+		t.Fatalf("failed to render content: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	formatJSONExtended(rec, article, &buf, Options{Link: testutil.FixtureBaseURL, Document: doc})
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, key := range []string{"title", "content", "excerpt", "content_hash", "canonical_url", "og_metadata", "jsonld", "toc", "images", "links", "quotes", "tables"} {
+		if value, found := got[key]; !found || value == nil {
+			t.Errorf("%s is missing or null", key)
+		}
+	}
+
+	var fields struct {
+		OG     map[string]string   `json:"og_metadata"`
+		JSONLD []map[string]any    `json:"jsonld"`
+		TOC    []meta.TOCEntry     `json:"toc"`
+		Images []meta.ContentImage `json:"images"`
+		Links  []meta.ContentLink  `json:"links"`
+		Quotes []meta.Quote        `json:"quotes"`
+		Tables int                 `json:"tables"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("unexpected JSON shape: %v", err)
+	}
+	if len(fields.OG) != 3 || fields.OG["og:image"] != "https://example.com/pool.jpg" {
+		t.Errorf("og_metadata = %v", fields.OG)
+	}
+	if len(fields.JSONLD) != 3 || fields.JSONLD[0]["@type"] != "Article" || fields.JSONLD[2]["name"] != "Shore" {
+		t.Errorf("jsonld = %v", fields.JSONLD)
+	}
+	if len(fields.TOC) != 2 || fields.TOC[1] != (meta.TOCEntry{Level: 2, Text: "Residents"}) {
+		t.Errorf("toc = %v", fields.TOC)
+	}
+	if len(fields.Images) != 1 || fields.Images[0] != (meta.ContentImage{URL: "https://example.com/crab.jpg", Alt: "A crab"}) {
+		t.Errorf("images = %v", fields.Images)
+	}
+	if len(fields.Links) != 1 || fields.Links[0] != (meta.ContentLink{URL: "https://example.com/anemones", Text: "anemones"}) {
+		t.Errorf("links = %v; want the fragment link left out", fields.Links)
+	}
+	if len(fields.Quotes) != 1 || fields.Quotes[0].Attribution != "Jacques Cousteau" {
+		t.Errorf("quotes = %v", fields.Quotes)
+	}
+	if fields.Tables != 1 {
+		t.Errorf("tables = %d; want 1", fields.Tables)
+	}
+}
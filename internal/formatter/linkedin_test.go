@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatLinkedIn(t *testing.T) {
+	const content = `<p>First <strong>bold claim</strong> and a <a href="https://example.com/ref">reference</a>.</p>
+<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>
+<ol><li>Step</li></ol>
+<p><em>Plain</em> # not a heading</p>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td><b>2</b></td></tr></table>`
+	link, _ := url.Parse("https://example.com/post?utm=1")
+	canonical, _ := url.Parse("https://example.com/post")
+	rec := httptest.NewRecorder()
+	formatLinkedIn(rec, readability.Article{}, bytes.NewBufferString(content), Options{Link: link, Canonical: canonical})
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q; want text/plain", ct)
+	}
+	want := strings.Join([]string{
+		"First ** bold claim ** and a reference (https://example.com/ref).",
+		"",
+		"• One",
+		"• Two",
+		"    • Nested",
+		"",
+		"1. Step",
+		"",
+		"Plain # not a heading",
+		"",
+		"A | B",
+		"1 | ** 2 **",
+		"",
+		"Originally published at https://example.com/post",
+	}, "\n") + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatLinkedIn() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatLinkedInTitle(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/title_only.html")
+	rec := httptest.NewRecorder()
+	formatLinkedIn(rec, article, bytes.NewBufferString("<p>Body</p>"), Options{})
+	if got := rec.Body.String(); got != "WHY GO WINS\n\nBody\n" {
+		t.Errorf("formatLinkedIn() = %q; want the title in capitals and no footer", got)
+	}
+}
@@ -0,0 +1,132 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/mattn/godown"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/**
+ * formatMarkdown converts the article content to Markdown.
+ * Useful for LLMs or note-taking applications. With `?frontmatter=true` it
+ * is formatMarkdownFrontmatter.
+ */
+func formatMarkdown(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	if opts.Frontmatter {
+		formatMarkdownFrontmatter(w, article, buf, opts)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown")
+	if err := godown.Convert(w, buf, nil); err != nil {
+		log.Printf("error converting to markdown: %v", err)
+	}
+}
+
+/**
+ * formatMarkdownFrontmatter returns the Markdown output preceded by a YAML
+ * frontmatter block, as static site generators (Hugo, Jekyll, Gatsby) expect:
+ *
+ *	---
+ *	title: "..."
+ *	author: "..."
+ *	date: "2024-05-01T10:00:00Z"
+ *	tags: ["go", "web"]
+ *	canonical_url: "..."
+ *	source: "..."
+ *	---
+ *
+ * source is the URL the article was requested with, which canonical_url may differ
+ * from. The author, date, canonical_url and source fields are left out when unknown. Tags come
+ * from the page keywords (see meta.ExtractKeywords). Values are written as JSON strings,
+ * which are valid YAML.
+ */
+func formatMarkdownFrontmatter(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/markdown")
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.WriteString("title: " + yamlString(strings.Join(strings.Fields(article.Title()), " ")) + "\n")
+	if author := strings.TrimSpace(article.Byline()); author != "" {
+		sb.WriteString("author: " + yamlString(author) + "\n")
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		sb.WriteString("date: " + yamlString(published.Format(time.RFC3339)) + "\n")
+	}
+	tags := make([]string, 0)
+	for _, tag := range meta.ExtractKeywords(opts.Document) {
+		tags = append(tags, yamlString(tag))
+	}
+	sb.WriteString("tags: [" + strings.Join(tags, ", ") + "]\n")
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		sb.WriteString("canonical_url: " + yamlString(canonical.String()) + "\n")
+	}
+	if opts.Link != nil {
+		sb.WriteString("source: " + yamlString(opts.Link.String()) + "\n")
+	}
+	sb.WriteString("---\n\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing frontmatter: %v", err)
+		return
+	}
+	if err := godown.Convert(w, buf, nil); err != nil {
+		log.Printf("error converting to markdown: %v", err)
+	}
+}
+
+/**
+ * formatBearBlog returns the Markdown output as Bear Blog (bearblog.dev) expects
+ * a post: no frontmatter, the title as the first and only "# " heading, then the
+ * content. Headings of the content are demoted by one level so none competes
+ * with the title.
+ */
+func formatBearBlog(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/markdown")
+	doc := dom.ParseFragment(buf.String())
+	var demote func(n *html.Node)
+	demote = func(n *html.Node) {
+		if n.Type == html.ElementNode && len(n.Data) == 2 && n.Data[0] == 'h' && n.Data[1] >= '1' && n.Data[1] <= '5' {
+			n.Data = "h" + string(n.Data[1]+1)
+			n.DataAtom = atom.Lookup([]byte(n.Data))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			demote(c)
+		}
+	}
+	demote(doc)
+	var content bytes.Buffer
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&content, c); err != nil {
+			log.Printf("error rendering content for bear blog: %v", err)
+			response.Error(w, http.StatusInternalServerError, "failed to render article content")
+			return
+		}
+	}
+
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		if _, err := io.WriteString(w, "# "+title+"\n\n"); err != nil {
+			log.Printf("error writing bear blog title: %v", err)
+			return
+		}
+	}
+	if err := godown.Convert(w, &content, nil); err != nil {
+		log.Printf("error converting to markdown: %v", err)
+	}
+}
+
+// yamlString quotes s as a double-quoted YAML scalar, by way of JSON.
+func yamlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
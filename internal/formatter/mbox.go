@@ -0,0 +1,80 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+// mailDomain is the domain of the addresses of the messages formatMbox writes,
+// reserved so they can't reach anyone.
+const mailDomain = "articleparser.invalid"
+
+/**
+ * formatMbox returns the article as an email message (RFC 2822), for archiving
+ * articles in a mailbox: the article HTML is the quoted-printable body, the title
+ * the subject and the publication date (or now) the date. The sender is named
+ * after the author or the site, and the message is addressed to no one.
+ */
+func formatMbox(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	var link, host string
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		link, host = canonical.String(), strings.TrimPrefix(canonical.Hostname(), "www.")
+	}
+	title := strings.Join(strings.Fields(article.Title()), " ")
+	date, err := article.PublishedTime()
+	if err != nil {
+		date = time.Now()
+	}
+	from := mail.Address{Name: cmp.Or(strings.TrimSpace(article.Byline()), article.SiteName(), host), Address: "noreply@" + mailDomain}
+	sum := sha256.Sum256([]byte(link + "\x00" + title))
+
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		msg.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", "undisclosed-recipients:;")
+	header("Subject", mime.QEncoding.Encode("utf-8", cmp.Or(title, link)))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(sum[:16])+"@"+mailDomain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/html; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&msg)
+	page := "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(title) + "</title></head><body>\n"
+	if title != "" {
+		page += "<h1>" + html.EscapeString(title) + "</h1>\n"
+	}
+	page += buf.String()
+	if link != "" {
+		page += "\n<p><a href=\"" + html.EscapeString(link) + "\">" + html.EscapeString(link) + "</a></p>"
+	}
+	page += "\n</body></html>\n"
+	if _, err := io.WriteString(body, page); err != nil {
+		log.Printf("error encoding message body: %v", err)
+	}
+	if err := body.Close(); err != nil {
+		log.Printf("error encoding message body: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "message/rfc822")
+	SetContentDisposition(w, "eml", article.Title())
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		log.Printf("error writing message response: %v", err)
+	}
+}
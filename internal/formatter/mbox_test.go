@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatMbox(t *testing.T) {
+	link, _ := url.Parse("https://www.example.com/post")
+	content := `<p>Café = ` + strings.Repeat("long line ", 20) + `</p>`
+	rec := httptest.NewRecorder()
+	formatMbox(rec, readability.Article{}, bytes.NewBufferString(content), Options{Link: link})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "message/rfc822" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.eml"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	raw := rec.Body.String()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("invalid message: %v\n%s", err, raw)
+	}
+	for _, name := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version"} {
+		if msg.Header.Get(name) == "" {
+			t.Errorf("header %s missing", name)
+		}
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || len(from) != 1 || from[0].Name != "example.com" {
+		t.Errorf("From = %v (%v)", from, err)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("invalid Date: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "https://www.example.com/post" {
+		t.Errorf("Subject = %q; want the URL of an untitled article", subject)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("message Content-Type = %q", ct)
+	}
+	if cte := msg.Header.Get("Content-Transfer-Encoding"); cte != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", cte)
+	}
+
+	encoded, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if !bytes.Contains(encoded, []byte("Caf=C3=A9 =3D")) {
+		t.Errorf("body is not quoted-printable: %s", encoded)
+	}
+	for line := range strings.SplitSeq(string(encoded), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("encoded line longer than 76 characters: %q", line)
+		}
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(encoded)))
+	if err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if !strings.Contains(string(body), content) || !strings.Contains(string(body), `<a href="https://www.example.com/post">`) {
+		t.Errorf("decoded body = %s", body)
+	}
+}
+
+func TestFormatMboxEncodesSubject(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/mbox_subject.html")
+	rec := httptest.NewRecorder()
+	formatMbox(rec, article, testutil.MustRenderHTML(t, article), Options{})
+
+	msg, err := mail.ReadMessage(rec.Body)
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if raw := msg.Header.Get("Subject"); !strings.HasPrefix(raw, "=?utf-8?q?") {
+		t.Errorf("Subject = %q; want an RFC 2047 encoded word", raw)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "Olá, mundo" {
+		t.Errorf("decoded Subject = %q (%v)", subject, err)
+	}
+}
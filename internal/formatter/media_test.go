@@ -0,0 +1,24 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/meta"
+)
+
+func TestFormatAudioMeta(t *testing.T) {
+	rec := httptest.NewRecorder()
+	formatAudioMeta(rec, readability.Article{}, bytes.NewBufferString("<p>No media here</p>"), Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	var media []meta.MediaElement
+	if err := json.Unmarshal(rec.Body.Bytes(), &media); err != nil || media == nil || len(media) != 0 {
+		t.Errorf("body = %s; want an empty JSON array", rec.Body.String())
+	}
+}
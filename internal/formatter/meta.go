@@ -0,0 +1,173 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatBibTeX returns the citation of the article as a BibTeX entry (see meta.BuildBibTeX).
+ */
+func formatBibTeX(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/x-bibtex; charset=utf-8")
+	SetContentDisposition(w, "bibtex", article.Title())
+	if _, err := io.WriteString(w, meta.BuildBibTeX(meta.NewArticleMeta(article, cmp.Or(opts.Canonical, opts.Link)))); err != nil {
+		log.Printf("error writing bibtex response: %v", err)
+	}
+}
+
+/**
+ * formatRIS returns the citation of the article as a RIS record (see meta.BuildRIS),
+ * which Zotero, Mendeley and EndNote import.
+ */
+func formatRIS(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/x-research-info-systems; charset=utf-8")
+	SetContentDisposition(w, "ris", article.Title())
+	if _, err := io.WriteString(w, meta.BuildRIS(meta.NewArticleMeta(article, cmp.Or(opts.Canonical, opts.Link)))); err != nil {
+		log.Printf("error writing ris response: %v", err)
+	}
+}
+
+// maxReadwiseHighlights caps the rows of the readwise output.
+const maxReadwiseHighlights = 50
+
+/**
+ * formatReadwise returns the paragraphs of the article as a Readwise highlight
+ * import CSV, one highlight per <p> (up to maxReadwiseHighlights), with the
+ * article title, author and canonical URL on every row. Location is the position
+ * of the paragraph in the article.
+ */
+func formatReadwise(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for readwise: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
+		return
+	}
+	var link string
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		link = canonical.String()
+	}
+	title := strings.Join(strings.Fields(article.Title()), " ")
+	author := strings.TrimSpace(article.Byline())
+
+	rows := [][]string{{"Highlight", "Title", "Author", "URL", "Note", "Location"}}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if len(rows) > maxReadwiseHighlights {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "p" {
+			if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+				rows = append(rows, []string{text, title, author, link, "", strconv.Itoa(len(rows))})
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	SetContentDisposition(w, "readwise", article.Title())
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		log.Printf("error writing readwise csv: %v", err)
+	}
+}
+
+/**
+ * formatAnki returns the flashcards of the article (see meta.ExtractFlashcards) as a
+ * tab separated file for Anki's "Import File", one Front/Back note per line. The
+ * header lines tell Anki the separator and that fields hold HTML.
+ */
+func formatAnki(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer, _ Options) {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for anki: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	var sb strings.Builder
+	sb.WriteString("#separator:tab\n#html:true\n#columns:Front\tBack\n")
+	for _, card := range meta.ExtractFlashcards(doc) {
+		sb.WriteString(card.Front + "\t" + card.Back + "\n")
+	}
+	w.Header().Set("Content-Type", "text/tab-separated-values; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="flashcards.txt"`)
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing anki flashcards: %v", err)
+	}
+}
+
+/**
+ * formatQuotes returns every quotation in the article as a JSON array.
+ * Useful for qualitative research that needs the quoted material only.
+ */
+func formatQuotes(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for quotes: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	if err := json.NewEncoder(w).Encode(meta.ExtractQuotes(doc)); err != nil {
+		log.Printf("error encoding quotes: %v", err)
+	}
+}
+
+/**
+ * formatGraph returns the co-occurrence graph of the entities named in the
+ * article (see meta.BuildCooccurrenceGraph) as JSON, for knowledge management tools.
+ */
+func formatGraph(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/json")
+	var sb strings.Builder
+	if err := article.RenderText(&sb); err != nil {
+		log.Printf("error rendering text for graph: %v", err)
+	}
+	if err := json.NewEncoder(w).Encode(meta.BuildCooccurrenceGraph(sb.String())); err != nil {
+		log.Printf("error encoding graph: %v", err)
+	}
+}
+
+/**
+ * formatAudioMeta returns the audio and video files embedded in the article
+ * (see meta.ExtractMediaElements) as a JSON array. Useful for podcast show notes.
+ */
+func formatAudioMeta(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for media: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	if err := json.NewEncoder(w).Encode(meta.ExtractMediaElements(doc, opts.Link)); err != nil {
+		log.Printf("error encoding media: %v", err)
+	}
+}
+
+/**
+ * formatMF2 writes the Microformats2 items (h-entry, h-card, h-feed...) of the
+ * original page as JSON, as parsed by meta.ParseMF2. Pages without microformats yield
+ * an empty object.
+ */
+func formatMF2(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	// readability drops the class attributes, so prefer the page as fetched
+	if err := json.NewEncoder(w).Encode(meta.ParseMF2(cmp.Or(opts.Document, article.Node), opts.Link)); err != nil {
+		log.Printf("error encoding microformats: %v", err)
+	}
+}
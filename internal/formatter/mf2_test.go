@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatMF2(t *testing.T) {
+	tests := []struct {
+		name, page, want string
+	}{
+		{"no microformats", `<html><head><link rel="me" href="/about"></head><body><p class="entry">Plain</p></body></html>`, "{}\n"},
+		{"card", `<p class="h-card">Carol</p>`, `"type":["h-card"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("failed to parse page: %v", err)
+			}
+			rec := httptest.NewRecorder()
+			formatMF2(rec, readability.Article{}, &bytes.Buffer{}, Options{Document: doc})
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if got := rec.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("formatMF2() = %q; want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * MicropubEntry is the JSON body of a Micropub create request for an h-entry.
+ */
+type MicropubEntry struct {
+	Type       []string           `json:"type"`
+	Properties MicropubProperties `json:"properties"`
+}
+
+// MicropubProperties are the h-entry properties of a MicropubEntry, each a list of values.
+type MicropubProperties struct {
+	Name       []string          `json:"name,omitempty"`
+	Content    []MicropubContent `json:"content"`
+	BookmarkOf []string          `json:"bookmark-of,omitempty"`
+	Published  []string          `json:"published,omitempty"`
+	Category   []string          `json:"category,omitempty"`
+}
+
+// MicropubContent is an HTML content value.
+type MicropubContent struct {
+	HTML string `json:"html"`
+}
+
+/**
+ * formatMicropub returns a Micropub create request posting the article as an
+ * h-entry bookmarking its canonical URL. Syndication targets (mp-syndicate-to)
+ * depend on the endpoint, so they are left for the client to add.
+ */
+func formatMicropub(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	content := dom.ParseFragment(buf.String())
+	dom.Sanitize(content)
+	var sb strings.Builder
+	for c := content.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&sb, c); err != nil {
+			log.Printf("error rendering content for micropub: %v", err)
+			response.Error(w, http.StatusInternalServerError, "failed to render article content")
+			return
+		}
+	}
+
+	entry := MicropubEntry{Type: []string{"h-entry"}}
+	entry.Properties.Content = []MicropubContent{{HTML: strings.TrimSpace(sb.String())}}
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		entry.Properties.Name = []string{title}
+	}
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		entry.Properties.BookmarkOf = []string{link.String()}
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		entry.Properties.Published = []string{published.Format(time.RFC3339)}
+	}
+	entry.Properties.Category = meta.ExtractKeywords(opts.Document)
+	if err := json.NewEncoder(w).Encode(entry); err != nil {
+		log.Printf("error encoding micropub entry: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatMicropub(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/title_only.html")
+	input := `<p onclick="steal()" style="color:red" class="lead">Hello <a href="javascript:alert(1)">bad</a> <a href="https://example.com/x">good</a></p><script>alert(1)</script><!-- note --><iframe src="https://ads.example"></iframe><img src="https://example.com/a.png" alt="A">`
+	link, _ := url.Parse("https://example.com/post?utm_source=x")
+	canonical, _ := url.Parse("https://example.com/post")
+	rec := httptest.NewRecorder()
+	formatMicropub(rec, article, bytes.NewBufferString(input), Options{Link: link, Canonical: canonical})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var entry MicropubEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !slices.Equal(entry.Type, []string{"h-entry"}) {
+		t.Errorf("type = %q", entry.Type)
+	}
+	props := entry.Properties
+	if !slices.Equal(props.Name, []string{"Why Go Wins"}) {
+		t.Errorf("name = %q", props.Name)
+	}
+	if !slices.Equal(props.BookmarkOf, []string{"https://example.com/post"}) {
+		t.Errorf("bookmark-of = %q; want the canonical URL", props.BookmarkOf)
+	}
+	if len(props.Content) != 1 {
+		t.Fatalf("content = %+v", props.Content)
+	}
+	content := props.Content[0].HTML
+	want := `<p class="lead">Hello <a>bad</a> <a href="https://example.com/x">good</a></p><img src="https://example.com/a.png" alt="A"/>`
+	if content != want {
+		t.Errorf("content.html = %q; want %q", content, want)
+	}
+	for _, unsafe := range []string{"script", "onclick", "javascript:", "iframe", "<!--", "style="} {
+		if strings.Contains(content, unsafe) {
+			t.Errorf("content.html contains %q", unsafe)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatSlackMrkdwn renders the article as Slack mrkdwn, for sharing in Slack messages.
+ *
+ * Slack only understands a small subset of Markdown: *bold*, _italic_, ~strike~,
+ * `code`, ``` blocks, <url|text> links and > quotes. Headings become bold lines,
+ * list items "• item" or "1. item", table rows their cells separated by " | ", and
+ * &, < and > are escaped as Slack requires.
+ */
+func formatSlackMrkdwn(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for mrkdwn: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := article.Title(); title != "" {
+		sb.WriteString("*" + mrkdwnEscape(title) + "*\n\n")
+	}
+	renderMrkdwn(&sb, doc, "")
+	if _, err := io.WriteString(w, strings.TrimSpace(NormalizeWhitespace(sb.String()))+"\n"); err != nil {
+		log.Printf("error writing mrkdwn response: %v", err)
+	}
+}
+
+// mrkdwnEscaper escapes the characters Slack reserves for its control sequences.
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// mrkdwnEscape escapes text for Slack mrkdwn.
+func mrkdwnEscape(text string) string {
+	return mrkdwnEscaper.Replace(text)
+}
+
+/**
+ * renderMrkdwn writes n and its children to sb as Slack mrkdwn. List items are
+ * prefixed with indent, which grows with each nested list.
+ */
+func renderMrkdwn(sb *strings.Builder, n *html.Node, indent string) {
+	switch n.Type {
+	case html.TextNode:
+		writeCollapsedText(sb, n.Data, mrkdwnEscape)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderMrkdwn(sb, c, indent)
+		}
+		return
+	}
+
+	children := func(sb *strings.Builder) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderMrkdwn(sb, c, indent)
+		}
+	}
+	inline := func(marker string) {
+		var inner strings.Builder
+		children(&inner)
+		writeWrapped(sb, inner.String(), marker, marker)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+			sb.WriteString("\n\n*" + mrkdwnEscape(text) + "*\n\n")
+		}
+	case "b", "strong":
+		inline("*")
+	case "i", "em":
+		inline("_")
+	case "s", "del", "strike":
+		inline("~")
+	case "code":
+		writeWrapped(sb, mrkdwnEscape(dom.TextContent(n)), "`", "`")
+	case "pre":
+		sb.WriteString("\n\n```\n" + mrkdwnEscape(strings.Trim(dom.TextContent(n), "\n")) + "\n```\n\n")
+	case "a":
+		var inner strings.Builder
+		children(&inner)
+		href, err := url.Parse(dom.Attr(n, "href"))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+			sb.WriteString(inner.String())
+			return
+		}
+		text := strings.TrimSpace(inner.String())
+		link := mrkdwnEscape(href.String())
+		if text == link {
+			writeWrapped(sb, inner.String(), "<", ">")
+		} else {
+			// "|" would end the link text early
+			writeWrapped(sb, strings.ReplaceAll(inner.String(), "|", "¦"), "<"+link+"|", ">")
+		}
+	case "img":
+		if src, err := url.Parse(dom.Attr(n, "src")); err == nil && (src.Scheme == "http" || src.Scheme == "https") {
+			sb.WriteString("<" + mrkdwnEscape(src.String()) + "|" + mrkdwnEscape(cmp.Or(strings.TrimSpace(dom.Attr(n, "alt")), "image")) + ">")
+		}
+	case "br":
+		sb.WriteString("\n")
+	case "ul", "ol":
+		number := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				renderMrkdwn(sb, c, indent)
+				continue
+			}
+			bullet := "•"
+			if n.Data == "ol" {
+				number++
+				bullet = strconv.Itoa(number) + "."
+			}
+			sb.WriteString("\n" + indent + bullet + " ")
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				renderMrkdwn(sb, gc, indent+"    ")
+			}
+		}
+		sb.WriteString("\n\n")
+	case "blockquote":
+		var inner strings.Builder
+		children(&inner)
+		sb.WriteString("\n\n" + quoteLines(inner.String()) + "\n\n")
+	case "tr":
+		sb.WriteString("\n" + tableRow(n, " | ", func(sb *strings.Builder, n *html.Node) { renderMrkdwn(sb, n, indent) }))
+	case "p", "div", "section", "article", "table", "figure", "hr":
+		sb.WriteString("\n\n")
+		children(sb)
+		sb.WriteString("\n\n")
+	default:
+		children(sb)
+	}
+}
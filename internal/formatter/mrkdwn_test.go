@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatSlackMrkdwn(t *testing.T) {
+	buf := bytes.NewBufferString(`<h2>Section <em>one</em></h2>
+<p>Some <strong>bold </strong>and <em>italic</em> text, <del>gone</del>, <code>x := 1</code> and a <a href="https://example.com/a?b=1&amp;c=2">link | here</a>.</p>
+<p>Bare <a href="https://example.com">https://example.com</a>, <a href="#top">anchor</a> and 1 &lt; 2 &amp; 3.</p>
+<pre>if a &lt; b {
+	return
+}</pre>
+<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+<ol><li>first</li><li>second</li></ol>
+<blockquote><p>Quoted</p><p>twice</p></blockquote>
+<table><thead><tr><th>A</th><th>B</th></tr></thead><tbody><tr><td>1</td><td><em>2</em></td></tr></tbody></table>`)
+	rec := httptest.NewRecorder()
+	formatSlackMrkdwn(rec, readability.Article{}, buf, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q; want text/plain; charset=utf-8", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"*Section one*\n",
+		"Some *bold* and _italic_ text, ~gone~, `x := 1` and a <https://example.com/a?b=1&amp;c=2|link ¦ here>.",
+		"Bare <https://example.com>, anchor and 1 &lt; 2 &amp; 3.",
+		"```\nif a &lt; b {\n\treturn\n}\n```",
+		"• one\n• two\n    1. nested",
+		"1. first\n2. second",
+		"> Quoted\n>\n> twice",
+		"A | B\n1 | _2_",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("formatSlackMrkdwn output missing %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * NotionBlock is a block of the Notion Block API, as accepted by
+ * `PATCH /v1/blocks/{id}/children`. Only the field named by Type is set.
+ */
+type NotionBlock struct {
+	Object           string            `json:"object"`
+	Type             string            `json:"type"`
+	Paragraph        *NotionTextBlock  `json:"paragraph,omitempty"`
+	Heading1         *NotionTextBlock  `json:"heading_1,omitempty"`
+	Heading2         *NotionTextBlock  `json:"heading_2,omitempty"`
+	Heading3         *NotionTextBlock  `json:"heading_3,omitempty"`
+	BulletedListItem *NotionTextBlock  `json:"bulleted_list_item,omitempty"`
+	NumberedListItem *NotionTextBlock  `json:"numbered_list_item,omitempty"`
+	Quote            *NotionTextBlock  `json:"quote,omitempty"`
+	Code             *NotionCodeBlock  `json:"code,omitempty"`
+	Image            *NotionImageBlock `json:"image,omitempty"`
+}
+
+// NotionTextBlock is the payload of text blocks (paragraphs, headings, list items, quotes).
+type NotionTextBlock struct {
+	RichText []NotionRichText `json:"rich_text"`
+}
+
+// NotionCodeBlock is the payload of a code block.
+type NotionCodeBlock struct {
+	RichText []NotionRichText `json:"rich_text"`
+	Language string           `json:"language"`
+}
+
+// NotionImageBlock is the payload of an image block pointing at an external URL.
+type NotionImageBlock struct {
+	Type     string           `json:"type"`
+	External NotionLink       `json:"external"`
+	Caption  []NotionRichText `json:"caption,omitempty"`
+}
+
+// NotionRichText is a run of text sharing the same annotations and link.
+type NotionRichText struct {
+	Type        string            `json:"type"`
+	Text        NotionTextContent `json:"text"`
+	Annotations NotionAnnotations `json:"annotations"`
+}
+
+// NotionTextContent is the content of a text rich text object.
+type NotionTextContent struct {
+	Content string      `json:"content"`
+	Link    *NotionLink `json:"link,omitempty"`
+}
+
+// NotionLink is a URL reference, used by links and external images.
+type NotionLink struct {
+	URL string `json:"url"`
+}
+
+// NotionAnnotations are the styles of a rich text object.
+type NotionAnnotations struct {
+	Bold          bool `json:"bold"`
+	Italic        bool `json:"italic"`
+	Strikethrough bool `json:"strikethrough"`
+	Underline     bool `json:"underline"`
+	Code          bool `json:"code"`
+}
+
+// imgSelector matches the images HTMLToNotionBlocks moves out of text blocks.
+var imgSelector = cascadia.MustCompile("img")
+
+// maxNotionTextLength is the longest content Notion accepts in a single rich text object.
+const maxNotionTextLength = 2000
+
+/**
+ * HTMLToNotionBlocks converts article HTML into Notion blocks.
+ *
+ * Headings map to heading_1 to heading_3 (deeper levels become heading_3),
+ * paragraphs to paragraph, <pre> to code, <img> to external image blocks,
+ * list items to bulleted_list_item or numbered_list_item and <blockquote> to quote.
+ * Notion blocks are flat here: nested lists and containers are flattened in document order.
+ */
+func HTMLToNotionBlocks(node *html.Node) []NotionBlock {
+	var blocks []NotionBlock
+	text := func(kind string, rich []NotionRichText) {
+		if len(rich) == 0 {
+			return
+		}
+		block := NotionBlock{Object: "block", Type: kind}
+		payload := &NotionTextBlock{RichText: rich}
+		switch kind {
+		case "paragraph":
+			block.Paragraph = payload
+		case "heading_1":
+			block.Heading1 = payload
+		case "heading_2":
+			block.Heading2 = payload
+		case "heading_3":
+			block.Heading3 = payload
+		case "bulleted_list_item":
+			block.BulletedListItem = payload
+		case "numbered_list_item":
+			block.NumberedListItem = payload
+		case "quote":
+			block.Quote = payload
+		}
+		blocks = append(blocks, block)
+	}
+	image := func(img *html.Node, caption []NotionRichText) {
+		if src, err := url.Parse(dom.Attr(img, "src")); err == nil && (src.Scheme == "http" || src.Scheme == "https") {
+			blocks = append(blocks, NotionBlock{Object: "block", Type: "image", Image: &NotionImageBlock{
+				Type: "external", External: NotionLink{URL: src.String()}, Caption: caption,
+			}})
+		}
+	}
+	// images inside text blocks cannot be inlined in Notion, so they follow the block
+	images := func(n *html.Node) {
+		for _, img := range cascadia.QueryAll(n, imgSelector) {
+			image(img, nil)
+		}
+	}
+
+	var walk func(n *html.Node, list string)
+	walk = func(n *html.Node, list string) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			// loose text and inline elements are gathered into a single paragraph
+			if c.Type == html.TextNode || (c.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.Data)) {
+				run := []*html.Node{c}
+				for c.NextSibling != nil && (c.NextSibling.Type == html.TextNode || (c.NextSibling.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.NextSibling.Data))) {
+					c = c.NextSibling
+					run = append(run, c)
+				}
+				text("paragraph", notionRichText(run...))
+				continue
+			}
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "script", "style", "noscript", "template":
+			case "h1":
+				text("heading_1", notionRichText(c))
+			case "h2":
+				text("heading_2", notionRichText(c))
+			case "h3", "h4", "h5", "h6":
+				text("heading_3", notionRichText(c))
+			case "p":
+				text("paragraph", notionRichText(c))
+				images(c)
+			case "blockquote":
+				text("quote", notionRichText(c))
+			case "pre":
+				language := cmp.Or(codeLanguage(c), "plain text")
+				content := strings.Trim(dom.TextContent(c), "\n")
+				blocks = append(blocks, NotionBlock{Object: "block", Type: "code", Code: &NotionCodeBlock{
+					RichText: splitNotionText(NotionRichText{Type: "text", Text: NotionTextContent{Content: content}}),
+					Language: language,
+				}})
+			case "img":
+				image(c, nil)
+			case "figure":
+				var caption []NotionRichText
+				if figcaption := dom.FindElement(c, "figcaption"); figcaption != nil {
+					caption = notionRichText(figcaption)
+				}
+				if img := dom.FindElement(c, "img"); img != nil {
+					image(img, caption)
+				} else {
+					walk(c, list)
+				}
+			case "ul":
+				walk(c, "bulleted_list_item")
+			case "ol":
+				walk(c, "numbered_list_item")
+			case "li":
+				text(cmp.Or(list, "bulleted_list_item"), notionRichText(c))
+				images(c)
+				// nested lists follow their parent item
+				for nested := c.FirstChild; nested != nil; nested = nested.NextSibling {
+					switch {
+					case nested.Type != html.ElementNode:
+					case nested.Data == "ul":
+						walk(nested, "bulleted_list_item")
+					case nested.Data == "ol":
+						walk(nested, "numbered_list_item")
+					}
+				}
+			default:
+				walk(c, list)
+			}
+		}
+	}
+	walk(node, "")
+	return blocks
+}
+
+/**
+ * notionRichText converts the inline content of nodes into rich text objects, merging
+ * runs with the same styles. Nested lists and images are skipped, as they become
+ * blocks of their own.
+ */
+func notionRichText(nodes ...*html.Node) []NotionRichText {
+	var rich []NotionRichText
+	add := func(content string, style NotionAnnotations, link string) {
+		if last := len(rich) - 1; last >= 0 && rich[last].Annotations == style && notionLinkURL(rich[last].Text.Link) == link {
+			rich[last].Text.Content += content
+			return
+		}
+		rt := NotionRichText{Type: "text", Text: NotionTextContent{Content: content}, Annotations: style}
+		if link != "" {
+			rt.Text.Link = &NotionLink{URL: link}
+		}
+		rich = append(rich, rt)
+	}
+	var walk func(n *html.Node, style NotionAnnotations, link string)
+	walk = func(n *html.Node, style NotionAnnotations, link string) {
+		if n.Type == html.TextNode {
+			content := strings.Join(strings.Fields(n.Data), " ")
+			if strings.TrimLeft(n.Data, " \t\n\r") != n.Data {
+				content = " " + content
+			}
+			if content != " " && strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+				content += " "
+			}
+			if content != "" {
+				add(content, style, link)
+			}
+			return
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "noscript", "template", "img", "ul", "ol":
+				return
+			case "b", "strong":
+				style.Bold = true
+			case "i", "em":
+				style.Italic = true
+			case "s", "del", "strike":
+				style.Strikethrough = true
+			case "u":
+				style.Underline = true
+			case "code":
+				style.Code = true
+			case "br":
+				add("\n", style, link)
+			case "a":
+				if href, err := url.Parse(dom.Attr(n, "href")); err == nil && (href.Scheme == "http" || href.Scheme == "https") {
+					link = href.String()
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, style, link)
+		}
+	}
+	for _, n := range nodes {
+		walk(n, NotionAnnotations{}, "")
+	}
+
+	// trim the edges, dropping runs left empty
+	for len(rich) > 0 {
+		if rich[0].Text.Content = strings.TrimLeft(rich[0].Text.Content, " "); rich[0].Text.Content != "" {
+			break
+		}
+		rich = rich[1:]
+	}
+	for len(rich) > 0 {
+		last := len(rich) - 1
+		if rich[last].Text.Content = strings.TrimRight(rich[last].Text.Content, " "); rich[last].Text.Content != "" {
+			break
+		}
+		rich = rich[:last]
+	}
+
+	var out []NotionRichText
+	for _, rt := range rich {
+		out = append(out, splitNotionText(rt)...)
+	}
+	return out
+}
+
+// notionLinkURL returns the URL of link, or "" when there is none.
+func notionLinkURL(link *NotionLink) string {
+	if link == nil {
+		return ""
+	}
+	return link.URL
+}
+
+/**
+ * splitNotionText splits rt into pieces of at most maxNotionTextLength characters.
+ */
+func splitNotionText(rt NotionRichText) []NotionRichText {
+	runes := []rune(rt.Text.Content)
+	if len(runes) <= maxNotionTextLength {
+		return []NotionRichText{rt}
+	}
+	var out []NotionRichText
+	for len(runes) > 0 {
+		n := min(len(runes), maxNotionTextLength)
+		piece := rt
+		piece.Text.Content = string(runes[:n])
+		out = append(out, piece)
+		runes = runes[n:]
+	}
+	return out
+}
+
+/**
+ * formatNotion returns the article as Notion blocks, in the body format of the
+ * Notion API's "append block children" endpoint: {"children": [...]}.
+ */
+func formatNotion(w http.ResponseWriter, _ readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for notion: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	blocks := HTMLToNotionBlocks(doc)
+	if blocks == nil {
+		blocks = []NotionBlock{}
+	}
+	if err := json.NewEncoder(w).Encode(map[string]any{"children": blocks}); err != nil {
+		log.Printf("error encoding notion blocks: %v", err)
+	}
+}
+
+// maxNotionPageChildren is how many blocks Notion accepts when creating a page.
+const maxNotionPageChildren = 100
+
+/**
+ * NotionIDPattern matches a Notion page id: a UUID, with or without dashes.
+ */
+var NotionIDPattern = regexp.MustCompile(`^(?i:[0-9a-f]{32}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+/**
+ * NotionPage is the body of a Notion "create a page" request.
+ */
+type NotionPage struct {
+	Parent struct {
+		PageID string `json:"page_id"`
+	} `json:"parent"`
+	Properties struct {
+		Title struct {
+			Title []NotionRichText `json:"title"`
+		} `json:"title"`
+	} `json:"properties"`
+	Children []NotionBlock `json:"children"`
+}
+
+/**
+ * FormatNotionPage returns a Notion "create a page" request body: the blocks of
+ * formatNotion under the page given by `?notion-parent-id=`, titled after the article.
+ *
+ * Notion only takes maxNotionPageChildren blocks on creation; longer articles are
+ * cut there and flagged with X-Notion-Truncated, the rest can be appended with the
+ * blocks of formatNotion.
+ */
+func FormatNotionPage(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	if opts.NotionParentID == "" {
+		response.Error(w, http.StatusBadRequest, "notion-page requires notion-parent-id")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for notion page: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	var page NotionPage
+	page.Parent.PageID = opts.NotionParentID
+	title := NotionRichText{Type: "text", Text: NotionTextContent{Content: cmp.Or(strings.TrimSpace(article.Title()), "Untitled")}}
+	page.Properties.Title.Title = splitNotionText(title)
+	page.Children = HTMLToNotionBlocks(doc)
+	if len(page.Children) > maxNotionPageChildren {
+		w.Header().Set("X-Notion-Truncated", "true")
+		page.Children = page.Children[:maxNotionPageChildren]
+	}
+	if page.Children == nil {
+		page.Children = []NotionBlock{}
+	}
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		log.Printf("error encoding notion page: %v", err)
+	}
+}
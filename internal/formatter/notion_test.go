@@ -0,0 +1,184 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func notionBlocks(t *testing.T, content string) []map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	formatNotion(rec, readability.Article{}, bytes.NewBufferString(content), Options{})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	var resp struct {
+		Children []map[string]any `json:"children"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	// every block follows the Block API shape: object, type and a payload keyed by type
+	for i, block := range resp.Children {
+		typ, _ := block["type"].(string)
+		if block["object"] != "block" || block[typ] == nil || len(block) != 3 {
+			t.Errorf("block %d does not match the Notion block schema: %v", i, block)
+		}
+	}
+	return resp.Children
+}
+
+func richText(block map[string]any) string {
+	payload := block[block["type"].(string)].(map[string]any)
+	var sb strings.Builder
+	for _, rt := range payload["rich_text"].([]any) {
+		rt := rt.(map[string]any)
+		if rt["type"] != "text" {
+			return "<invalid rich text>"
+		}
+		sb.WriteString(rt["text"].(map[string]any)["content"].(string))
+	}
+	return sb.String()
+}
+
+func TestFormatNotionBlockTypes(t *testing.T) {
+	blocks := notionBlocks(t, `<div>
+<h1>Title</h1><h2>Section</h2><h4>Deep</h4>
+<p>Some <b>bold</b> and <a href="https://example.com/">linked</a> text.</p>
+<ul><li>First</li><li>Second<ul><li>Nested</li></ul></li></ul>
+<ol><li>One</li></ol>
+<pre><code class="language-go">fmt.Println("hi")</code></pre>
+<blockquote>Quoted words</blockquote>
+<figure><img src="https://example.com/a.png"><figcaption>A caption</figcaption></figure>
+</div>`)
+
+	want := []struct{ typ, text string }{
+		{"heading_1", "Title"},
+		{"heading_2", "Section"},
+		{"heading_3", "Deep"},
+		{"paragraph", "Some bold and linked text."},
+		{"bulleted_list_item", "First"},
+		{"bulleted_list_item", "Second"},
+		{"bulleted_list_item", "Nested"},
+		{"numbered_list_item", "One"},
+		{"code", `fmt.Println("hi")`},
+		{"quote", "Quoted words"},
+		{"image", ""},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("got %d blocks; want %d: %v", len(blocks), len(want), blocks)
+	}
+	for i, w := range want {
+		if blocks[i]["type"] != w.typ {
+			t.Errorf("block %d type = %v; want %s", i, blocks[i]["type"], w.typ)
+			continue
+		}
+		if w.typ != "image" && richText(blocks[i]) != w.text {
+			t.Errorf("block %d text = %q; want %q", i, richText(blocks[i]), w.text)
+		}
+	}
+
+	para := blocks[3]["paragraph"].(map[string]any)["rich_text"].([]any)
+	bold := para[1].(map[string]any)
+	if bold["text"].(map[string]any)["content"] != "bold" || bold["annotations"].(map[string]any)["bold"] != true {
+		t.Errorf("bold run = %v", bold)
+	}
+	link := para[3].(map[string]any)["text"].(map[string]any)
+	if link["content"] != "linked" || link["link"].(map[string]any)["url"] != "https://example.com/" {
+		t.Errorf("link run = %v", link)
+	}
+
+	if lang := blocks[8]["code"].(map[string]any)["language"]; lang != "go" {
+		t.Errorf("code language = %v; want go", lang)
+	}
+	img := blocks[10]["image"].(map[string]any)
+	if img["type"] != "external" || img["external"].(map[string]any)["url"] != "https://example.com/a.png" {
+		t.Errorf("image block = %v", img)
+	}
+	if caption := img["caption"].([]any); len(caption) != 1 || caption[0].(map[string]any)["text"].(map[string]any)["content"] != "A caption" {
+		t.Errorf("image caption = %v", img["caption"])
+	}
+}
+
+func TestHTMLToNotionBlocksSplitsLongText(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<p>" + strings.Repeat("a", maxNotionTextLength+10) + "</p><pre>" + strings.Repeat("b", maxNotionTextLength*2) + "</pre>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := HTMLToNotionBlocks(doc)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks; want 2", len(blocks))
+	}
+	if rich := blocks[0].Paragraph.RichText; len(rich) != 2 || len(rich[0].Text.Content) != maxNotionTextLength {
+		t.Errorf("long paragraph not split at %d characters", maxNotionTextLength)
+	}
+	if rich := blocks[1].Code.RichText; len(rich) != 2 || blocks[1].Code.Language != "plain text" {
+		t.Errorf("long code block = %d pieces, language %q", len(rich), blocks[1].Code.Language)
+	}
+}
+
+func TestFormatNotionEmpty(t *testing.T) {
+	if blocks := notionBlocks(t, ""); len(blocks) != 0 {
+		t.Errorf("empty content produced blocks: %v", blocks)
+	}
+}
+
+func TestFormatNotionPage(t *testing.T) {
+	const parent = "0f5e4c1a-9d1b-4c3e-8a2f-1b2c3d4e5f60"
+	rec := httptest.NewRecorder()
+	FormatNotionPage(rec, readability.Article{}, bytes.NewBufferString("<h2>Intro</h2><p>Body</p>"), Options{NotionParentID: parent})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Parent struct {
+			PageID string `json:"page_id"`
+		} `json:"parent"`
+		Properties struct {
+			Title struct {
+				Title []struct {
+					Text struct {
+						Content string `json:"content"`
+					} `json:"text"`
+				} `json:"title"`
+			} `json:"title"`
+		} `json:"properties"`
+		Children []map[string]any `json:"children"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if page.Parent.PageID != parent {
+		t.Errorf("parent.page_id = %q; want %q", page.Parent.PageID, parent)
+	}
+	if title := page.Properties.Title.Title; len(title) != 1 || title[0].Text.Content != "Untitled" {
+		t.Errorf("properties.title = %+v", title)
+	}
+	if len(page.Children) != 2 || page.Children[0]["type"] != "heading_2" || page.Children[1]["type"] != "paragraph" {
+		t.Errorf("children = %v", page.Children)
+	}
+}
+
+func TestFormatNotionPageTruncates(t *testing.T) {
+	rec := httptest.NewRecorder()
+	content := strings.Repeat("<p>Paragraph</p>", maxNotionPageChildren+5)
+	FormatNotionPage(rec, readability.Article{}, bytes.NewBufferString(content), Options{NotionParentID: "0f5e4c1a9d1b4c3e8a2f1b2c3d4e5f60"})
+	if rec.Header().Get("X-Notion-Truncated") != "true" {
+		t.Error("X-Notion-Truncated not set")
+	}
+	var page NotionPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(page.Children) != maxNotionPageChildren {
+		t.Errorf("got %d children; want %d", len(page.Children), maxNotionPageChildren)
+	}
+}
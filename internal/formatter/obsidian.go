@@ -0,0 +1,94 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatObsidian returns the article as a note for Obsidian: GitHub Flavored
+ * Markdown (see formatGFM) under a YAML frontmatter with the note source, creation
+ * date and tags, plus its author and publication date when known.
+ *
+ * The article title is the only top level heading, so the headings of the content
+ * are shifted one level down, and `[[wikilinks]]` in the text are kept unescaped so
+ * they still link to other notes. Tags come from the page keywords, with spaces
+ * replaced by dashes as Obsidian tags can't contain them.
+ */
+func formatObsidian(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for obsidian: %v", err)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		sb.WriteString("source: " + yamlString(canonical.String()) + "\n")
+	}
+	if author := strings.TrimSpace(article.Byline()); author != "" {
+		sb.WriteString("author: " + yamlString(author) + "\n")
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		sb.WriteString("published: " + published.Format(time.DateOnly) + "\n")
+	}
+	sb.WriteString("created: " + time.Now().Format(time.DateOnly) + "\n")
+	keywords := meta.ExtractKeywords(opts.Document)
+	if len(keywords) == 0 {
+		sb.WriteString("tags: []\n")
+	} else {
+		sb.WriteString("tags:\n")
+		for _, keyword := range keywords {
+			sb.WriteString("  - " + yamlString(strings.Join(strings.Fields(strings.ReplaceAll(keyword, "#", "")), "-")) + "\n")
+		}
+	}
+	sb.WriteString("---\n\n")
+
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		sb.WriteString("# " + obsidianEscape(title) + "\n\n")
+	}
+	g := newGFMWriter(doc)
+	g.escape = obsidianEscape
+	g.headingShift = 1
+	g.document(&sb, doc)
+	if _, err := io.WriteString(w, strings.TrimRight(NormalizeWhitespace(sb.String()), "\n")+"\n"); err != nil {
+		log.Printf("error writing obsidian response: %v", err)
+	}
+}
+
+// wikilinkPattern matches an Obsidian [[wikilink]], with an optional |alias.
+var wikilinkPattern = regexp.MustCompile(`\[\[[^\[\]\n]+\]\]`)
+
+// obsidianEscape escapes text like gfmEscape, except for the [[wikilinks]] it holds.
+func obsidianEscape(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, match := range wikilinkPattern.FindAllStringIndex(text, -1) {
+		sb.WriteString(gfmEscape(text[last:match[0]]))
+		sb.WriteString(text[match[0]:match[1]])
+		last = match[1]
+	}
+	sb.WriteString(gfmEscape(text[last:]))
+	return sb.String()
+}
+
+// gfmLinkDestination writes a URL as a link destination, wrapping it in <> when it
+// contains spaces or parentheses.
+func gfmLinkDestination(href string) string {
+	if strings.ContainsAny(href, " ()") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(href) + ">"
+	}
+	return href
+}
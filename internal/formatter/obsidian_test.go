@@ -0,0 +1,45 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatObsidian(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head><meta name="keywords" content="Go, web dev, #notes"></head>`))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	link, _ := url.Parse("https://example.com/post")
+	input := `<h1>Intro</h1><p>See [[Other Note|alias]] and [not a link]</p><h2>Code</h2>` +
+		`<pre><code class="language-go">fmt.Println("hi")</code></pre>`
+	rec := httptest.NewRecorder()
+	formatObsidian(rec, readability.Article{}, bytes.NewBufferString(input), Options{Link: link, Document: doc})
+
+	body := rec.Body.String()
+	front, content, found := strings.Cut(strings.TrimPrefix(body, "---\n"), "\n---\n\n")
+	if !strings.HasPrefix(body, "---\n") || !found {
+		t.Fatalf("missing frontmatter: %q", body)
+	}
+	wantFront := `source: "https://example.com/post"
+created: ` + time.Now().Format(time.DateOnly) + `
+tags:
+  - "Go"
+  - "web-dev"
+  - "notes"`
+	if front != wantFront {
+		t.Errorf("frontmatter = %q; want %q", front, wantFront)
+	}
+
+	wantContent := "## Intro\n\nSee [[Other Note|alias]] and \\[not a link\\]\n\n### Code\n\n```go\nfmt.Println(\"hi\")\n```\n"
+	if content != wantContent {
+		t.Errorf("content = %q; want %q", content, wantContent)
+	}
+}
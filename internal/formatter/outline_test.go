@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatTana(t *testing.T) {
+	const input = `<p>Intro with <a href="https://example.com/a">a link</a>.</p>
+<h2>Section</h2>
+<p>Under section</p>
+<h3>Sub</h3>
+<ul><li>One<ul><li><a href="https://example.com/b">https://example.com/b</a></li></ul></li></ul>
+<h2>Next</h2>
+<pre><code class="language-sh">echo hi
+  indented
+</code></pre>`
+	rec := httptest.NewRecorder()
+	formatTana(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q; want text/plain", ct)
+	}
+	want := strings.Join([]string{
+		"%%tana%%",
+		"- Untitled",
+		"  - Intro with a link [[https://example.com/a]].",
+		"  - Section #heading",
+		"    - Under section",
+		"    - Sub #heading",
+		"      - One",
+		"        - [[https://example.com/b]]",
+		"  - Next #heading",
+		"    - sh #code",
+		"      - `echo hi`",
+		"      - `  indented`",
+	}, "\n") + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatTana() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatLogseq(t *testing.T) {
+	const input = `<h2>Top</h2>
+<p>See <a href="https://example.com/">the site</a></p>
+<h3>Nested</h3>
+<pre><code class="language-go">fmt.Println("hi")
+</code></pre>
+<h2>Second</h2>
+<p>Last</p>`
+	rec := httptest.NewRecorder()
+	formatLogseq(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+
+	want := strings.Join([]string{
+		"- [[Untitled]]",
+		"  - ## Top",
+		"    - See the site [[https://example.com/]]",
+		"    - ### Nested",
+		"      - #+BEGIN_SRC go",
+		`        fmt.Println("hi")`,
+		"        #+END_SRC",
+		"  - ## Second",
+		"    - Last",
+	}, "\n") + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatLogseq() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatRoam(t *testing.T) {
+	const input = `<p>Ada Lovelace wrote about the engine of <a href="https://example.com/babbage">Charles Babbage</a>.</p>
+<h2>Notes</h2>
+<pre><code>print(1)</code></pre>`
+	rec := httptest.NewRecorder()
+	formatRoam(rec, readability.Article{}, bytes.NewBufferString(input), Options{})
+
+	want := strings.Join([]string{
+		"# Untitled",
+		"- References:: [[Ada Lovelace]] [[Charles Babbage]]",
+		"- Ada Lovelace wrote about the engine of [Charles Babbage](https://example.com/babbage).",
+		"- **Notes**",
+		"  - ```",
+		"    print(1)",
+		"    ```",
+	}, "\n") + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("formatRoam() =\n%s\nwant\n%s", got, want)
+	}
+}
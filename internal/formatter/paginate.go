@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultPageSize is the page length, in characters, when only `?page=` is set.
+	DefaultPageSize = 3000
+	// MaxPageSize caps `?page-size=`.
+	MaxPageSize = 10000
+)
+
+/**
+ * Page is a chunk of the article content, as split by Paginate.
+ */
+type Page struct {
+	// Number is the 1-based position of the page.
+	Number int
+	// Content is the HTML of the page. Concatenating every page gives back the
+	// content they were split from.
+	Content string
+}
+
+// pageBreakElements are the blocks Paginate may end a page after.
+var pageBreakElements = []string{"p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "dl", "pre", "blockquote", "figure", "table", "hr"}
+
+/**
+ * Paginate splits the HTML in buf into pages of about pageSize characters.
+ *
+ * Pages only end after a block (see pageBreakElements) that isn't nested in
+ * another one, so a paragraph or list is never cut and a block longer than
+ * pageSize gets a page of its own. The containers wrapping the blocks (the
+ * readability <div>) open on the first page and close on the last one, which
+ * html.Parse handles. There is always at least one page.
+ */
+func Paginate(buf *bytes.Buffer, pageSize int) []Page {
+	content := buf.String()
+
+	// offsets right after each top level block
+	var breaks []int
+	offset, depth := 0, 0
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		offset += len(z.Raw())
+		name, _ := z.TagName()
+		if !slices.Contains(pageBreakElements, string(name)) {
+			continue
+		}
+		switch {
+		case string(name) == "hr" || tt == html.SelfClosingTagToken:
+			if depth == 0 {
+				breaks = append(breaks, offset)
+			}
+		case tt == html.StartTagToken:
+			depth++
+		case tt == html.EndTagToken && depth > 0:
+			depth--
+			if depth == 0 {
+				breaks = append(breaks, offset)
+			}
+		}
+	}
+
+	var pages []Page
+	add := func(content string) {
+		pages = append(pages, Page{Number: len(pages) + 1, Content: content})
+	}
+	size := func(start, end int) int {
+		return utf8.RuneCountInString(content[start:end])
+	}
+	start, last := 0, 0
+	for _, end := range breaks {
+		if size(start, end) > pageSize && last > start {
+			add(content[start:last])
+			start = last
+		}
+		if size(start, end) > pageSize {
+			add(content[start:end])
+			start = end
+		}
+		last = end
+	}
+	// what follows the last page is only closing tags, unless it has text
+	if rest := content[start:]; len(pages) == 0 || strings.TrimSpace(dom.TextContent(dom.ParseFragment(rest))) != "" {
+		add(rest)
+	} else {
+		pages[len(pages)-1].Content += rest
+	}
+	return pages
+}
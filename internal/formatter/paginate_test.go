@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPaginate(t *testing.T) {
+	content := paginationContent(20)
+	for _, size := range []int{1, 100, 500, 3000, 100000} {
+		pages := Paginate(bytes.NewBufferString(content), size)
+		var joined strings.Builder
+		for i, page := range pages {
+			if page.Number != i+1 {
+				t.Errorf("size %d: page %d numbered %d", size, i+1, page.Number)
+			}
+			joined.WriteString(page.Content)
+			// every page but the last ends right after a block
+			if i < len(pages)-1 && !strings.HasSuffix(page.Content, "</h2>") && !strings.HasSuffix(page.Content, "</p>\n") && !strings.HasSuffix(page.Content, "</p>") {
+				t.Errorf("size %d: page %d ends mid-block: %q", size, i+1, page.Content[max(0, len(page.Content)-20):])
+			}
+		}
+		if joined.String() != content {
+			t.Errorf("size %d: reassembled pages differ from the content", size)
+		}
+		if size == 500 {
+			for i, page := range pages[:len(pages)-1] {
+				if n := utf8.RuneCountInString(page.Content); n > 500 {
+					t.Errorf("page %d has %d characters; want at most 500", i+1, n)
+				}
+			}
+		}
+	}
+
+	if pages := Paginate(bytes.NewBufferString(content), 100000); len(pages) != 1 {
+		t.Errorf("got %d pages; want the whole content on one page", len(pages))
+	}
+	if pages := Paginate(&bytes.Buffer{}, 100); len(pages) != 1 || pages[0].Content != "" {
+		t.Errorf("empty content = %+v; want one empty page", pages)
+	}
+	// the closing tags of the wrapper don't make a page of their own
+	pages := Paginate(bytes.NewBufferString(content), 1)
+	if last := pages[len(pages)-1].Content; !strings.Contains(last, "<ul>") || !strings.HasSuffix(last, "</div>") {
+		t.Errorf("last page = %q", last)
+	}
+}
+
+func paginationContent(paragraphs int) string {
+	var sb strings.Builder
+	sb.WriteString(`<div id="readability-page-1" class="page">`)
+	for i := range paragraphs {
+		fmt.Fprintf(&sb, "<h2>Part %d</h2><p>%s é</p>\n", i, strings.Repeat("word ", 40))
+	}
+	sb.WriteString("<ul><li>One</li><li><p>Two</p></li></ul></div>")
+	return sb.String()
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatPDF(t *testing.T) {
+	if _, found := Formatters["pdf"]; !found {
+		t.Skip("no PDF backend available")
+	}
+	rec := httptest.NewRecorder()
+	formatPDF(rec, readability.Article{}, bytes.NewBufferString("<p>Printable body</p>"), Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type = %q; want application/pdf", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.pdf"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("body is not a PDF: %q", rec.Body.String())
+	}
+}
+
+func TestFormatPDFStopsWithTheRequest(t *testing.T) {
+	if _, found := Formatters["pdf"]; !found {
+		t.Skip("no PDF backend available")
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	rec := httptest.NewRecorder()
+	formatPDF(rec, readability.Article{}, bytes.NewBufferString("<p>Printable body</p>"), Options{Context: ctx})
+	if rec.Code != http.StatusInternalServerError || bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("status = %d; want the render of a gone client to be abandoned", rec.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatPocket(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post?utm_source=feed")
+	canonical, _ := url.Parse("https://example.com/post")
+	tests := []struct {
+		name    string
+		opts    Options
+		wantURL string
+	}{
+		{"canonical takes precedence", Options{Link: link, Canonical: canonical}, "https://example.com/post"},
+		{"input URL without canonical", Options{Link: link}, "https://example.com/post?utm_source=feed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			formatPocket(rec, readability.Article{}, &bytes.Buffer{}, tt.opts)
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			var payload map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			// the fields of https://getpocket.com/developer/docs/v3/add, less the credentials
+			if keys := slices.Sorted(maps.Keys(payload)); !slices.Equal(keys, []string{"tags", "title", "tweet_id", "url"}) {
+				t.Errorf("keys = %v", keys)
+			}
+			if payload["url"] != tt.wantURL {
+				t.Errorf("url = %v; want %q", payload["url"], tt.wantURL)
+			}
+			if payload["tags"] != "article,parsed" || payload["tweet_id"] != "" {
+				t.Errorf("payload = %v", payload)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatQuotes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	buf := bytes.NewBufferString(`<p>Intro</p><blockquote>To be or not to be<cite>Hamlet</cite></blockquote>`)
+	formatQuotes(rec, readability.Article{}, buf, Options{})
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+	var got []map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := []map[string]string{{"quote": "To be or not to be", "attribution": "Hamlet"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("formatQuotes() = %v; want %v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	formatQuotes(rec, readability.Article{}, bytes.NewBufferString("<p>none</p>"), Options{})
+	if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+		t.Errorf("formatQuotes() without quotes = %q; want []", body)
+	}
+}
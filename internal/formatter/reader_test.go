@@ -0,0 +1,92 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func newCSSServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.URL.Path {
+		case "/theme.css":
+			w.Header().Set("Content-Type", "text/css")
+			_, err = w.Write([]byte(`body{color:#222}a::after{content:"</style>"}`))
+		case "/huge.css":
+			w.Header().Set("Content-Type", "text/css")
+			_, err = w.Write(bytes.Repeat([]byte("a{}"), maxCSSSize))
+		default:
+			http.NotFound(w, r)
+		}
+		if err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+}
+
+func TestInlineCSS(t *testing.T) {
+	srv := newCSSServer(t)
+	defer srv.Close()
+
+	page := `<html><head><link id="theme" rel="stylesheet" href="` + srv.URL + `/theme.css"></head><body></body></html>`
+	got, err := InlineCSS(page, srv.URL+"/theme.css", srv.Client())
+	if err != nil {
+		t.Fatalf("InlineCSS() error = %v", err)
+	}
+	if strings.Contains(got, "<link") {
+		t.Errorf("link element not replaced: %s", got)
+	}
+	if !strings.Contains(got, "<head><style>body{color:#222}") {
+		t.Errorf("stylesheet not inlined in place of the link: %s", got)
+	}
+	if strings.Count(got, "</style>") != 1 {
+		t.Errorf("stylesheet content can close the style element: %s", got)
+	}
+}
+
+func TestInlineCSSErrors(t *testing.T) {
+	srv := newCSSServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		name, link, cssURL string
+	}{
+		{"missing link", srv.URL + "/theme.css", srv.URL + "/other.css"},
+		{"not found", srv.URL + "/missing.css", srv.URL + "/missing.css"},
+		{"too large", srv.URL + "/huge.css", srv.URL + "/huge.css"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := `<head><link rel="stylesheet" href="` + tt.link + `"></head>`
+			if _, err := InlineCSS(page, tt.cssURL, srv.Client()); err == nil {
+				t.Error("InlineCSS() succeeded; want an error")
+			}
+		})
+	}
+}
+
+func TestFormatReader(t *testing.T) {
+	theme, _ := LookupTheme("none")
+	rec := httptest.NewRecorder()
+	formatReader(rec, readability.Article{}, bytes.NewBufferString("<p>Offline body</p>"), Options{Theme: theme})
+
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.html"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	policy := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(policy, "'unsafe-inline'") {
+		t.Errorf("Content-Security-Policy = %q; want inline styles allowed", policy)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `<meta http-equiv="Content-Security-Policy" content="default-src &#39;self&#39;;`) {
+		t.Errorf("policy not embedded as a meta tag: %s", body)
+	}
+	if !strings.Contains(body, "<p>Offline body</p>") {
+		t.Errorf("content missing: %s", body)
+	}
+}
@@ -0,0 +1,100 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+/**
+ * readingListTemplate is the bookmark list imported by Instapaper, one link per article.
+ */
+var readingListTemplate = template.Must(template.New("reading-list").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Reading list</title>
+</head>
+<body>
+	<ul>
+		{{- range .}}
+		<li><a href="{{.URL}}">{{.Title}}</a></li>
+		{{- end}}
+	</ul>
+</body>
+</html>
+`))
+
+/**
+ * ReadingListEntry is a link of the Instapaper reading list.
+ */
+type ReadingListEntry struct {
+	URL   string
+	Title string
+}
+
+/**
+ * NewReadingListEntry links to the canonical URL of the article (the fetched one when
+ * it declares none), titled with the article title or, without one, the URL itself.
+ */
+func NewReadingListEntry(article readability.Article, opts Options) ReadingListEntry {
+	var link string
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		link = canonical.String()
+	}
+	return ReadingListEntry{URL: link, Title: cmp.Or(article.Title(), link)}
+}
+
+/**
+ * WriteReadingList writes entries as an Instapaper importable reading list file.
+ */
+func WriteReadingList(w http.ResponseWriter, entries []ReadingListEntry) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="reading-list.html"`)
+	if err := readingListTemplate.Execute(w, entries); err != nil {
+		log.Printf("error executing reading list template: %v", err)
+	}
+}
+
+/**
+ * formatInstapaper returns a reading list holding the article, in the HTML bookmark
+ * format Instapaper imports. The batch endpoint merges its items into a single list.
+ */
+func formatInstapaper(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	WriteReadingList(w, []ReadingListEntry{NewReadingListEntry(article, opts)})
+}
+
+/**
+ * PocketItem is the payload of Pocket's v3 Add API (`POST /v3/add`), without the
+ * consumer_key and access_token the client adds to it.
+ */
+type PocketItem struct {
+	URL     string `json:"url"`
+	Title   string `json:"title"`
+	Tags    string `json:"tags"`
+	TweetID string `json:"tweet_id"`
+}
+
+// pocketTags are the comma separated tags of the items formatPocket returns.
+const pocketTags = "article,parsed"
+
+/**
+ * formatPocket returns a PocketItem saving the article to Pocket, under its
+ * canonical URL when the page declares one.
+ */
+func formatPocket(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	item := PocketItem{Title: strings.Join(strings.Fields(article.Title()), " "), Tags: pocketTags}
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		item.URL = link.String()
+	}
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		log.Printf("error encoding pocket item: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatReadwise(t *testing.T) {
+	link, _ := url.Parse("https://example.com/post")
+	input := `<p>First, with "quotes" and commas</p><p>  </p><div><p>Second
+line</p></div>`
+	rec := httptest.NewRecorder()
+	formatReadwise(rec, readability.Article{}, bytes.NewBufferString(input), Options{Link: link})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.csv"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if !strings.Contains(rec.Body.String(), `"First, with ""quotes"" and commas"`) {
+		t.Errorf("highlight not quoted: %s", rec.Body.String())
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"Highlight", "Title", "Author", "URL", "Note", "Location"},
+		{`First, with "quotes" and commas`, "", "", "https://example.com/post", "", "1"},
+		{"Second line", "", "", "https://example.com/post", "", "2"},
+	}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Errorf("rows = %q; want %q", rows, want)
+	}
+}
+
+func TestFormatReadwiseCapsHighlights(t *testing.T) {
+	var input strings.Builder
+	for i := range maxReadwiseHighlights + 10 {
+		fmt.Fprintf(&input, "<p>Paragraph %d</p>", i)
+	}
+	rec := httptest.NewRecorder()
+	formatReadwise(rec, readability.Article{}, bytes.NewBufferString(input.String()), Options{})
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != maxReadwiseHighlights+1 {
+		t.Errorf("got %d rows; want the header and %d highlights", len(rows), maxReadwiseHighlights)
+	}
+	if last := rows[len(rows)-1][0]; last != fmt.Sprintf("Paragraph %d", maxReadwiseHighlights-1) {
+		t.Errorf("last highlight = %q", last)
+	}
+}
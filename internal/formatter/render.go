@@ -0,0 +1,610 @@
+/**
+ * Package formatter renders extracted articles in the output formats the
+ * service supports.
+ */
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/article"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"github.com/lucasew/readability-web/internal/pdf"
+	"github.com/lucasew/readability-web/internal/response"
+	"github.com/lucasew/readability-web/internal/stats"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxExcerptLength = 500
+
+	// minSummaryLength is how many characters a paragraph needs to be picked as the summary.
+	minSummaryLength = 100
+
+	// pdfRenderTimeout bounds how long a PDF backend may take (see formatPDF).
+	pdfRenderTimeout = 20 * time.Second
+)
+
+/**
+ * formatHandler defines the function signature for handling different output formats.
+ *
+ * Implementations are responsible for:
+ * 1. Setting the appropriate Content-Type header.
+ * 2. Encoding the article content (HTML, JSON, Markdown, etc.) into the response writer.
+ * 3. Handling any encoding errors (logging them, as headers are already written).
+ */
+type formatHandler func(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options)
+
+/**
+ * Options carries per-request rendering settings to the formatters.
+ *
+ * It is built once by the handler after validating the control parameters,
+ * so formatters never have to re-parse or re-validate the request.
+ */
+type Options struct {
+	// Link is the normalized URL the article was fetched from.
+	Link *url.URL
+	// Theme is the stylesheet selected for the HTML output.
+	Theme ThemeEntry
+	// Typography holds optional font overrides for the HTML output (nil when unset).
+	Typography *typography
+	// Nonce is the CSP nonce that inline styles must carry to be applied.
+	Nonce string
+	// Debug holds parser diagnostics for the JSON output (nil unless requested and enabled).
+	Debug *article.Diagnostics
+	// NoImages strips images from the content before any formatter sees it.
+	NoImages bool
+	// NoLinks unwraps links (keeping their text) before any formatter sees it.
+	NoLinks bool
+	// EmbedImages replaces image sources with data URIs (see EmbedImages).
+	EmbedImages bool
+	// Canonical is the URL the page declares as canonical, nil when it declares none.
+	Canonical *url.URL
+	// Text holds the settings of the plain text output.
+	Text TextOptions
+	// NotionParentID is the page FormatNotionPage creates the article under (`?notion-parent-id=`).
+	NotionParentID string
+	// Document is the page as fetched, before readability extraction (nil when unknown).
+	Document *html.Node
+	// Page is the 1-based page of the content to return (`?page=`), 0 to return all of it.
+	Page int
+	// PageSize is the approximate length of a page in characters (`?page-size=`).
+	PageSize int
+	// EPUBCompat adds an EPUB 2 toc.ncx to EPUB books (`?epub-compat=true`).
+	EPUBCompat bool
+	// Frontmatter makes Markdown output start with YAML frontmatter (`?frontmatter=true`).
+	Frontmatter bool
+	// Archive is the Wayback Machine snapshot the article was read from, if any.
+	Archive *article.ArchiveSnapshot
+	// AMPURL is the AMP version of the page the article was read from, if any.
+	AMPURL *url.URL
+	// Extractor is the article.FetchResult's Extractor.
+	Extractor string
+	// Context is the request's context, for formatters that do slow work such as
+	// formatPDF (nil outside of a request).
+	Context context.Context
+}
+
+/**
+ * TextOptions carries the settings of formatText.
+ */
+type TextOptions struct {
+	// PreserveWhitespace skips NormalizeWhitespace (`?dedupe-whitespace=false`),
+	// for tools that depend on the exact line spacing.
+	PreserveWhitespace bool
+	// Width is the column to wrap lines at (`?wrap=`), 0 to leave them unwrapped.
+	Width int
+}
+
+const (
+	// MinTextWidth and MaxTextWidth bound `?wrap=`.
+	MinTextWidth = 20
+	MaxTextWidth = 200
+)
+
+/**
+ * formatJSON returns the article content and its metadata in a JSON object.
+ * Useful for programmatic consumption where the client wants to handle rendering.
+ *
+ * Fields:
+ * - title: the article title.
+ * - content: the cleaned-up article HTML.
+ * - excerpt: a plain text summary (see articleExcerpt), at most maxExcerptLength characters.
+ * - content_hash: stats.SimHash of the article text as 16 hex digits, for near-duplicate detection.
+ * - canonical_url: the page's canonical URL (see meta.ExtractCanonicalURL), or the requested URL.
+ * - byline, published_time (RFC 3339), site_name, image (the lead image URL) and
+ *   language: from readability, falling back to the page's meta tags (author,
+ *   article:published_time, og:site_name, og:image, <html lang>). Left out when unknown.
+ * - word_count: the number of words of the article text.
+ * - _debug: parser diagnostics, only when requested and enabled.
+ */
+func formatJSON(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(jsonFields(article, buf, opts)); err != nil {
+		log.Printf("error encoding json: %v", err)
+	}
+}
+
+/**
+ * jsonFields returns the fields of formatJSON.
+ */
+func jsonFields(article readability.Article, buf *bytes.Buffer, opts Options) map[string]any {
+	data := map[string]any{
+		"title":        article.Title(),
+		"content":      buf.String(),
+		"excerpt":      articleExcerpt(article, buf),
+		"content_hash": fmt.Sprintf("%016x", stats.SimHash(dom.TextContent(dom.OrEmpty(article.Node)))),
+	}
+	if canonical := cmp.Or(opts.Canonical, opts.Link); canonical != nil {
+		data["canonical_url"] = canonical.String()
+	}
+	optional := map[string]string{
+		"byline":    cmp.Or(strings.TrimSpace(article.Byline()), meta.Value(opts.Document, "author")),
+		"site_name": cmp.Or(strings.TrimSpace(article.SiteName()), meta.Value(opts.Document, "og:site_name")),
+		"image":     cmp.Or(strings.TrimSpace(article.ImageURL()), meta.Value(opts.Document, "og:image", "twitter:image")),
+		"language":  cmp.Or(strings.TrimSpace(article.Language()), meta.Lang(opts.Document)),
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		optional["published_time"] = published.Format(time.RFC3339)
+	} else if published, err := time.Parse(time.RFC3339, meta.Value(opts.Document, "article:published_time")); err == nil {
+		optional["published_time"] = published.Format(time.RFC3339)
+	}
+	for key, value := range optional {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	data["word_count"] = stats.WordCount(dom.OrEmpty(article.Node))
+	if opts.Archive != nil {
+		data["archive"] = opts.Archive
+	}
+	if opts.AMPURL != nil {
+		data["amp_url"] = opts.AMPURL.String()
+	}
+	if opts.Extractor != "" {
+		data["extractor"] = opts.Extractor
+	}
+	if opts.Debug != nil {
+		data["_debug"] = opts.Debug
+	}
+	return data
+}
+
+/**
+ * formatJSONExtended returns everything known about the article in one JSON object,
+ * for clients that would otherwise combine several formats: the fields of
+ * formatJSON, plus
+ * - og_metadata: the OpenGraph properties of the page (see meta.ExtractOpenGraph).
+ * - jsonld: its JSON-LD documents (see meta.ExtractJSONLD).
+ * - toc: the headings of the content (see meta.ExtractTOC).
+ * - images and links: the images and links of the content, in document order.
+ * - quotes: the quotations of the content (see meta.ExtractQuotes).
+ * - tables: the number of tables in the content.
+ */
+func formatJSONExtended(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for extended json: %v", err)
+		doc = &html.Node{Type: html.DocumentNode}
+	}
+	data := jsonFields(article, buf, opts)
+	data["og_metadata"] = meta.ExtractOpenGraph(opts.Document)
+	data["jsonld"] = meta.ExtractJSONLD(opts.Document)
+	data["toc"] = meta.ExtractTOC(doc)
+	data["quotes"] = meta.ExtractQuotes(doc)
+
+	images, links := []meta.ContentImage{}, []meta.ContentLink{}
+	tables := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "img":
+				if src := strings.TrimSpace(dom.Attr(n, "src")); src != "" {
+					images = append(images, meta.ContentImage{URL: src, Alt: strings.TrimSpace(dom.Attr(n, "alt"))})
+				}
+			case "a":
+				if href := strings.TrimSpace(dom.Attr(n, "href")); href != "" && !strings.HasPrefix(href, "#") {
+					links = append(links, meta.ContentLink{URL: href, Text: strings.Join(strings.Fields(dom.TextContent(n)), " ")})
+				}
+			case "table":
+				tables++
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	data["images"], data["links"], data["tables"] = images, links, tables
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("error encoding extended json: %v", err)
+	}
+}
+
+/**
+ * articleExcerpt returns the readability excerpt, trimmed to maxExcerptLength characters.
+ *
+ * When readability found none, the text of the first <p> in the rendered
+ * content is used instead.
+ */
+func articleExcerpt(article readability.Article, buf *bytes.Buffer) string {
+	excerpt := article.Excerpt()
+	if excerpt == "" {
+		if doc, err := html.Parse(bytes.NewReader(buf.Bytes())); err == nil {
+			if p := dom.FindElement(doc, "p"); p != nil {
+				excerpt = strings.Join(strings.Fields(dom.TextContent(p)), " ")
+			}
+		}
+	}
+	if runes := []rune(excerpt); len(runes) > maxExcerptLength {
+		excerpt = strings.TrimSpace(string(runes[:maxExcerptLength]))
+	}
+	return excerpt
+}
+
+/**
+ * formatSummary returns the lead paragraph as plain text, for share previews and
+ * aggregators that do not need the full content.
+ *
+ * The lead paragraph is the first <p> of the rendered content longer than
+ * minSummaryLength characters, so bylines and captions are skipped. When there is
+ * none (e.g. articles made of headings and lists) the readability excerpt is used.
+ */
+func formatSummary(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	summary := leadParagraph(buf)
+	if summary == "" {
+		summary = strings.Join(strings.Fields(article.Excerpt()), " ")
+	}
+	if _, err := io.WriteString(w, summary+"\n"); err != nil {
+		log.Printf("error writing summary response: %v", err)
+	}
+}
+
+/**
+ * leadParagraph returns the text of the first <p> in buf longer than minSummaryLength
+ * characters, or "" when there is none.
+ */
+func leadParagraph(buf *bytes.Buffer) string {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return ""
+	}
+	var lead string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "p" {
+			if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); utf8.RuneCountInString(text) > minSummaryLength {
+				lead = text
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil && lead == ""; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return lead
+}
+
+/**
+ * imageExtensions maps image MIME types to the file extensions used by formatZip.
+ */
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+	"image/svg+xml": ".svg",
+}
+
+/**
+ * ArchiveImage is an image file stored in the images/ directory of a zip archive.
+ */
+type ArchiveImage struct {
+	File string `json:"file"`
+	Alt  string `json:"alt,omitempty"`
+	data []byte
+}
+
+/**
+ * extractArchiveImages moves the images embedded as data URIs in doc (see
+ * EmbedImages) to files under images/, pointing their src at the file instead.
+ */
+func extractArchiveImages(doc *html.Node) []ArchiveImage {
+	images := []ArchiveImage{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "img" {
+			if rest, isData := strings.CutPrefix(dom.Attr(n, "src"), "data:"); isData {
+				meta, payload, _ := strings.Cut(rest, ",")
+				mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+				if data, err := base64.StdEncoding.DecodeString(payload); err == nil && isBase64 {
+					name := fmt.Sprintf("images/%03d%s", len(images)+1, cmp.Or(imageExtensions[mediaType], ".img"))
+					images = append(images, ArchiveImage{File: name, Alt: dom.Attr(n, "alt"), data: data})
+					for i := range n.Attr {
+						if n.Attr[i].Key == "src" {
+							n.Attr[i].Val = name
+						}
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return images
+}
+
+/**
+ * formatZip returns a zip archive bundling the article as article.html (formatHTML),
+ * article.md (formatMarkdown) and metadata.json (formatJSON, plus the "toc" and
+ * "images" lists).
+ *
+ * With `?include-images-as-base64=true` the embedded images are stored as files
+ * under images/ and both documents point at them, so the archive works offline.
+ */
+func formatZip(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for zip: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to build zip archive")
+		return
+	}
+	images := extractArchiveImages(doc)
+	content := &bytes.Buffer{}
+	if body := dom.FindElement(doc, "body"); body != nil {
+		for c := body.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(content, c); err != nil {
+				log.Printf("error rendering content for zip: %v", err)
+			}
+		}
+	}
+
+	render := func(format formatHandler) []byte {
+		rec := response.NewBuffered()
+		format(rec, article, bytes.NewBuffer(content.Bytes()), opts)
+		return rec.Body.Bytes()
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(render(formatJSON), &metadata); err != nil {
+		log.Printf("error decoding json for zip metadata: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to build zip archive")
+		return
+	}
+	metadata["toc"] = meta.ExtractTOC(doc)
+	metadata["images"] = images
+	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		log.Printf("error encoding zip metadata: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to build zip archive")
+		return
+	}
+
+	type archiveFile struct {
+		name string
+		data []byte
+	}
+	files := []archiveFile{
+		{"article.html", render(formatHTML)},
+		{"article.md", render(formatMarkdown)},
+		{"metadata.json", metadataJSON},
+	}
+	for _, image := range images {
+		files = append(files, archiveFile{image.File, image.data})
+	}
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for _, file := range files {
+		f, err := zw.Create(file.name)
+		if err == nil {
+			_, err = f.Write(file.data)
+		}
+		if err != nil {
+			log.Printf("error writing %s to zip: %v", file.name, err)
+			response.Error(w, http.StatusInternalServerError, "failed to build zip archive")
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("error finishing zip: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to build zip archive")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	SetContentDisposition(w, "zip", article.Title())
+	if _, err := w.Write(archive.Bytes()); err != nil {
+		log.Printf("error writing zip: %v", err)
+	}
+}
+
+/**
+ * downloadExtensions are the file extensions of the formats served as downloads
+ * rather than shown in the browser (see SetContentDisposition).
+ */
+var downloadExtensions = map[string]string{
+	"bibtex":   ".bib",
+	"eml":      ".eml",
+	"epub":     ".epub",
+	"kindle":   ".epub",
+	"pdf":      ".pdf",
+	"reader":   ".html",
+	"readwise": ".csv",
+	"ris":      ".ris",
+	"zip":      ".zip",
+}
+
+/**
+ * SetContentDisposition marks the response as a download named after the article
+ * title when format is a download format (see downloadExtensions).
+ */
+func SetContentDisposition(w http.ResponseWriter, format, title string) {
+	ext, found := downloadExtensions[format]
+	if !found {
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+articleFilename(title, ext)+`"`)
+}
+
+/**
+ * articleFilename turns title into a download file name with the extension ext.
+ *
+ * Characters outside [a-zA-Z0-9._-] become "_", so the name is safe in any file
+ * system and in the header without escaping; leading dots are dropped so names
+ * like "../../etc/passwd" can't become hidden or relative files. The name is cut
+ * at 100 characters and an empty result becomes "article".
+ */
+func articleFilename(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._-", r)) {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(title))
+	name = strings.TrimLeft(name, ".")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return cmp.Or(name, "article") + ext
+}
+
+/**
+ * init registers the pdf format when the PDF backend compiled in can run here
+ * (e.g. when Chromium is installed, for the chromium build).
+ */
+func init() {
+	if pdf.Available() {
+		Formatters["pdf"] = formatPDF
+	} else {
+		log.Printf("pdf format disabled: the %s backend is not available", pdf.Backend())
+	}
+}
+
+/**
+ * formatPDF returns the article as a PDF download named after its title, rendered
+ * by the backend selected at build time (see package pdf).
+ */
+func formatPDF(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	// a client that goes away stops the renderer
+	parent := opts.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, pdfRenderTimeout)
+	defer cancel()
+	doc, err := pdf.Render(ctx, article.Title(), buf.Bytes())
+	if err != nil {
+		log.Printf("error rendering pdf: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render PDF")
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	SetContentDisposition(w, "pdf", article.Title())
+	if _, err := w.Write(doc); err != nil {
+		log.Printf("error writing pdf: %v", err)
+	}
+}
+
+/**
+ * Formatters maps format names (including aliases) to their respective handler functions.
+ *
+ * This design allows for easy extensibility of output formats. New formats can be
+ * added by implementing a formatHandler and registering it here.
+ */
+var Formatters = map[string]formatHandler{
+	"html":               formatHTML,
+	"md":                 formatMarkdown,
+	"markdown":           formatMarkdown,
+	"gfm":                formatGFM,
+	"github-markdown":    formatGFM,
+	"mdx":                formatMarkdownFrontmatter,
+	"markdownx":          formatMarkdownFrontmatter,
+	"frontmatter-md":     formatMarkdownFrontmatter,
+	"md-frontmatter":     formatMarkdownFrontmatter,
+	"obsidian":           formatObsidian,
+	"bearblog":           formatBearBlog,
+	"bear":               formatBearBlog,
+	"json":               formatJSON,
+	"json-extended":      formatJSONExtended,
+	"full-json":          formatJSONExtended,
+	"text":               formatText,
+	"txt":                formatText,
+	"rss-item":           formatRSSItem,
+	"atom-entry":         formatAtomEntry,
+	"opml":               formatOPML,
+	"quotes":             formatQuotes,
+	"quote":              formatQuotes,
+	"ansi":               formatANSI,
+	"slides":             formatSlides,
+	"ssml":               formatSSML,
+	"docbook":            formatDocBook,
+	"db":                 formatDocBook,
+	"summary":            formatSummary,
+	"reader":             formatReader,
+	"graph":              formatGraph,
+	"audio-meta":         formatAudioMeta,
+	"audio-metadata":     formatAudioMeta,
+	"notion":             formatNotion,
+	"notion-page":        FormatNotionPage,
+	"ghost":              formatGhost,
+	"lexical":            formatGhost,
+	"hast":               formatHAST,
+	"mf2":                formatMF2,
+	"microformats2":      formatMF2,
+	"mrkdwn":             formatSlackMrkdwn,
+	"slack":              formatSlackMrkdwn,
+	"discord":            formatDiscordMD,
+	"jira":               formatJira,
+	"confluence":         formatConfluence,
+	"substack":           formatSubstack,
+	"linkedin":           formatLinkedIn,
+	"twitter-thread":     formatTwitterThread,
+	"twitterthread":      formatTwitterThread,
+	"tweets":             formatTwitterThread,
+	"apple-notes":        formatAppleNotes,
+	"gemini":             formatGemini,
+	"gemtext":            formatGemini,
+	"jsonfeed":           formatJSONFeed,
+	"json-feed":          formatJSONFeed,
+	"tana":               formatTana,
+	"logseq":             formatLogseq,
+	"roam":               formatRoam,
+	"zip":                formatZip,
+	"instapaper":         formatInstapaper,
+	"readwise":           formatReadwise,
+	"anki":               formatAnki,
+	"pocket":             formatPocket,
+	"micropub":           formatMicropub,
+	"bibtex":             formatBibTeX,
+	"ris":                formatRIS,
+	"zotero":             formatRIS,
+	"mbox":               formatMbox,
+	"eml":                formatMbox,
+	"readwise-highlight": formatReadwise,
+	"epub":               formatEPUB,
+	"kindle":             formatKindle,
+	"speech":             formatSSML,
+}
@@ -0,0 +1,16 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+)
+
+func mustParseSelector(t *testing.T, raw string) cascadia.Sel {
+	t.Helper()
+	sel, err := cascadia.Parse(raw)
+	if err != nil {
+		t.Fatalf("failed to parse selector %q: %v", raw, err)
+	}
+	return sel
+}
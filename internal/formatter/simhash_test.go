@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/stats"
+	"golang.org/x/net/html"
+)
+
+const simhashText = `The city council met on Tuesday evening to debate the proposed budget for the coming year.
+Residents filled the chamber, many of them carrying signs about the planned cuts to library hours and park maintenance.
+The mayor opened the session by thanking volunteers who had organized neighborhood cleanups over the summer,
+then handed the floor to the finance director, who walked through a long presentation on revenue projections.
+Property tax receipts are expected to grow slightly, she said, but rising pension obligations and the cost of
+repairing aging water pipes will consume most of that increase. Several council members questioned whether the
+projections were too optimistic given the slowdown in new construction permits. One member proposed delaying the
+purchase of two new fire trucks, a suggestion that drew loud objections from firefighters seated in the back row.
+After nearly three hours of public comment, in which teachers, shop owners, retirees and students took turns at the
+microphone, the council agreed to postpone the final vote until next month. A working group will study alternatives,
+including a modest fee on commercial parking lots and a partnership with the county to share equipment costs.
+The library director said she was cautiously hopeful that weekend hours could be preserved if the fee passes.
+Outside the building, a small crowd lingered to discuss the evening, and a food truck did brisk business until
+well after midnight, when the last of the residents finally headed home through the quiet downtown streets.`
+
+func TestFormatJSONContentHash(t *testing.T) {
+	p := &html.Node{Type: html.ElementNode, Data: "p"}
+	p.AppendChild(&html.Node{Type: html.TextNode, Data: simhashText})
+	rec := httptest.NewRecorder()
+	formatJSON(rec, readability.Article{Node: p}, &bytes.Buffer{}, Options{})
+
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	hash, _ := resp["content_hash"].(string)
+	if !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(hash) {
+		t.Fatalf("content_hash = %q; want 16 hex digits", hash)
+	}
+	if want := fmt.Sprintf("%016x", stats.SimHash(simhashText)); hash != want {
+		t.Errorf("content_hash = %q; want %q", hash, want)
+	}
+}
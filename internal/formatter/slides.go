@@ -0,0 +1,199 @@
+package formatter
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * SlidesTemplate is the reveal.js presentation document used by formatSlides.
+ *
+ * The first slide shows the title and byline; each slide can hold vertical
+ * sub-slides and an optional background image.
+ */
+const SlidesTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8"/>
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>{{.Title}}</title>
+	<link rel="stylesheet" href="https://unpkg.com/reveal.js@5/dist/reveal.css">
+	<link rel="stylesheet" href="https://unpkg.com/reveal.js@5/dist/theme/white.css">
+</head>
+<body>
+	<div class="reveal">
+		<div class="slides">
+			<section>
+				<h1>{{.Title}}</h1>
+				{{- with .Byline}}
+				<p>{{.}}</p>
+				{{- end}}
+			</section>
+			{{- range .Slides}}
+			{{- if .Children}}
+			<section>
+				{{template "slide" .}}
+				{{- range .Children}}
+				{{template "slide" .}}
+				{{- end}}
+			</section>
+			{{- else}}
+			{{template "slide" .}}
+			{{- end}}
+			{{- end}}
+		</div>
+	</div>
+	<script src="https://unpkg.com/reveal.js@5/dist/reveal.js"></script>
+	<script nonce="{{.Nonce}}">Reveal.initialize({hash: true});</script>
+</body>
+</html>
+{{- define "slide"}}
+<section{{with .Background}} data-background-image="{{.}}"{{end}}>
+	{{- with .Heading}}
+	<h2>{{.}}</h2>
+	{{- end}}
+	{{.Content}}
+</section>
+{{- end}}
+`
+
+// slidesTemplate is the parsed SlidesTemplate.
+var slidesTemplate = template.Must(template.New("slides").Parse(SlidesTemplate))
+
+/**
+ * slide is a single reveal.js slide built from an article section.
+ */
+type slide struct {
+	Heading    string
+	Content    template.HTML
+	Background string
+	// Children are vertical sub-slides, one per <h3> under the <h2>.
+	Children []*slide
+}
+
+/**
+ * formatSlides turns the article into a reveal.js presentation.
+ *
+ * Each <h2> starts a new slide and each <h3> a vertical sub-slide under it.
+ * An image right after a heading becomes that slide's background.
+ */
+func formatSlides(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", ContentSecurityPolicy(opts.Nonce, "https://unpkg.com"))
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for slides: %v", err)
+		return
+	}
+	data := struct {
+		Title  string
+		Byline string
+		Slides []*slide
+		Nonce  string
+	}{
+		Title:  article.Title(),
+		Byline: article.Byline(),
+		Slides: buildSlides(doc),
+		Nonce:  opts.Nonce,
+	}
+	if err := slidesTemplate.Execute(w, data); err != nil {
+		log.Printf("error executing slides template: %v", err)
+	}
+}
+
+/**
+ * buildSlides splits the content into slides at <h2> and <h3> headings.
+ *
+ * Container elements (div, section, ...) are flattened so headings nested in
+ * wrappers still start slides. Content before the first heading gets its own slide.
+ */
+func buildSlides(doc *html.Node) []*slide {
+	var (
+		slides  []*slide
+		current *slide
+		content strings.Builder
+		// afterHeading is true until the first block following a heading
+		afterHeading bool
+	)
+	flush := func() {
+		if current != nil {
+			current.Content = template.HTML(content.String())
+		}
+		content.Reset()
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+				continue
+			}
+			if c.Type == html.ElementNode {
+				switch c.Data {
+				case "html", "head", "body", "div", "section", "article", "main", "header":
+					walk(c)
+					continue
+				case "h2":
+					flush()
+					current = &slide{Heading: strings.TrimSpace(dom.TextContent(c))}
+					slides = append(slides, current)
+					afterHeading = true
+					continue
+				case "h3":
+					flush()
+					sub := &slide{Heading: strings.TrimSpace(dom.TextContent(c))}
+					if parent := len(slides); parent > 0 {
+						slides[parent-1].Children = append(slides[parent-1].Children, sub)
+					} else {
+						slides = append(slides, sub)
+					}
+					current = sub
+					afterHeading = true
+					continue
+				}
+			}
+			if current == nil {
+				current = &slide{}
+				slides = append(slides, current)
+			}
+			if afterHeading {
+				afterHeading = false
+				if src := soleImage(c); src != "" {
+					current.Background = src
+					continue
+				}
+			}
+			if err := html.Render(&content, c); err != nil {
+				log.Printf("error rendering slide content: %v", err)
+			}
+		}
+	}
+	walk(doc)
+	flush()
+	return slides
+}
+
+/**
+ * soleImage returns the src of n when n is an image, or a block holding only one.
+ */
+func soleImage(n *html.Node) string {
+	if n.Type != html.ElementNode {
+		return ""
+	}
+	if n.Data == "img" {
+		return dom.Attr(n, "src")
+	}
+	if onlyImages(n) {
+		if img := dom.FindElement(n, "img"); img != nil {
+			return dom.Attr(img, "src")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+func TestFormatSlides(t *testing.T) {
+	buf := bytes.NewBufferString(`<div id="readability-page-1"><p>Intro text.</p>
+<h2>First</h2><img src="https://example.com/bg.png"><p>First body.</p>
+<h2>Second</h2><p>Second body.</p><h3>Detail A</h3><p>A body.</p><h3>Detail B</h3><p>B body.</p>
+<h2>Third</h2><p><img src="https://example.com/inline.png"> with text</p></div>`)
+	rec := httptest.NewRecorder()
+	formatSlides(rec, readability.Article{}, buf, Options{Nonce: "n0nce"})
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `<script src="https://unpkg.com/reveal.js@5/dist/reveal.js"></script>`) {
+		t.Errorf("slides missing reveal.js CDN script: %q", body)
+	}
+	if !strings.Contains(body, `href="https://unpkg.com/reveal.js@5/dist/reveal.css"`) {
+		t.Errorf("slides missing reveal.js CDN stylesheet: %q", body)
+	}
+	if !strings.Contains(body, `<script nonce="n0nce">`) {
+		t.Errorf("inline reveal.js init script must carry the CSP nonce: %q", body)
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self' https://bookmarklet-theme.vercel.app https://unpkg.com 'nonce-n0nce'") {
+		t.Errorf("Content-Security-Policy = %q; want reveal.js and nonce allowed", csp)
+	}
+
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to parse slides: %v", err)
+	}
+	top := cascadia.QueryAll(doc, mustParseSelector(t, ".slides > section"))
+	// title slide + intro slide + one per <h2>
+	if len(top) != 5 {
+		t.Fatalf("got %d top-level sections; want 5 (title, intro, 3 x h2)", len(top))
+	}
+	if got := strings.Count(body, "<h2>"); got != 5 {
+		t.Errorf("got %d <h2> headings; want 5 (3 x h2, 2 x h3)", got)
+	}
+	if nested := cascadia.QueryAll(top[3], mustParseSelector(t, "section > section")); len(nested) != 3 {
+		t.Errorf("second <h2> slide has %d vertical slides; want 3 (its own + 2 x h3)", len(nested))
+	}
+	if bg := dom.Attr(top[2], "data-background-image"); bg != "https://example.com/bg.png" {
+		t.Errorf("first <h2> slide background = %q; want the image following the heading", bg)
+	}
+	if strings.Contains(body, `<img src="https://example.com/bg.png"`) {
+		t.Error("background image should not also be rendered inline")
+	}
+	if dom.Attr(top[4], "data-background-image") != "" || !strings.Contains(body, "inline.png") {
+		t.Error("an image mixed with text must stay inline")
+	}
+}
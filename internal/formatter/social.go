@@ -0,0 +1,250 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+// maxTweetLength is the length limit of a tweet, as counted by TweetLength.
+const maxTweetLength = 280
+
+/**
+ * formatTwitterThread returns the article as a thread of tweets, in a JSON array
+ * of strings: the title, the text split at sentence boundaries (see
+ * SplitIntoTweets), then the canonical URL. Each tweet starts with its position,
+ * "2/7: ", and fits in maxTweetLength with it.
+ */
+func formatTwitterThread(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "application/json")
+	var sb strings.Builder
+	if err := article.RenderText(&sb); err != nil {
+		log.Printf("error rendering text for tweets: %v", err)
+	}
+	var tail []string
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		// Twitter shortens links, so the URL fits whatever its length
+		tail = append(tail, link.String())
+	}
+
+	// the numbering takes room from the text, and depends on how many tweets it makes
+	var tweets []string
+	for digits := 1; ; digits++ {
+		budget := maxTweetLength - len(": /") - 2*digits
+		tweets = slices.Concat(SplitIntoTweets(article.Title(), budget), SplitIntoTweets(NormalizeWhitespace(sb.String()), budget), tail)
+		if len(strconv.Itoa(len(tweets))) <= digits {
+			break
+		}
+	}
+	for i, tweet := range tweets {
+		tweets[i] = fmt.Sprintf("%d/%d: %s", i+1, len(tweets), tweet)
+	}
+	if tweets == nil {
+		tweets = []string{}
+	}
+	if err := json.NewEncoder(w).Encode(tweets); err != nil {
+		log.Printf("error encoding tweets: %v", err)
+	}
+}
+
+/**
+ * TweetLength returns the length of text as Twitter counts it: 1 for Latin and
+ * general punctuation characters, 2 for everything else (CJK, emoji...). Emoji
+ * variation selectors and joiners count for nothing, so most emoji count as 2.
+ */
+func TweetLength(text string) int {
+	length := 0
+	for _, r := range text {
+		length += tweetWeight(r)
+	}
+	return length
+}
+
+// tweetWeight returns how much r counts for in TweetLength.
+func tweetWeight(r rune) int {
+	switch {
+	case r == 0x200d || (r >= 0xfe00 && r <= 0xfe0f) || (r >= 0x1f3fb && r <= 0x1f3ff):
+		return 0
+	case r <= 0x10ff, r >= 0x2000 && r <= 0x200c, r >= 0x2010 && r <= 0x201f, r >= 0x2032 && r <= 0x2037:
+		return 1
+	}
+	return 2
+}
+
+/**
+ * SplitIntoTweets splits text into chunks of at most maxLen (see TweetLength),
+ * packing as many whole sentences as fit in each. Sentences longer than maxLen
+ * are split between words, and words longer than maxLen between characters.
+ */
+func SplitIntoTweets(text string, maxLen int) []string {
+	var pieces []string
+	start := 0
+	for _, end := range append(meta.SentenceEndPattern.FindAllStringIndex(text, -1), []int{len(text), len(text)}) {
+		sentence := strings.Join(strings.Fields(text[start:end[1]]), " ")
+		start = end[1]
+		if sentence == "" {
+			continue
+		}
+		if TweetLength(sentence) <= maxLen {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		for word := range strings.FieldsSeq(sentence) {
+			for TweetLength(word) > maxLen {
+				cut := tweetCut(word, maxLen)
+				pieces = append(pieces, word[:cut])
+				word = word[cut:]
+			}
+			pieces = append(pieces, word)
+		}
+	}
+
+	var tweets []string
+	for _, piece := range pieces {
+		if last := len(tweets) - 1; last >= 0 && TweetLength(tweets[last])+1+TweetLength(piece) <= maxLen {
+			tweets[last] += " " + piece
+			continue
+		}
+		tweets = append(tweets, piece)
+	}
+	return tweets
+}
+
+// tweetCut returns the length in bytes of the longest prefix of word that fits in
+// maxLen, and at least of its first character.
+func tweetCut(word string, maxLen int) int {
+	cut, length := 0, 0
+	for i, r := range word {
+		if length += tweetWeight(r); i > 0 && length > maxLen {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	return cut
+}
+
+/**
+ * formatLinkedIn renders the article as plain text for pasting into LinkedIn's
+ * article editor, which strips Markdown.
+ *
+ * The title is in capitals, bold text is set off as "** text **" (spaced, so it
+ * isn't read as Markdown), list items start with "• " and links are followed by
+ * their URL in parentheses. A footer links back to the canonical URL.
+ */
+func formatLinkedIn(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for linkedin: %v", err)
+		return
+	}
+	var sb strings.Builder
+	if title := strings.Join(strings.Fields(article.Title()), " "); title != "" {
+		sb.WriteString(strings.ToUpper(title) + "\n\n")
+	}
+	renderLinkedIn(&sb, doc, "")
+	text := strings.TrimSpace(NormalizeWhitespace(sb.String()))
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		text += "\n\nOriginally published at " + link.String()
+	}
+	if _, err := io.WriteString(w, text+"\n"); err != nil {
+		log.Printf("error writing linkedin response: %v", err)
+	}
+}
+
+// noEscape is the identity escape, for plain text outputs.
+func noEscape(text string) string {
+	return text
+}
+
+/**
+ * renderLinkedIn writes n and its children to sb as formatLinkedIn plain text.
+ * List items are prefixed with indent, which grows with each nested list.
+ */
+func renderLinkedIn(sb *strings.Builder, n *html.Node, indent string) {
+	switch n.Type {
+	case html.TextNode:
+		writeCollapsedText(sb, n.Data, noEscape)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderLinkedIn(sb, c, indent)
+		}
+		return
+	}
+
+	children := func(sb *strings.Builder) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderLinkedIn(sb, c, indent)
+		}
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template", "img":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+			sb.WriteString("\n\n** " + text + " **\n\n")
+		}
+	case "b", "strong":
+		var inner strings.Builder
+		children(&inner)
+		writeWrapped(sb, inner.String(), "** ", " **")
+	case "pre":
+		sb.WriteString("\n\n" + strings.Trim(dom.TextContent(n), "\n") + "\n\n")
+	case "a":
+		var inner strings.Builder
+		children(&inner)
+		href, err := url.Parse(dom.Attr(n, "href"))
+		if err != nil || (href.Scheme != "http" && href.Scheme != "https") || strings.TrimSpace(inner.String()) == href.String() {
+			sb.WriteString(inner.String())
+			return
+		}
+		writeWrapped(sb, inner.String(), "", " ("+href.String()+")")
+	case "br":
+		sb.WriteString("\n")
+	case "ul", "ol":
+		number := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				renderLinkedIn(sb, c, indent)
+				continue
+			}
+			bullet := "•"
+			if n.Data == "ol" {
+				number++
+				bullet = strconv.Itoa(number) + "."
+			}
+			sb.WriteString("\n" + indent + bullet + " ")
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				renderLinkedIn(sb, gc, indent+"    ")
+			}
+		}
+		sb.WriteString("\n\n")
+	case "blockquote":
+		var inner strings.Builder
+		children(&inner)
+		sb.WriteString("\n\n“" + strings.TrimSpace(NormalizeWhitespace(inner.String())) + "”\n\n")
+	case "tr":
+		sb.WriteString("\n" + tableRow(n, " | ", func(sb *strings.Builder, n *html.Node) { renderLinkedIn(sb, n, indent) }))
+	case "p", "div", "section", "article", "table", "figure", "hr":
+		sb.WriteString("\n\n")
+		children(sb)
+		sb.WriteString("\n\n")
+	default:
+		children(sb)
+	}
+}
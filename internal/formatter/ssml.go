@@ -0,0 +1,125 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+// ssmlBreak is the pause inserted after headings in the SSML output.
+const ssmlBreak = `<break time="500ms"/>`
+
+/**
+ * formatSSML returns the article as SSML (Speech Synthesis Markup Language) for
+ * text-to-speech engines and voice assistants.
+ *
+ * Headings become sentences followed by a pause, list items and table rows become
+ * sentences, code blocks are read verbatim and bold/italic text is emphasized.
+ */
+func formatSSML(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "application/ssml+xml")
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<speak version="1.1" xmlns="http://www.w3.org/2001/10/synthesis">`)
+	if title := article.Title(); title != "" {
+		sb.WriteString("<s>")
+		xml.EscapeText(&sb, []byte(title))
+		sb.WriteString("</s>" + ssmlBreak)
+	}
+	if article.Node != nil {
+		renderSSML(&sb, article.Node, false)
+	}
+	sb.WriteString("</speak>\n")
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing ssml response: %v", err)
+	}
+}
+
+/**
+ * renderSSML writes n and its children to sb as SSML.
+ * Once inside a <p> or <s>, nested blocks are flattened, as SSML does not allow
+ * paragraphs or sentences to nest.
+ */
+func renderSSML(sb *strings.Builder, n *html.Node, inside bool) {
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			return
+		}
+		if strings.TrimLeft(n.Data, " \t\n\r") != n.Data {
+			text = " " + text
+		}
+		if strings.TrimRight(n.Data, " \t\n\r") != n.Data {
+			text += " "
+		}
+		xml.EscapeText(sb, []byte(text))
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderSSML(sb, c, inside)
+		}
+		return
+	}
+
+	children := func(inside bool) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			renderSSML(sb, c, inside)
+		}
+	}
+	// wrap renders the children inside tag, or just separates them when already in a block
+	wrap := func(open, close string) {
+		if inside {
+			sb.WriteString(" ")
+			children(true)
+			sb.WriteString(" ")
+			return
+		}
+		sb.WriteString(open)
+		children(true)
+		sb.WriteString(close)
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		wrap("<s>", "</s>"+ssmlBreak)
+	case "li":
+		wrap("<s>", "</s>")
+	case "tr":
+		// each row is a sentence, with a short pause between its cells
+		row := tableRow(n, ", ", func(sb *strings.Builder, n *html.Node) { renderSSML(sb, n, true) })
+		if inside {
+			sb.WriteString(" " + row + " ")
+		} else {
+			sb.WriteString("<s>" + row + "</s>")
+		}
+	case "p", "blockquote", "figcaption", "dt", "dd":
+		wrap("<p>", "</p>")
+	case "pre":
+		if !inside {
+			sb.WriteString("<p>")
+		}
+		sb.WriteString(`<say-as interpret-as="verbatim">`)
+		xml.EscapeText(sb, []byte(strings.TrimSpace(dom.TextContent(n))))
+		sb.WriteString("</say-as>")
+		if !inside {
+			sb.WriteString("</p>")
+		}
+	case "b", "strong", "i", "em":
+		sb.WriteString(`<emphasis level="strong">`)
+		children(inside)
+		sb.WriteString("</emphasis>")
+	case "br":
+		sb.WriteString(" ")
+	default:
+		children(inside)
+	}
+}
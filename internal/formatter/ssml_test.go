@@ -0,0 +1,80 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestFormatSSML(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<div>
+<h2>Getting started</h2>
+<p>Install it <b>today</b> &amp; enjoy <em>everything</em>.</p>
+<ul><li>First step</li><li><p>Second step</p></li></ul>
+<pre>if a &lt; b { return }</pre>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td><em>2</em></td></tr></table>
+<script>alert("ignored")</script>
+</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	formatSSML(rec, readability.Article{Node: doc}, &bytes.Buffer{}, Options{})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/ssml+xml" {
+		t.Errorf("Content-Type = %q; want application/ssml+xml", ct)
+	}
+	body := rec.Body.String()
+
+	// the output must be well-formed XML rooted at <speak>
+	dec := xml.NewDecoder(strings.NewReader(body))
+	var root string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SSML is not valid XML: %v\n%s", err, body)
+		}
+		if start, ok := tok.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+	if root != "speak" {
+		t.Errorf("root element = %q; want speak", root)
+	}
+
+	for _, want := range []string{
+		`<s>Getting started</s><break time="500ms"/>`,
+		`<emphasis level="strong">today</emphasis>`,
+		`<emphasis level="strong">everything</emphasis>`,
+		`&amp; enjoy`,
+		`<s>First step</s>`,
+		`<s> Second step </s>`,
+		`<say-as interpret-as="verbatim">if a &lt; b { return }</say-as>`,
+		`<s>A, B</s><s>1, <emphasis level="strong">2</emphasis></s>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("SSML missing %q, got: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "alert") {
+		t.Errorf("SSML must not include scripts: %s", body)
+	}
+}
+
+func TestFormatSSMLRegistered(t *testing.T) {
+	for _, name := range []string{"ssml", "speech"} {
+		if _, ok := Formatters[name]; !ok {
+			t.Errorf("format %q is not registered", name)
+		}
+	}
+}
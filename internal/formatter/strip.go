@@ -0,0 +1,51 @@
+package formatter
+
+import "golang.org/x/net/html"
+
+/**
+ * StripImages removes <img> and <picture> elements from the tree, in place,
+ * along with <figure> elements that contain nothing but images.
+ * It returns node for convenience.
+ */
+func StripImages(node *html.Node) *html.Node {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode && (c.Data == "img" || c.Data == "picture" || (c.Data == "figure" && onlyImages(c))) {
+				n.RemoveChild(c)
+			} else {
+				walk(c)
+			}
+			c = next
+		}
+	}
+	walk(node)
+	return node
+}
+
+/**
+ * UnwrapLinks replaces every <a> element with its children, in place,
+ * so link text (and any formatting inside it) is kept without the href.
+ * It returns node for convenience.
+ */
+func UnwrapLinks(node *html.Node) *html.Node {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			walk(c)
+			next := c.NextSibling
+			if c.Type == html.ElementNode && c.Data == "a" {
+				for c.FirstChild != nil {
+					child := c.FirstChild
+					c.RemoveChild(child)
+					n.InsertBefore(child, c)
+				}
+				n.RemoveChild(c)
+			}
+			c = next
+		}
+	}
+	walk(node)
+	return node
+}
@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestStripImages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(imagesFixture))
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	var out strings.Builder
+	if err := html.Render(&out, StripImages(doc)); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	got := out.String()
+	for _, unwanted := range []string{"<img", "<picture", "<source", "figure.png"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("StripImages left %q in %q", unwanted, got)
+		}
+	}
+	for _, wanted := range []string{"Text next to an image.", "<figcaption>Kept caption</figcaption>"} {
+		if !strings.Contains(got, wanted) {
+			t.Errorf("StripImages removed %q from %q", wanted, got)
+		}
+	}
+	if strings.Count(got, "<figure>") != 1 {
+		t.Errorf("expected only the captioned figure to remain, got %q", got)
+	}
+}
+
+func TestUnwrapLinks(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<p>See <a href="https://example.com/a">the <em>docs</em> page</a> and <a href="/b"><a href="/c">nested</a></a>.</p>`))
+	if err != nil {
+		t.Fatalf("failed to parse html: %v", err)
+	}
+	var out strings.Builder
+	if err := html.Render(&out, UnwrapLinks(doc)); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	got := out.String()
+	if strings.Contains(got, "<a") || strings.Contains(got, "href") {
+		t.Errorf("UnwrapLinks left links in %q", got)
+	}
+	for _, wanted := range []string{"See the <em>docs</em> page and", "nested."} {
+		if !strings.Contains(got, wanted) {
+			t.Errorf("UnwrapLinks lost %q, got %q", wanted, got)
+		}
+	}
+}
+
+const imagesFixture = `<html><head><title>Images</title></head><body><article>
+<p>Intro paragraph with enough words to make readability keep this article content around.</p>
+<p><img src="https://example.com/inline.png" alt="inline"> Text next to an image.</p>
+<figure><img src="https://example.com/figure.png"></figure>
+<figure><picture><source srcset="https://example.com/pic.webp"><img src="https://example.com/pic.png"></picture><figcaption>Kept caption</figcaption></figure>
+<p>Closing paragraph with more words so the article is long enough to be extracted.</p>
+</article></body></html>`
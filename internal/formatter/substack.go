@@ -0,0 +1,106 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/response"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatSubstack returns the article as HTML that Substack's editor keeps when pasted
+ * or imported: only the elements listed in substackElements, without classes or
+ * other attributes, and an attribution footer linking back to the source.
+ */
+func formatSubstack(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for substack: %v", err)
+		response.Error(w, http.StatusInternalServerError, "failed to render article content")
+		return
+	}
+	var sb strings.Builder
+	renderSubstack(&sb, doc)
+	if link := cmp.Or(opts.Canonical, opts.Link); link != nil {
+		name := cmp.Or(strings.TrimSpace(article.SiteName()), link.Hostname())
+		sb.WriteString("\n<p><em>Originally published at <a href=\"" + html.EscapeString(link.String()) + "\">" + html.EscapeString(name) + "</a></em></p>\n")
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing substack response: %v", err)
+	}
+}
+
+/**
+ * substackElements maps the HTML elements Substack's editor understands to the
+ * element they are written as. Headings all become <h2>, Substack's section
+ * heading; other elements (<div>, <section>, <article>...) are unwrapped.
+ */
+var substackElements = map[string]string{
+	"p": "p", "h1": "h2", "h2": "h2", "h3": "h2", "h4": "h2", "h5": "h2", "h6": "h2",
+	"ul": "ul", "ol": "ol", "li": "li", "blockquote": "blockquote",
+	"b": "strong", "strong": "strong", "i": "em", "em": "em", "code": "code",
+}
+
+/**
+ * renderSubstack writes the children of n to sb as Substack-compatible HTML.
+ */
+func renderSubstack(sb *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.Type {
+		case html.TextNode:
+			sb.WriteString(html.EscapeString(c.Data))
+			continue
+		case html.ElementNode:
+		default:
+			renderSubstack(sb, c)
+			continue
+		}
+
+		switch c.Data {
+		case "script", "style", "noscript", "template":
+		case "pre":
+			sb.WriteString("<pre>" + html.EscapeString(strings.Trim(dom.TextContent(c), "\n")) + "</pre>\n")
+		case "img":
+			src, err := url.Parse(strings.TrimSpace(dom.Attr(c, "src")))
+			if err != nil || (src.Scheme != "http" && src.Scheme != "https") {
+				continue
+			}
+			sb.WriteString(`<img src="` + html.EscapeString(src.String()) + `"`)
+			if alt := strings.TrimSpace(dom.Attr(c, "alt")); alt != "" {
+				sb.WriteString(` alt="` + html.EscapeString(alt) + `"`)
+			}
+			sb.WriteString(">")
+		case "a":
+			href, err := url.Parse(strings.TrimSpace(dom.Attr(c, "href")))
+			if err != nil || (href.Scheme != "http" && href.Scheme != "https" && href.Scheme != "mailto") {
+				renderSubstack(sb, c)
+				continue
+			}
+			sb.WriteString(`<a href="` + html.EscapeString(href.String()) + `">`)
+			renderSubstack(sb, c)
+			sb.WriteString("</a>")
+		case "br":
+			sb.WriteString("<br>")
+		case "hr":
+			sb.WriteString("<hr>\n")
+		default:
+			tag, found := substackElements[c.Data]
+			if !found {
+				renderSubstack(sb, c)
+				continue
+			}
+			sb.WriteString("<" + tag + ">")
+			renderSubstack(sb, c)
+			sb.WriteString("</" + tag + ">")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatSubstack(t *testing.T) {
+	input := `<article><section><div class="intro" data-id="1"><h1>Title</h1><h3 id="x">Part</h3>
+<p class="lead">Some <b>bold</b> and <i>italic</i> <a href="https://example.com/?a=1&amp;b=2" class="x">link</a></p>
+<div><blockquote><p>Quoted</p></blockquote></div>
+<pre><code class="language-go">if a &lt; b {}</code></pre>
+<img src="https://example.com/a.png" alt="An image" class="wide"><img src="javascript:x">
+<script>alert(1)</script></div></section></article>`
+	canonical, _ := url.Parse("https://blog.example.com/post")
+	rec := httptest.NewRecorder()
+	formatSubstack(rec, readability.Article{}, bytes.NewBufferString(input), Options{Canonical: canonical})
+	body := rec.Body.String()
+
+	for _, banned := range []string{"<div", "<section", "<article", "class=", "id=", "data-", "<script", "<h1", "<h3", "javascript:"} {
+		if strings.Contains(body, banned) {
+			t.Errorf("output contains %q: %s", banned, body)
+		}
+	}
+	for _, want := range []string{
+		"<h2>Title</h2><h2>Part</h2>",
+		`<p>Some <strong>bold</strong> and <em>italic</em> <a href="https://example.com/?a=1&amp;b=2">link</a></p>`,
+		"<blockquote><p>Quoted</p></blockquote>",
+		"<pre>if a &lt; b {}</pre>",
+		`<img src="https://example.com/a.png" alt="An image">`,
+		`<p><em>Originally published at <a href="https://blog.example.com/post">blog.example.com</a></em></p>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("output lacks %q: %s", want, body)
+		}
+	}
+}
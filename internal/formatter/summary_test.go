@@ -0,0 +1,57 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/testutil"
+)
+
+func TestFormatSummary(t *testing.T) {
+	lead := strings.Repeat("The lead paragraph explains the story. ", 4)
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"skips short paragraphs",
+			`<div><p>By Jane Doe</p><p>Photo: <em>agency</em></p><p>` + lead + `</p><p>` + lead + ` Second.</p></div>`,
+			strings.TrimSpace(lead),
+		},
+		{
+			"strips tags",
+			`<div><p>The <a href="/x">lead paragraph</a> has <b>markup</b> that must be removed before it is returned as a plain text summary to clients.</p></div>`,
+			"The lead paragraph has markup that must be removed before it is returned as a plain text summary to clients.",
+		},
+		{
+			"headings and lists only",
+			`<div><h2>Steps</h2><ul><li>One</li><li>Two</li></ul></div>`,
+			"",
+		},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		formatSummary(rec, readability.Article{}, bytes.NewBufferString(tt.content), Options{})
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("%s: Content-Type = %q; want text/plain", tt.name, ct)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != tt.expected {
+			t.Errorf("%s: summary = %q; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatSummaryFallsBackToExcerpt(t *testing.T) {
+	article := testutil.ArticleFromFixture(t, "testdata/summary_list.html")
+	buf := testutil.MustRenderHTML(t, article)
+
+	rec := httptest.NewRecorder()
+	formatSummary(rec, article, buf, Options{})
+	if got := strings.TrimSpace(rec.Body.String()); got != "A checklist for getting started." {
+		t.Errorf("summary = %q; want the readability excerpt", got)
+	}
+}
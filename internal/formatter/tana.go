@@ -0,0 +1,330 @@
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * outlineNode is a node of the bullet outlines imported by note taking tools
+ * such as Tana and Logseq.
+ */
+type outlineNode struct {
+	Text     string
+	Children []*outlineNode
+}
+
+/**
+ * add appends a child node with the given text and returns it.
+ */
+func (n *outlineNode) add(text string) *outlineNode {
+	child := &outlineNode{Text: text}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+/**
+ * outlineStyle holds the tool specific syntax used by HTMLToOutline.
+ */
+type outlineStyle struct {
+	// Heading returns the text of the node for a heading of the given level (1-6).
+	Heading func(level int, text string) string
+	// Link returns the inline form of a http(s) link; text is empty when it just repeats the URL.
+	Link func(text, href string) string
+	// Code adds the node(s) for a code block under parent.
+	Code func(parent *outlineNode, lang, code string)
+}
+
+/**
+ * HTMLToOutline builds the outline of node under a root node named title.
+ *
+ * Headings hold the content up to the next heading of the same or a higher
+ * level, paragraphs and list items are leaf nodes (nested lists nest), and
+ * everything is rendered on a single line except what style.Code produces.
+ */
+func HTMLToOutline(title string, node *html.Node, style outlineStyle) *outlineNode {
+	o := outlineWalker{style: style, sections: []outlineSection{{node: &outlineNode{Text: title}}}}
+	o.walk(node)
+	return o.sections[0].node
+}
+
+/**
+ * outlineSection is an open heading node and its level, 0 for the root.
+ */
+type outlineSection struct {
+	level int
+	node  *outlineNode
+}
+
+/**
+ * outlineWalker keeps the stack of open sections while walking the article.
+ */
+type outlineWalker struct {
+	style    outlineStyle
+	sections []outlineSection
+}
+
+/**
+ * parent returns the node new blocks are added to, the innermost open section.
+ */
+func (o *outlineWalker) parent() *outlineNode {
+	return o.sections[len(o.sections)-1].node
+}
+
+/**
+ * walk adds the blocks under n to the outline.
+ */
+func (o *outlineWalker) walk(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode || (c.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.Data)) {
+			run := []*html.Node{c}
+			for c.NextSibling != nil && (c.NextSibling.Type == html.TextNode || (c.NextSibling.Type == html.ElementNode && slices.Contains(meta.InlineElements, c.NextSibling.Data))) {
+				c = c.NextSibling
+				run = append(run, c)
+			}
+			if text := o.text(run...); text != "" {
+				o.parent().add(text)
+			}
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "script", "style", "noscript", "template", "br", "hr":
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level := int(c.Data[1] - '0')
+			for len(o.sections) > 1 && o.sections[len(o.sections)-1].level >= level {
+				o.sections = o.sections[:len(o.sections)-1]
+			}
+			heading := o.parent().add(o.style.Heading(level, cmp.Or(o.text(c), "Untitled")))
+			o.sections = append(o.sections, outlineSection{level: level, node: heading})
+		case "p", "li", "dt", "dd", "figcaption":
+			if text := o.text(c); text != "" {
+				o.parent().add(text)
+			}
+		case "ul", "ol":
+			o.list(o.parent(), c)
+		case "pre":
+			o.style.Code(o.parent(), codeLanguage(c), strings.Trim(dom.TextContent(c), "\n"))
+		default:
+			o.walk(c)
+		}
+	}
+}
+
+/**
+ * list adds the items of list under parent, nesting the items of nested lists.
+ */
+func (o *outlineWalker) list(parent *outlineNode, list *html.Node) {
+	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		var inline []*html.Node
+		var nested []*html.Node
+		for c := li.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+				nested = append(nested, c)
+			} else {
+				inline = append(inline, c)
+			}
+		}
+		item := parent
+		if text := o.text(inline...); text != "" {
+			item = parent.add(text)
+		}
+		for _, sub := range nested {
+			o.list(item, sub)
+		}
+	}
+}
+
+/**
+ * text returns the collapsed text of nodes on a single line, with http(s) links
+ * written by style.Link.
+ */
+func (o *outlineWalker) text(nodes ...*html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case n.Type != html.ElementNode:
+		case slices.Contains([]string{"script", "style", "noscript", "template"}, n.Data):
+			return
+		case n.Data == "a":
+			u, err := url.Parse(dom.Attr(n, "href"))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				break
+			}
+			text := strings.Join(strings.Fields(dom.TextContent(n)), " ")
+			if text == u.String() {
+				text = ""
+			}
+			sb.WriteString(o.style.Link(text, u.String()))
+			return
+		case n.Data == "br":
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+/**
+ * writeOutline writes node as a "- " line indented two spaces per depth, then
+ * its children. Continuation lines of multi-line nodes are aligned with the text
+ * after the bullet.
+ */
+func writeOutline(sb *strings.Builder, node *outlineNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	sb.WriteString(indent + "- " + strings.ReplaceAll(node.Text, "\n", "\n"+indent+"  ") + "\n")
+	for _, child := range node.Children {
+		writeOutline(sb, child, depth+1)
+	}
+}
+
+/**
+ * outlineTitle returns the article title for the root of an outline.
+ */
+func outlineTitle(article readability.Article) string {
+	return cmp.Or(strings.Join(strings.Fields(article.Title()), " "), "Untitled")
+}
+
+/**
+ * writeOutlineResponse parses buf, builds its outline with style and writes it
+ * after header, rooted at root.
+ */
+func writeOutlineResponse(w http.ResponseWriter, name, header, root string, buf *bytes.Buffer, style outlineStyle) {
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for %s: %v", name, err)
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(header)
+	writeOutline(&sb, HTMLToOutline(root, doc, style), 0)
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing %s response: %v", name, err)
+	}
+}
+
+/**
+ * tanaStyle is the Tana Paste syntax: headings tagged #heading, links as [[URL]]
+ * references and code blocks as a #code node with one child per line.
+ */
+var tanaStyle = outlineStyle{
+	Heading: func(_ int, text string) string {
+		return text + " #heading"
+	},
+	Link: func(text, href string) string {
+		return strings.TrimSpace(text + " [[" + href + "]]")
+	},
+	Code: func(parent *outlineNode, lang, code string) {
+		node := parent.add(cmp.Or(lang, "code") + " #code")
+		for line := range strings.SplitSeq(code, "\n") {
+			if strings.TrimSpace(line) != "" {
+				node.add("`" + strings.TrimRight(line, " \t\r") + "`")
+			}
+		}
+	},
+}
+
+/**
+ * formatTana renders the article as Tana Paste, the indented outline Tana imports
+ * when text starting with "%%tana%%" is pasted. The title is the root node.
+ */
+func formatTana(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writeOutlineResponse(w, "tana", "%%tana%%\n", outlineTitle(article), buf, tanaStyle)
+}
+
+/**
+ * logseqStyle is the Logseq outline syntax: headings as Markdown headings inside
+ * their block, links as [[URL]] page references and code blocks as
+ * #+BEGIN_SRC/#+END_SRC blocks.
+ */
+var logseqStyle = outlineStyle{
+	Heading: func(level int, text string) string {
+		return strings.Repeat("#", level) + " " + text
+	},
+	Link: tanaStyle.Link,
+	Code: func(parent *outlineNode, lang, code string) {
+		parent.add(strings.TrimSpace("#+BEGIN_SRC "+lang) + "\n" + code + "\n#+END_SRC")
+	},
+}
+
+/**
+ * formatLogseq renders the article as a Logseq outline, with a [[Title]] page
+ * reference as the root block.
+ */
+func formatLogseq(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	title := strings.NewReplacer("[[", "[", "]]", "]").Replace(outlineTitle(article))
+	writeOutlineResponse(w, "logseq", "", "[["+title+"]]", buf, logseqStyle)
+}
+
+/**
+ * roamStyle is the Roam Research Markdown syntax: bold headings, Markdown links
+ * and fenced code blocks.
+ */
+var roamStyle = outlineStyle{
+	Heading: func(_ int, text string) string {
+		return "**" + text + "**"
+	},
+	Link: func(text, href string) string {
+		return "[" + cmp.Or(text, href) + "](" + href + ")"
+	},
+	Code: func(parent *outlineNode, lang, code string) {
+		parent.add("```" + lang + "\n" + code + "\n```")
+	},
+}
+
+/**
+ * formatRoam renders the article as Roam Research Markdown: a "# Title" line
+ * followed by the outline, headed by a "References::" block linking the proper
+ * nouns found by meta.DetectPageRefs as [[page]] references.
+ */
+func formatRoam(w http.ResponseWriter, article readability.Article, buf *bytes.Buffer, _ Options) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	doc, err := html.Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		log.Printf("error parsing content for roam: %v", err)
+		return
+	}
+	title := outlineTitle(article)
+	root := HTMLToOutline(title, doc, roamStyle)
+	var sb strings.Builder
+	sb.WriteString("# " + title + "\n")
+	if refs := meta.DetectPageRefs(dom.TextContent(doc)); len(refs) > 0 {
+		for i, ref := range refs {
+			refs[i] = "[[" + ref + "]]"
+		}
+		sb.WriteString("- References:: " + strings.Join(refs, " ") + "\n")
+	}
+	for _, child := range root.Children {
+		writeOutline(&sb, child, 0)
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		log.Printf("error writing roam response: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestLoadTemplateFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "article.html")
+	custom := `<html><head><title>Custom: {{.Title}}</title></head><body>{{.Content}}</body></html>`
+	if err := os.WriteFile(path, []byte(custom), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+
+	oldTemplate := DefaultTemplate
+	DefaultTemplate = LoadTemplate(path)
+	defer func() { DefaultTemplate = oldTemplate }()
+
+	article := readability.Article{Node: &html.Node{Type: html.ElementNode, Data: "p"}}
+
+	rec := httptest.NewRecorder()
+	formatHTML(rec, article, bytes.NewBufferString("<p>custom body</p>"), Options{})
+	body := rec.Body.String()
+	if !strings.Contains(body, "<title>Custom: ") {
+		t.Errorf("formatHTML did not use the custom template, got: %q", body)
+	}
+	if !strings.Contains(body, "<p>custom body</p>") {
+		t.Errorf("custom template missing content, got: %q", body)
+	}
+}
+
+func TestLoadTemplateFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // empty means the file is not created
+	}{
+		{"missing file", ""},
+		{"invalid template", "<h1>{{.Title</h1>"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "article.html")
+		if tt.content != "" {
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write template: %v", err)
+			}
+		}
+		tmpl := LoadTemplate(path)
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, templateData{Title: "T"}); err != nil {
+			t.Fatalf("%s: fallback template failed to execute: %v", tt.name, err)
+		}
+		if !strings.Contains(buf.String(), "bookmarklet-theme.vercel.app") {
+			t.Errorf("%s: expected built-in template, got: %q", tt.name, buf.String())
+		}
+	}
+}
+
+func TestReloadTemplateKeepsCurrentOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "article.html")
+	if err := os.WriteFile(path, []byte("<h1>{{.Title</h1>"), 0o644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	t.Setenv("ARTICLE_TEMPLATE_PATH", path)
+
+	before := currentTemplate()
+	reloadTemplate()
+	if currentTemplate() != before {
+		t.Error("reloadTemplate replaced the template despite a parse error")
+	}
+}
@@ -0,0 +1,322 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/dom"
+	"github.com/lucasew/readability-web/internal/meta"
+	"golang.org/x/net/html"
+)
+
+/**
+ * formatText returns the plain text content, stripped of HTML tags.
+ *
+ * The article node is rendered by textWriter rather than passed through the
+ * pre-rendered HTML buffer, so /txt and format=text responses are actual plain
+ * text: blocks separated by blank lines, list items marked, quotes prefixed with
+ * "> " and links numbered, their URLs listed at the end. Lines are wrapped at
+ * opts.Text.Width columns when set. The result is passed through
+ * NormalizeWhitespace unless opts.Text asks for the text as is.
+ */
+func formatText(w http.ResponseWriter, article readability.Article, _ *bytes.Buffer, opts Options) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	t := textWriter{}
+	var blocks []string
+	if article.Node != nil {
+		blocks = t.render(article.Node, opts.Text.Width)
+	}
+	if len(t.links) > 0 {
+		notes := []string{"Links:"}
+		for i, link := range t.links {
+			notes = append(notes, fmt.Sprintf("[%d] %s", i+1, link))
+		}
+		blocks = append(blocks, strings.Join(notes, "\n"))
+	}
+	text := strings.Join(blocks, "\n\n") + "\n"
+	if !opts.Text.PreserveWhitespace {
+		text = NormalizeWhitespace(text) + "\n"
+	}
+	if _, err := io.WriteString(w, text); err != nil {
+		log.Printf("error writing text response: %v", err)
+	}
+}
+
+/**
+ * textWriter renders HTML as plain text for formatText. It collects the URLs of
+ * the links it meets, which the text refers to as "[n]".
+ */
+type textWriter struct {
+	links []string
+}
+
+/**
+ * textInline reports whether n is laid out inline by textWriter.
+ */
+func textInline(n *html.Node) bool {
+	return n.Type == html.TextNode || (n.Type == html.ElementNode && (slices.Contains(meta.InlineElements, n.Data) || n.Data == "img" || n.Data == "br"))
+}
+
+/**
+ * render returns the text blocks under n, wrapped at width columns (0 for no
+ * wrapping). Runs of text and inline elements outside of paragraphs are
+ * paragraphs of their own.
+ */
+func (t *textWriter) render(n *html.Node, width int) []string {
+	var blocks []string
+	add := func(block string) {
+		if strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if textInline(c) {
+			run := []*html.Node{c}
+			for c.NextSibling != nil && textInline(c.NextSibling) {
+				c = c.NextSibling
+				run = append(run, c)
+			}
+			add(t.paragraph(width, run...))
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "head", "script", "style", "noscript", "template":
+		case "h1", "h2":
+			heading := t.paragraph(width, c)
+			underline := "="
+			if c.Data == "h2" {
+				underline = "-"
+			}
+			longest := 0
+			for line := range strings.SplitSeq(heading, "\n") {
+				longest = max(longest, utf8.RuneCountInString(line))
+			}
+			add(heading + "\n" + strings.Repeat(underline, longest))
+		case "h3", "h4", "h5", "h6", "p", "dt", "caption", "figcaption", "summary":
+			add(t.paragraph(width, c))
+		case "pre":
+			add(strings.Trim(dom.TextContent(c), "\n"))
+		case "ul", "ol":
+			add(t.list(c, width))
+		case "blockquote":
+			var quote []string
+			for line := range strings.SplitSeq(strings.Join(t.render(c, narrower(width, 2)), "\n\n"), "\n") {
+				quote = append(quote, strings.TrimRight("> "+line, " "))
+			}
+			add(strings.Join(quote, "\n"))
+		case "table":
+			add(t.table(c))
+		case "hr":
+			add("* * *")
+		default:
+			blocks = append(blocks, t.render(c, width)...)
+		}
+	}
+	return blocks
+}
+
+/**
+ * list returns the items of a <ul> or <ol> as lines starting with "- " or "1. ",
+ * the following lines of an item (and nested lists) indented under its text.
+ */
+func (t *textWriter) list(list *html.Node, width int) string {
+	number, _ := strconv.Atoi(dom.Attr(list, "start"))
+	number = max(number, 1)
+	var lines []string
+	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if list.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		item := strings.Join(t.render(li, narrower(width, len(marker))), "\n")
+		if item == "" {
+			continue
+		}
+		for i, line := range strings.Split(item, "\n") {
+			switch {
+			case i == 0:
+				line = marker + line
+			case line != "":
+				line = strings.Repeat(" ", len(marker)) + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+/**
+ * table returns a line per row of a <table>, its cells separated by " | ".
+ */
+func (t *textWriter) table(table *html.Node) string {
+	var rows []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+			var cells []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					cells = append(cells, strings.ReplaceAll(t.paragraph(0, cell), "\n", " "))
+				}
+			}
+			rows = append(rows, strings.Join(cells, " | "))
+		}
+	}
+	walk(table)
+	return strings.Join(rows, "\n")
+}
+
+/**
+ * paragraph returns the text of nodes with whitespace collapsed, keeping the
+ * line breaks of <br>, wrapped at width columns.
+ */
+func (t *textWriter) paragraph(width int, nodes ...*html.Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		t.inline(&sb, n)
+	}
+	var lines []string
+	for line := range strings.SplitSeq(sb.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, wrapLine(line, width))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+/**
+ * inline writes the text of n to sb, with a "[n]" reference after each link
+ * and the alt text of images in brackets.
+ */
+func (t *textWriter) inline(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// newlines in the source are spaces; only <br> breaks lines
+		sb.WriteString(strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return ' '
+			}
+			return r
+		}, n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	switch n.Data {
+	case "script", "style", "noscript", "template":
+		return
+	case "br":
+		sb.WriteString("\n")
+		return
+	case "img":
+		if alt := strings.TrimSpace(dom.Attr(n, "alt")); alt != "" {
+			sb.WriteString(" [" + alt + "] ")
+		}
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		t.inline(sb, c)
+	}
+	if n.Data == "a" && strings.TrimSpace(dom.TextContent(n)) != "" {
+		if u, err := url.Parse(dom.Attr(n, "href")); err == nil && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "mailto") {
+			index := slices.Index(t.links, u.String())
+			if index < 0 {
+				t.links = append(t.links, u.String())
+				index = len(t.links) - 1
+			}
+			fmt.Fprintf(sb, " [%d]", index+1)
+		}
+	}
+}
+
+/**
+ * narrower returns the width left after indenting by indent columns, keeping
+ * 0 (no wrapping) as is.
+ */
+func narrower(width, indent int) int {
+	if width <= 0 {
+		return 0
+	}
+	return max(width-indent, 1)
+}
+
+/**
+ * wrapLine breaks line between words so that lines are at most width runes
+ * long, except for words longer than width. A width of 0 disables wrapping.
+ */
+func wrapLine(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	var sb strings.Builder
+	column := 0
+	for _, word := range strings.Fields(line) {
+		length := utf8.RuneCountInString(word)
+		if column > 0 && column+1+length > width {
+			sb.WriteString("\n")
+			column = 0
+		}
+		if column > 0 {
+			sb.WriteString(" ")
+			column++
+		}
+		sb.WriteString(word)
+		column += length
+	}
+	return sb.String()
+}
+
+/**
+ * NormalizeWhitespace cleans up plain text for display:
+ * it converts \r\n (and lone \r) line endings to \n, trims trailing spaces
+ * and tabs from every line, squashes runs of blank lines into a single one and
+ * drops blank lines at the start and end.
+ */
+func NormalizeWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	var sb strings.Builder
+	sb.Grow(len(text))
+	pendingBlank := false
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			pendingBlank = sb.Len() > 0
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+			if pendingBlank {
+				sb.WriteString("\n")
+			}
+		}
+		pendingBlank = false
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,27 @@
+package formatter
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+func TestFormatHTMLTheme(t *testing.T) {
+	for _, theme := range AvailableThemes() {
+		rec := httptest.NewRecorder()
+		formatHTML(rec, readability.Article{}, bytes.NewBufferString("<p>body</p>"), Options{Theme: theme})
+		body := rec.Body.String()
+		if theme.PreviewURL == "" {
+			if strings.Contains(body, `rel="stylesheet"`) {
+				t.Errorf("theme %q: unexpected stylesheet in %q", theme.Name, body)
+			}
+			continue
+		}
+		if !strings.Contains(body, `href="`+theme.PreviewURL+`"`) {
+			t.Errorf("theme %q: stylesheet %q missing from %q", theme.Name, theme.PreviewURL, body)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+func TestTweetLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"café — “quoted”", 15},
+		{"😀", 2},
+		{"👍🏽", 2},
+		{"❤️", 2},
+		{"👨‍👩‍👧", 6},
+		{"日本", 4},
+	}
+	for _, tt := range tests {
+		if got := TweetLength(tt.text); got != tt.want {
+			t.Errorf("TweetLength(%q) = %d; want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSplitIntoTweets(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   []string
+	}{
+		{"packs sentences", "One. Two! Three? Four.", 10, []string{"One. Two!", "Three?", "Four."}},
+		{"keeps sentences whole", "First sentence here. Second one.", 25, []string{"First sentence here.", "Second one."}},
+		{"paragraphs", "Para one\nPara two", 100, []string{"Para one Para two"}},
+		{"long sentence splits between words", "aaa bbb ccc ddd", 8, []string{"aaa bbb", "ccc ddd"}},
+		{"long word", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"emoji count double", "😀😀😀 ok", 6, []string{"😀😀😀", "ok"}},
+		{"empty", "  \n ", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitIntoTweets(tt.text, tt.maxLen)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SplitIntoTweets(%q, %d) = %q; want %q", tt.text, tt.maxLen, got, tt.want)
+			}
+			for _, tweet := range got {
+				if TweetLength(tweet) > tt.maxLen {
+					t.Errorf("tweet %q is longer than %d", tweet, tt.maxLen)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatTwitterThread(t *testing.T) {
+	text := strings.Repeat("This sentence has exactly fifty characters in it. ", 30) + "Done 🎉."
+	node, err := html.Parse(strings.NewReader("<p>" + text + "</p>"))
+	if err != nil {
+		t.Fatalf("failed to parse content: %v", err)
+	}
+	link, _ := url.Parse("https://example.com/post")
+	rec := httptest.NewRecorder()
+	formatTwitterThread(rec, readability.Article{Node: node}, &bytes.Buffer{}, Options{Link: link})
+
+	var tweets []string
+	if err := json.Unmarshal(rec.Body.Bytes(), &tweets); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(tweets) < 7 {
+		t.Fatalf("got %d tweets; want the text split in several", len(tweets))
+	}
+	prefix := regexp.MustCompile(`^(\d+)/(\d+): `)
+	for i, tweet := range tweets {
+		if TweetLength(tweet) > maxTweetLength {
+			t.Errorf("tweet %d is %d characters long", i+1, TweetLength(tweet))
+		}
+		m := prefix.FindStringSubmatch(tweet)
+		if m == nil || m[1] != strconv.Itoa(i+1) || m[2] != strconv.Itoa(len(tweets)) {
+			t.Errorf("tweet %d is not numbered: %q", i+1, tweet)
+		}
+		if body := prefix.ReplaceAllString(tweet, ""); i < len(tweets)-1 && !strings.HasSuffix(body, ".") {
+			t.Errorf("tweet %d breaks mid-sentence: %q", i+1, tweet)
+		}
+	}
+	if last := tweets[len(tweets)-1]; !strings.HasSuffix(last, ": https://example.com/post") {
+		t.Errorf("last tweet = %q; want the URL", last)
+	}
+}
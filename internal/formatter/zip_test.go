@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/lucasew/readability-web/internal/meta"
+)
+
+func TestFormatZip(t *testing.T) {
+	theme, _ := LookupTheme("none")
+	const content = `<h2>Intro</h2><p>Zipped body</p><img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" alt="dot"><h3>More</h3>`
+	rec := httptest.NewRecorder()
+	formatZip(rec, readability.Article{}, bytes.NewBufferString(content), Options{Theme: theme})
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %q; want application/zip", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="article.zip"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("response is not a zip archive: %v", err)
+	}
+	files := map[string]string{}
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Name, err)
+		}
+		names = append(names, f.Name)
+		files[f.Name] = string(data)
+	}
+	if want := []string{"article.html", "article.md", "metadata.json", "images/001.gif"}; !slices.Equal(names, want) {
+		t.Errorf("archive files = %q; want %q", names, want)
+	}
+	if !strings.Contains(files["article.html"], `src="images/001.gif"`) || strings.Contains(files["article.html"], "data:image") {
+		t.Errorf("article.html does not point at the image file:\n%s", files["article.html"])
+	}
+	if !strings.Contains(files["article.md"], "Zipped body") {
+		t.Errorf("article.md missing content:\n%s", files["article.md"])
+	}
+	if files["images/001.gif"][:3] != "GIF" {
+		t.Errorf("image file is not the decoded GIF: %q", files["images/001.gif"])
+	}
+
+	var metadata struct {
+		Content string          `json:"content"`
+		TOC     []meta.TOCEntry `json:"toc"`
+		Images  []struct {
+			File string `json:"file"`
+			Alt  string `json:"alt"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal([]byte(files["metadata.json"]), &metadata); err != nil {
+		t.Fatalf("metadata.json is not valid JSON: %v", err)
+	}
+	if want := []meta.TOCEntry{{Level: 2, Text: "Intro"}, {Level: 3, Text: "More"}}; !slices.Equal(metadata.TOC, want) {
+		t.Errorf("toc = %+v; want %+v", metadata.TOC, want)
+	}
+	if len(metadata.Images) != 1 || metadata.Images[0].File != "images/001.gif" || metadata.Images[0].Alt != "dot" {
+		t.Errorf("images = %+v", metadata.Images)
+	}
+	if !strings.Contains(metadata.Content, "Zipped body") {
+		t.Errorf("content = %q", metadata.Content)
+	}
+}
@@ -0,0 +1,56 @@
+/**
+ * Package log carries the per-request log context the access log reports.
+ */
+package log
+
+import "context"
+
+/**
+ * FetchAttempt records one upstream request made while fetching an article, for the `_debug` output.
+ */
+type FetchAttempt struct {
+	URL       string `json:"url"`
+	UserAgent string `json:"user_agent"`
+	Status    int    `json:"status"`
+}
+
+/**
+ * LogContext collects per-request fields for the access log.
+ *
+ * It is stored in the request context and filled in progressively as the request
+ * moves through the pipeline (URL and format in the handler, durations in
+ * article.Fetch, status in the middleware), then emitted as a single event.
+ */
+type LogContext struct {
+	ArticleURL      string
+	Format          string
+	RequestID       string
+	FetchDurationMs int64
+	ParseDurationMs int64
+	Status          int
+	// FetchAttempts lists the upstream requests made, with their User-Agent and status.
+	FetchAttempts []FetchAttempt
+}
+
+// logContextKey is the context key for the request's *LogContext.
+type logContextKey struct{}
+
+/**
+ * NewContext returns a copy of ctx carrying lc, for FromContext.
+ */
+func NewContext(ctx context.Context, lc *LogContext) context.Context {
+	return context.WithValue(ctx, logContextKey{}, lc)
+}
+
+/**
+ * FromContext returns the LogContext attached to ctx.
+ *
+ * When there is none (e.g. functions called directly in tests), a throwaway
+ * value is returned so callers never need to nil-check.
+ */
+func FromContext(ctx context.Context) *LogContext {
+	if lc, ok := ctx.Value(logContextKey{}).(*LogContext); ok {
+		return lc
+	}
+	return &LogContext{}
+}
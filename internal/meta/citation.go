@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"cmp"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/readeck/go-readability/v2"
+)
+
+/**
+ * ArticleMeta is the bibliographic data of an article, as cited by BuildBibTeX and
+ * BuildRIS.
+ */
+type ArticleMeta struct {
+	Title string
+	// Authors holds one name per author, empty when the article has no byline.
+	Authors []string
+	URL     string
+	// Publisher is the domain name of the site, without "www.".
+	Publisher string
+	// Published is the publication date, zero when unknown.
+	Published time.Time
+}
+
+// bylinePrefixPattern matches the "By" most bylines start with.
+var bylinePrefixPattern = regexp.MustCompile(`(?i)^by\s+`)
+
+// bylineSeparatorPattern matches the separators of the authors in a byline.
+var bylineSeparatorPattern = regexp.MustCompile(`\s+(?:and|&)\s+`)
+
+/**
+ * NewArticleMeta collects the bibliographic data of the article. The URL is the
+ * canonical one when the page declares it.
+ */
+func NewArticleMeta(article readability.Article, link *url.URL) ArticleMeta {
+	meta := ArticleMeta{Title: strings.Join(strings.Fields(article.Title()), " ")}
+	if byline := bylinePrefixPattern.ReplaceAllString(strings.TrimSpace(article.Byline()), ""); byline != "" {
+		for _, author := range bylineSeparatorPattern.Split(byline, -1) {
+			if author = strings.TrimSpace(author); author != "" {
+				meta.Authors = append(meta.Authors, author)
+			}
+		}
+	}
+	if link != nil {
+		meta.URL = link.String()
+		meta.Publisher = strings.TrimPrefix(link.Hostname(), "www.")
+	}
+	if published, err := article.PublishedTime(); err == nil {
+		meta.Published = published
+	}
+	return meta
+}
+
+// bibTeXEscaper escapes the characters LaTeX reads as commands.
+var bibTeXEscaper = strings.NewReplacer(`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`,
+	"$", `\$`, "#", `\#`, "_", `\_`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`)
+
+// bibTeXKeyPattern matches the characters left out of citation keys.
+var bibTeXKeyPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+/**
+ * BuildBibTeX returns meta as a BibTeX @article entry, with the fields the entry
+ * type requires (author, title, journal, year) and the url. The site stands for
+ * the journal, and for the author when there is no byline. year is left out when
+ * the publication date is unknown.
+ *
+ * The citation key is the last name of the first author, the year and the first
+ * word of the title ("doe2024hello").
+ */
+func BuildBibTeX(meta ArticleMeta) string {
+	var key strings.Builder
+	if len(meta.Authors) > 0 {
+		names := strings.Fields(meta.Authors[0])
+		key.WriteString(bibTeXKeyPattern.ReplaceAllString(strings.ToLower(names[len(names)-1]), ""))
+	}
+	if !meta.Published.IsZero() {
+		key.WriteString(strconv.Itoa(meta.Published.Year()))
+	}
+	for word := range strings.FieldsSeq(strings.ToLower(meta.Title)) {
+		if word = bibTeXKeyPattern.ReplaceAllString(word, ""); word != "" {
+			key.WriteString(word)
+			break
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("@article{" + cmp.Or(key.String(), "article") + ",\n")
+	field := func(name, value string) {
+		if value != "" {
+			sb.WriteString("  " + name + " = {" + value + "},\n")
+		}
+	}
+	authors := make([]string, 0, len(meta.Authors))
+	for _, author := range meta.Authors {
+		authors = append(authors, bibTeXEscaper.Replace(author))
+	}
+	if len(authors) == 0 && meta.Publisher != "" {
+		// braced, so the site isn't split into first and last names
+		authors = append(authors, "{"+bibTeXEscaper.Replace(meta.Publisher)+"}")
+	}
+	field("author", strings.Join(authors, " and "))
+	field("title", bibTeXEscaper.Replace(meta.Title))
+	field("journal", bibTeXEscaper.Replace(meta.Publisher))
+	field("publisher", bibTeXEscaper.Replace(meta.Publisher))
+	if !meta.Published.IsZero() {
+		field("year", strconv.Itoa(meta.Published.Year()))
+		field("month", strings.ToLower(meta.Published.Month().String()[:3]))
+	}
+	field("url", meta.URL)
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+/**
+ * BuildRIS returns meta as a RIS record of type JOUR: TI (title), AU (one per
+ * author), T2 and PB (the site), PY and DA (publication date), UR (url), closed by
+ * ER. Lines end with CRLF, as the RIS specification asks.
+ */
+func BuildRIS(meta ArticleMeta) string {
+	var sb strings.Builder
+	field := func(tag, value string) {
+		// a line break would start a new field
+		if value = strings.Join(strings.Fields(value), " "); value != "" || tag == "ER" {
+			sb.WriteString(tag + "  - " + value + "\r\n")
+		}
+	}
+	field("TY", "JOUR")
+	field("TI", meta.Title)
+	for _, author := range meta.Authors {
+		field("AU", author)
+	}
+	field("T2", meta.Publisher)
+	field("PB", meta.Publisher)
+	if !meta.Published.IsZero() {
+		field("PY", strconv.Itoa(meta.Published.Year()))
+		field("DA", meta.Published.Format("2006/01/02/"))
+	}
+	field("UR", meta.URL)
+	field("ER", "")
+	return sb.String()
+}
@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var citationMeta = ArticleMeta{
+	Title:     "Hello, World: 100% of {Go} & more",
+	Authors:   []string{"Jane Doe", "John Smith"},
+	URL:       "https://www.example.com/post",
+	Publisher: "example.com",
+	Published: time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+}
+
+func TestBuildBibTeX(t *testing.T) {
+	got := BuildBibTeX(citationMeta)
+	want := `@article{doe2024hello,
+  author = {Jane Doe and John Smith},
+  title = {Hello, World: 100\% of \{Go\} \& more},
+  journal = {example.com},
+  publisher = {example.com},
+  year = {2024},
+  month = {may},
+  url = {https://www.example.com/post},
+}
+`
+	if got != want {
+		t.Errorf("BuildBibTeX() =\n%s\nwant\n%s", got, want)
+	}
+
+	// the fields an @article entry requires
+	for _, field := range []string{"author", "title", "journal", "year"} {
+		if !regexp.MustCompile(`(?m)^  ` + field + ` = \{.+\},$`).MatchString(got) {
+			t.Errorf("mandatory field %s missing", field)
+		}
+	}
+
+	anonymous := BuildBibTeX(ArticleMeta{Title: "Untitled", Publisher: "example.com"})
+	if !strings.HasPrefix(anonymous, "@article{untitled,\n") || !strings.Contains(anonymous, "author = {{example.com}},") {
+		t.Errorf("entry without byline or date = %s", anonymous)
+	}
+	if strings.Contains(anonymous, "year") {
+		t.Errorf("year set without a publication date: %s", anonymous)
+	}
+}
+
+func TestBuildRIS(t *testing.T) {
+	got := BuildRIS(citationMeta)
+	want := "TY  - JOUR\r\n" +
+		"TI  - Hello, World: 100% of {Go} & more\r\n" +
+		"AU  - Jane Doe\r\n" +
+		"AU  - John Smith\r\n" +
+		"T2  - example.com\r\n" +
+		"PB  - example.com\r\n" +
+		"PY  - 2024\r\n" +
+		"DA  - 2024/05/01/\r\n" +
+		"UR  - https://www.example.com/post\r\n" +
+		"ER  - \r\n"
+	if got != want {
+		t.Errorf("BuildRIS() = %q; want %q", got, want)
+	}
+
+	// every line is a two character tag, two spaces, a dash and a space
+	tag := regexp.MustCompile(`^[A-Z][A-Z0-9]  - `)
+	for line := range strings.SplitSeq(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if !tag.MatchString(line) {
+			t.Errorf("invalid RIS line %q", line)
+		}
+	}
+	if multiline := BuildRIS(ArticleMeta{Title: "Two\nlines"}); !strings.Contains(multiline, "TI  - Two lines\r\n") {
+		t.Errorf("line breaks kept in a field: %q", multiline)
+	}
+}
@@ -0,0 +1,112 @@
+package meta
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/**
+ * Flashcard is a question and its answer, as imported by Anki.
+ */
+type Flashcard struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+// flashcardBlocks are the elements whose sentences ExtractFlashcards turns into cards.
+var flashcardBlocks = []string{"p", "li", "dt", "dd", "td", "th", "blockquote", "figcaption"}
+
+// flashcardBlank replaces the answer in the question of a Flashcard.
+const flashcardBlank = "_____"
+
+/**
+ * ExtractFlashcards returns a cloze card for every bold (<strong>, <b>) term of
+ * node: the front is the sentence holding the term with the term blanked out,
+ * the back is the whole sentence with the term in bold. A sentence with several
+ * terms makes one card per term. Cards are escaped HTML text but for that <b>,
+ * with whitespace collapsed (so they never hold tabs or line breaks).
+ */
+func ExtractFlashcards(node *html.Node) []Flashcard {
+	cards := []Flashcard{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && slices.Contains(flashcardBlocks, n.Data) {
+			cards = append(cards, blockFlashcards(n)...)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+	return cards
+}
+
+/**
+ * blockFlashcards returns the cards of the text of block, leaving out the nested
+ * blocks ExtractFlashcards visits on their own.
+ */
+func blockFlashcards(block *html.Node) []Flashcard {
+	var sb strings.Builder
+	var terms [][2]int
+	space := false
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				space = space || strings.TrimLeft(c.Data, " \t\n\r") != c.Data
+				for word := range strings.FieldsSeq(c.Data) {
+					if space && sb.Len() > 0 {
+						sb.WriteString(" ")
+					}
+					sb.WriteString(word)
+					space = true
+				}
+				space = strings.TrimRight(c.Data, " \t\n\r") != c.Data || (space && strings.TrimSpace(c.Data) == "")
+			case c.Type != html.ElementNode:
+			case slices.Contains(flashcardBlocks, c.Data), c.Data == "ul", c.Data == "ol", c.Data == "dl", c.Data == "table":
+			case c.Data == "script", c.Data == "style", c.Data == "noscript", c.Data == "template":
+			case c.Data == "br":
+				space = true
+			case c.Data == "b", c.Data == "strong":
+				start := sb.Len()
+				collect(c)
+				terms = append(terms, [2]int{start, sb.Len()})
+			default:
+				collect(c)
+			}
+		}
+	}
+	collect(block)
+	text := sb.String()
+
+	var cards []Flashcard
+	from := 0
+	ends := append(SentenceEndPattern.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, end := range ends {
+		to := end[1]
+		for _, term := range terms {
+			// a term running into the next sentence is cut at the end of its own
+			start := term[0]
+			for start < len(text) && text[start] == ' ' {
+				start++
+			}
+			if start < from || start >= to {
+				continue
+			}
+			answer := strings.TrimSpace(text[start:min(term[1], to)])
+			if answer == "" {
+				continue
+			}
+			before, after := html.EscapeString(text[from:start]), html.EscapeString(text[start+len(answer):to])
+			cards = append(cards, Flashcard{
+				Front: strings.TrimSpace(before + flashcardBlank + after),
+				Back:  strings.TrimSpace(before + "<b>" + html.EscapeString(answer) + "</b>" + after),
+			})
+		}
+		from = to
+	}
+	return cards
+}
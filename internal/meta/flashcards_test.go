@@ -0,0 +1,57 @@
+package meta
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractFlashcards(t *testing.T) {
+	tests := []struct {
+		name, input string
+		want        []Flashcard
+	}{
+		{
+			"single term",
+			`<p>Cells get energy from the <strong>mitochondria</strong>. Other text.</p>`,
+			[]Flashcard{{"Cells get energy from the _____.", "Cells get energy from the <b>mitochondria</b>."}},
+		},
+		{
+			"several terms in one sentence",
+			`<p>The <b>heart</b> pumps blood through
+			the <strong>arteries</strong> and <b>veins</b>.</p>`,
+			[]Flashcard{
+				{"The _____ pumps blood through the arteries and veins.", "The <b>heart</b> pumps blood through the arteries and veins."},
+				{"The heart pumps blood through the _____ and veins.", "The heart pumps blood through the <b>arteries</b> and veins."},
+				{"The heart pumps blood through the arteries and _____.", "The heart pumps blood through the arteries and <b>veins</b>."},
+			},
+		},
+		{
+			"terms in different sentences",
+			`<p>First is <b>alpha</b>. Last is <b>omega</b>!</p>`,
+			[]Flashcard{{"First is _____.", "First is <b>alpha</b>."}, {"Last is _____!", "Last is <b>omega</b>!"}},
+		},
+		{
+			"nested blocks and escaping",
+			`<ul><li><b>Ohm's law</b>: V = I &lt; R<ul><li>See <b>Kirchhoff</b></li></ul></li></ul>`,
+			[]Flashcard{
+				{"_____: V = I &lt; R", "<b>Ohm&#39;s law</b>: V = I &lt; R"},
+				{"See _____", "See <b>Kirchhoff</b>"},
+			},
+		},
+		{"no bold", `<p>Nothing to learn here.</p><div><b>Loose</b> text</div>`, []Flashcard{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("failed to parse content: %v", err)
+			}
+			if got := ExtractFlashcards(doc); !slices.Equal(got, tt.want) {
+				t.Errorf("ExtractFlashcards() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
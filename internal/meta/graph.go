@@ -0,0 +1,127 @@
+package meta
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+/**
+ * Graph is a co-occurrence graph of the named entities in a text.
+ */
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an entity and how many times it is mentioned.
+type GraphNode struct {
+	ID       string `json:"id"`
+	Mentions int    `json:"mentions"`
+}
+
+// GraphEdge links two entities mentioned in Weight sentences together.
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Weight int    `json:"weight"`
+}
+
+var (
+	// SentenceEndPattern matches the punctuation and spacing between two sentences.
+	SentenceEndPattern = regexp.MustCompile(`[.!?]+["'”’)\]]*\s+|\n+`)
+	// entityPattern matches runs of capitalized words, the candidate entities.
+	entityPattern = regexp.MustCompile(`\p{Lu}[\p{L}\p{M}\p{N}'’-]*(?:[ \t]+\p{Lu}[\p{L}\p{M}\p{N}'’-]*)*`)
+)
+
+/**
+ * sentenceStarters are capitalized only for starting a sentence, so they are
+ * dropped from the front of an entity found there.
+ */
+var sentenceStarters = []string{
+	"A", "After", "All", "An", "And", "As", "At", "But", "By", "For", "From", "He", "Her", "His", "I", "If",
+	"In", "It", "Its", "On", "One", "Our", "She", "So", "That", "The", "Their", "There", "These", "They",
+	"This", "Those", "To", "We", "When", "While", "With", "You",
+}
+
+/**
+ * BuildCooccurrenceGraph finds the named entities of text and links the ones
+ * mentioned in the same sentence.
+ *
+ * Entities are found with a capitalization heuristic: runs of capitalized words
+ * ("Ada Lovelace", "New York"). A single word starting a sentence is only taken
+ * when the same word is capitalized in the middle of some sentence too, as it may
+ * be capitalized just for being first. Nodes are sorted by mentions and edges by
+ * weight, most frequent first.
+ */
+func BuildCooccurrenceGraph(text string) Graph {
+	type mention struct {
+		entity    string
+		ambiguous bool
+	}
+	var sentences [][]mention
+	// entities seen where their capitalization is meaningful
+	midSentence := map[string]bool{}
+	start := 0
+	ends := append(SentenceEndPattern.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, end := range ends {
+		sentence := text[start:end[0]]
+		start = end[1]
+		var mentions []mention
+		for _, loc := range entityPattern.FindAllStringIndex(sentence, -1) {
+			words := strings.Fields(sentence[loc[0]:loc[1]])
+			first := strings.TrimSpace(sentence[:loc[0]]) == ""
+			ambiguous := first && len(words) == 1
+			if first && slices.Contains(sentenceStarters, words[0]) {
+				words = words[1:]
+			}
+			// also skips "I", which is capitalized anywhere
+			if len(words) == 0 || (len(words) == 1 && slices.Contains(sentenceStarters, words[0])) {
+				continue
+			}
+			entity := strings.Join(words, " ")
+			mentions = append(mentions, mention{entity, ambiguous})
+			if !ambiguous {
+				midSentence[entity] = true
+			}
+		}
+		sentences = append(sentences, mentions)
+	}
+
+	counts := map[string]int{}
+	weights := map[[2]string]int{}
+	for _, mentions := range sentences {
+		var entities []string
+		for _, m := range mentions {
+			if m.ambiguous && !midSentence[m.entity] {
+				continue
+			}
+			counts[m.entity]++
+			if !slices.Contains(entities, m.entity) {
+				entities = append(entities, m.entity)
+			}
+		}
+		slices.Sort(entities)
+		for i, a := range entities {
+			for _, b := range entities[i+1:] {
+				weights[[2]string{a, b}]++
+			}
+		}
+	}
+
+	graph := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for id, n := range counts {
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: id, Mentions: n})
+	}
+	for pair, n := range weights {
+		graph.Edges = append(graph.Edges, GraphEdge{Source: pair[0], Target: pair[1], Weight: n})
+	}
+	slices.SortFunc(graph.Nodes, func(a, b GraphNode) int {
+		return cmp.Or(cmp.Compare(b.Mentions, a.Mentions), cmp.Compare(a.ID, b.ID))
+	})
+	slices.SortFunc(graph.Edges, func(a, b GraphEdge) int {
+		return cmp.Or(cmp.Compare(b.Weight, a.Weight), cmp.Compare(a.Source, b.Source), cmp.Compare(a.Target, b.Target))
+	})
+	return graph
+}
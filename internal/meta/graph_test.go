@@ -0,0 +1,36 @@
+package meta
+
+import (
+	"reflect"
+	"testing"
+)
+
+const graphText = `Ada Lovelace worked with Charles Babbage in London.
+The Analytical Engine was designed by Charles Babbage.
+Later, Lovelace wrote notes about the Analytical Engine. She published them in London!
+However, the notes went unnoticed for a century. I think Ada Lovelace deserved better.`
+
+func TestBuildCooccurrenceGraph(t *testing.T) {
+	got := BuildCooccurrenceGraph(graphText)
+
+	wantNodes := []GraphNode{
+		{ID: "Ada Lovelace", Mentions: 2},
+		{ID: "Analytical Engine", Mentions: 2},
+		{ID: "Charles Babbage", Mentions: 2},
+		{ID: "London", Mentions: 2},
+		{ID: "Lovelace", Mentions: 1},
+	}
+	if !reflect.DeepEqual(got.Nodes, wantNodes) {
+		t.Errorf("nodes = %+v; want %+v", got.Nodes, wantNodes)
+	}
+	wantEdges := []GraphEdge{
+		{Source: "Ada Lovelace", Target: "Charles Babbage", Weight: 1},
+		{Source: "Ada Lovelace", Target: "London", Weight: 1},
+		{Source: "Analytical Engine", Target: "Charles Babbage", Weight: 1},
+		{Source: "Analytical Engine", Target: "Lovelace", Weight: 1},
+		{Source: "Charles Babbage", Target: "London", Weight: 1},
+	}
+	if !reflect.DeepEqual(got.Edges, wantEdges) {
+		t.Errorf("edges = %+v; want %+v", got.Edges, wantEdges)
+	}
+}
@@ -0,0 +1,81 @@
+package meta
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * MediaElement is an audio or video file, or a text track, embedded in the article.
+ */
+type MediaElement struct {
+	URL string `json:"url"`
+	// Type is the declared MIME type, empty when the page doesn't tell.
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	// Kind is "audio", "video" or, for <track>, its kind ("subtitles", "captions", ...).
+	Kind string `json:"kind"`
+	// Lang is the language of a text track.
+	Lang string `json:"lang,omitempty"`
+}
+
+/**
+ * ExtractMediaElements lists the media files of <audio> and <video> elements: their
+ * own src, every fallback <source> and the <track> subtitles.
+ *
+ * URLs are resolved against base (which may be nil); URLs that are not http(s),
+ * such as blob: or data:, are skipped, as are repeated URLs.
+ */
+func ExtractMediaElements(node *html.Node, base *url.URL) []MediaElement {
+	media := []MediaElement{}
+	add := func(raw string, element MediaElement) {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || raw == "" {
+			return
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return
+		}
+		element.URL = u.String()
+		if !slices.ContainsFunc(media, func(m MediaElement) bool { return m.URL == element.URL }) {
+			media = append(media, element)
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "audio" || n.Data == "video") {
+			label := strings.TrimSpace(cmp.Or(dom.Attr(n, "title"), dom.Attr(n, "aria-label")))
+			add(dom.Attr(n, "src"), MediaElement{Type: dom.Attr(n, "type"), Label: label, Kind: n.Data})
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type != html.ElementNode {
+					continue
+				}
+				switch c.Data {
+				case "source":
+					add(dom.Attr(c, "src"), MediaElement{Type: dom.Attr(c, "type"), Label: label, Kind: n.Data})
+				case "track":
+					add(dom.Attr(c, "src"), MediaElement{
+						Label: strings.TrimSpace(cmp.Or(dom.Attr(c, "label"), dom.Attr(c, "srclang"))),
+						Kind:  cmp.Or(dom.Attr(c, "kind"), "subtitles"),
+						Lang:  dom.Attr(c, "srclang"),
+					})
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+	return media
+}
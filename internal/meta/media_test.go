@@ -0,0 +1,42 @@
+package meta
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractMediaElements(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<article>
+<p>Episode 42 show notes.</p>
+<audio title="Episode 42" controls>
+	<source src="/media/ep42.opus" type="audio/ogg; codecs=opus">
+	<source src="/media/ep42.mp3" type="audio/mpeg">
+	<p>Your browser can't play it, <a href="/media/ep42.mp3">download it</a>.</p>
+</audio>
+<video src="https://cdn.example.com/interview.mp4" type="video/mp4" aria-label="Interview">
+	<source src="https://cdn.example.com/interview.mp4">
+	<source src="blob:https://example.com/1234">
+	<track src="subs/en.vtt" kind="captions" srclang="en" label="English">
+	<track src="subs/pt.vtt" srclang="pt">
+</video>
+</article>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://example.com/podcast/42")
+
+	want := []MediaElement{
+		{URL: "https://example.com/media/ep42.opus", Type: "audio/ogg; codecs=opus", Label: "Episode 42", Kind: "audio"},
+		{URL: "https://example.com/media/ep42.mp3", Type: "audio/mpeg", Label: "Episode 42", Kind: "audio"},
+		{URL: "https://cdn.example.com/interview.mp4", Type: "video/mp4", Label: "Interview", Kind: "video"},
+		{URL: "https://example.com/podcast/subs/en.vtt", Label: "English", Kind: "captions", Lang: "en"},
+		{URL: "https://example.com/podcast/subs/pt.vtt", Label: "pt", Kind: "subtitles", Lang: "pt"},
+	}
+	if got := ExtractMediaElements(doc, base); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractMediaElements() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,245 @@
+package meta
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * ExtractKeywords returns the keywords a page declares: the comma separated list
+ * of its <meta name="keywords">, then its <meta property="article:tag"> values.
+ * Duplicates (ignoring case) and blank entries are dropped. node may be nil.
+ */
+func ExtractKeywords(node *html.Node) []string {
+	var keywords []string
+	add := func(keyword string) {
+		keyword = strings.Join(strings.Fields(keyword), " ")
+		if keyword != "" && !slices.ContainsFunc(keywords, func(k string) bool { return strings.EqualFold(k, keyword) }) {
+			keywords = append(keywords, keyword)
+		}
+	}
+	var tags []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			switch {
+			case strings.EqualFold(dom.Attr(n, "name"), "keywords"):
+				for keyword := range strings.SplitSeq(dom.Attr(n, "content"), ",") {
+					add(keyword)
+				}
+			case dom.Attr(n, "property") == "article:tag":
+				tags = append(tags, dom.Attr(n, "content"))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if node != nil {
+		walk(node)
+	}
+	for _, tag := range tags {
+		add(tag)
+	}
+	return keywords
+}
+
+/**
+ * Value returns the content of the first <meta> of node whose name or property
+ * is one of keys (ignoring case), trying keys in order. node may be nil.
+ */
+func Value(node *html.Node, keys ...string) string {
+	if node == nil {
+		return ""
+	}
+	values := map[string]string{}
+	for _, meta := range cascadia.QueryAll(node, metaSelector) {
+		key := strings.ToLower(cmp.Or(dom.Attr(meta, "property"), dom.Attr(meta, "name")))
+		if _, seen := values[key]; !seen {
+			values[key] = strings.TrimSpace(dom.Attr(meta, "content"))
+		}
+	}
+	for _, key := range keys {
+		if value := values[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// metaSelector matches the <meta> elements Value looks at.
+var metaSelector = cascadia.MustCompile("meta[content]")
+
+/**
+ * Lang returns the language the page declares on its <html> element.
+ * node may be nil.
+ */
+func Lang(node *html.Node) string {
+	if node == nil {
+		return ""
+	}
+	if root := dom.FindElement(node, "html"); root != nil {
+		return strings.TrimSpace(dom.Attr(root, "lang"))
+	}
+	return ""
+}
+
+// ContentImage is an image of the article content.
+type ContentImage struct {
+	URL string `json:"url"`
+	Alt string `json:"alt"`
+}
+
+// ContentLink is a link of the article content.
+type ContentLink struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+}
+
+/**
+ * ExtractOpenGraph returns the OpenGraph properties a page declares in its
+ * <meta property="og:..."> elements, keyed by property ("og:title", "og:image"...).
+ * The first value of a repeated property wins. node may be nil.
+ */
+func ExtractOpenGraph(node *html.Node) map[string]string {
+	og := map[string]string{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			property := strings.ToLower(strings.TrimSpace(dom.Attr(n, "property")))
+			if _, seen := og[property]; strings.HasPrefix(property, "og:") && !seen {
+				og[property] = strings.TrimSpace(dom.Attr(n, "content"))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if node != nil {
+		walk(node)
+	}
+	return og
+}
+
+/**
+ * ExtractJSONLD returns the JSON-LD documents of a page's
+ * <script type="application/ld+json"> elements. Scripts holding an array
+ * contribute each of its items; invalid ones are skipped. node may be nil.
+ */
+func ExtractJSONLD(node *html.Node) []any {
+	docs := []any{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" {
+			if mediaType, _, _ := mime.ParseMediaType(dom.Attr(n, "type")); mediaType == "application/ld+json" && n.FirstChild != nil {
+				var value any
+				if err := json.Unmarshal([]byte(n.FirstChild.Data), &value); err != nil {
+					log.Printf("skipping invalid JSON-LD: %v", err)
+				} else if items, isArray := value.([]any); isArray {
+					docs = append(docs, items...)
+				} else {
+					docs = append(docs, value)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	if node != nil {
+		walk(node)
+	}
+	return docs
+}
+
+/**
+ * TOCEntry is a heading of the article, for tables of contents.
+ */
+type TOCEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+/**
+ * ExtractTOC returns the headings of doc in document order.
+ */
+func ExtractTOC(doc *html.Node) []TOCEntry {
+	toc := []TOCEntry{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && len(n.Data) == 2 && n.Data[0] == 'h' && n.Data[1] >= '1' && n.Data[1] <= '6' {
+			if text := strings.Join(strings.Fields(dom.TextContent(n)), " "); text != "" {
+				toc = append(toc, TOCEntry{Level: int(n.Data[1] - '0'), Text: text})
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return toc
+}
+
+/**
+ * ExtractCanonicalURL returns the canonical URL a page declares for itself: its
+ * <link rel="canonical">, falling back to <meta property="og:url">.
+ *
+ * Relative URLs are resolved against base (which may be nil). It returns nil when
+ * the page declares neither, or when the declared URL is not http(s).
+ */
+func ExtractCanonicalURL(node *html.Node, base *url.URL) *url.URL {
+	var canonical, ogURL string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "link" && canonical == "" && slices.Contains(strings.Fields(strings.ToLower(dom.Attr(n, "rel"))), "canonical"):
+				canonical = strings.TrimSpace(dom.Attr(n, "href"))
+			case n.Data == "meta" && ogURL == "" && dom.Attr(n, "property") == "og:url":
+				ogURL = strings.TrimSpace(dom.Attr(n, "content"))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	for _, raw := range []string{canonical, ogURL} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return u
+		}
+	}
+	return nil
+}
+
+/**
+ * SameSite reports whether a and b are on the same host, ignoring a leading "www.".
+ */
+func SameSite(a, b *url.URL) bool {
+	host := func(u *url.URL) string {
+		return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+	return host(a) == host(b)
+}
@@ -0,0 +1,54 @@
+package meta
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractCanonicalURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1?utm_source=feed")
+	tests := []struct {
+		name     string
+		head     string
+		expected string
+	}{
+		{"link rel canonical", `<link rel="canonical" href="https://example.com/articles/1"><meta property="og:url" content="https://example.com/og">`, "https://example.com/articles/1"},
+		{"relative canonical", `<link rel="canonical" href="/articles/1">`, "https://example.com/articles/1"},
+		{"og:url fallback", `<meta property="og:url" content="https://example.com/og">`, "https://example.com/og"},
+		{"invalid canonical falls back to og:url", `<link rel="canonical" href="javascript:alert(1)"><meta property="og:url" content="https://example.com/og">`, "https://example.com/og"},
+		{"non-http only", `<link rel="canonical" href="ftp://example.com/file">`, ""},
+		{"none", `<link rel="stylesheet" href="/style.css">`, ""},
+	}
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader("<html><head>" + tt.head + "</head><body></body></html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ExtractCanonicalURL(doc, base)
+		if tt.expected == "" {
+			if got != nil {
+				t.Errorf("%s: ExtractCanonicalURL = %q; want nil", tt.name, got)
+			}
+			continue
+		}
+		if got == nil || got.String() != tt.expected {
+			t.Errorf("%s: ExtractCanonicalURL = %v; want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestSameSite(t *testing.T) {
+	parse := func(raw string) *url.URL {
+		u, _ := url.Parse(raw)
+		return u
+	}
+	if !SameSite(parse("https://www.Example.com/a"), parse("http://example.com:8080/b")) {
+		t.Errorf("www. prefix, case and port should not make sites differ")
+	}
+	if SameSite(parse("https://example.com/"), parse("https://example.org/")) {
+		t.Errorf("different domains reported as the same site")
+	}
+}
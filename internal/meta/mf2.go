@@ -0,0 +1,340 @@
+package meta
+
+import (
+	"bytes"
+	"log"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * mf2ClassPattern matches a Microformats2 class name: a root (`h-`) or property
+ * (`p-`, `u-`, `dt-`, `e-`) prefix, followed by a lowercase name with an optional
+ * vendor prefix (`h-x-entry`).
+ */
+var mf2ClassPattern = regexp.MustCompile(`^(h|p|u|dt|e)-([a-z0-9]+-)?[a-z]+(-[a-z]+)*$`)
+
+/**
+ * mf2Property is a property class of an element, split into its prefix (`p`, `u`,
+ * `dt` or `e`) and name.
+ */
+type mf2Property struct {
+	Prefix, Name string
+}
+
+/**
+ * mf2Classes returns the root types and the properties an element declares
+ * through its class attribute, in order and without duplicates.
+ */
+func mf2Classes(n *html.Node) (roots []string, props []mf2Property) {
+	for _, class := range strings.Fields(dom.Attr(n, "class")) {
+		if !mf2ClassPattern.MatchString(class) {
+			continue
+		}
+		prefix, name, _ := strings.Cut(class, "-")
+		if prefix == "h" {
+			if !slices.Contains(roots, class) {
+				roots = append(roots, class)
+			}
+			continue
+		}
+		if prop := (mf2Property{prefix, name}); !slices.Contains(props, prop) {
+			props = append(props, prop)
+		}
+	}
+	slices.Sort(roots)
+	return roots, props
+}
+
+/**
+ * mf2Item accumulates the properties and nested items of a microformat while its
+ * element is walked.
+ */
+type mf2Item struct {
+	properties map[string][]interface{}
+	children   []interface{}
+	// prefixes records the kinds of explicit properties, which disable the
+	// implied name, photo and url.
+	prefixes map[string]bool
+}
+
+/**
+ * ParseMF2 parses the Microformats2 items of an HTML tree, following the
+ * microformats2 parsing specification: top-level items, their `p-`, `u-`, `dt-`
+ * and `e-` properties, nested items (as property values or children), the
+ * implied name, photo and url, and the page rel links.
+ *
+ * Relative URLs are resolved against base (which may be nil). The value-class
+ * pattern is not supported. It returns an empty map when the tree has no
+ * microformats.
+ */
+func ParseMF2(node *html.Node, base *url.URL) map[string]interface{} {
+	result := map[string]interface{}{}
+	if node == nil {
+		return result
+	}
+	items := mf2Items(node, base)
+	if len(items) == 0 {
+		return result
+	}
+	rels := map[string][]string{}
+	relURLs := map[string]map[string]interface{}{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "a" || n.Data == "link" || n.Data == "area") {
+			if kinds := strings.Fields(strings.ToLower(dom.Attr(n, "rel"))); len(kinds) > 0 && dom.HasAttr(n, "href") {
+				href := mf2URL(dom.Attr(n, "href"), base)
+				info, found := relURLs[href]
+				if !found {
+					info = map[string]interface{}{"rels": []string{}}
+					if text := mf2Text(n); text != "" {
+						info["text"] = text
+					}
+					relURLs[href] = info
+				}
+				for _, kind := range kinds {
+					if !slices.Contains(rels[kind], href) {
+						rels[kind] = append(rels[kind], href)
+					}
+					if seen := info["rels"].([]string); !slices.Contains(seen, kind) {
+						info["rels"] = append(seen, kind)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+
+	result["items"] = items
+	result["rels"] = rels
+	result["rel-urls"] = relURLs
+	return result
+}
+
+/**
+ * mf2Items returns the outermost microformats below n, skipping the elements that
+ * are not microformats themselves.
+ */
+func mf2Items(n *html.Node, base *url.URL) []interface{} {
+	items := []interface{}{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if roots, _ := mf2Classes(c); len(roots) > 0 {
+			items = append(items, mf2ParseItem(c, roots, base))
+			continue
+		}
+		items = append(items, mf2Items(c, base)...)
+	}
+	return items
+}
+
+/**
+ * mf2ParseItem parses the microformat rooted at n, whose types are roots.
+ */
+func mf2ParseItem(n *html.Node, roots []string, base *url.URL) map[string]interface{} {
+	item := &mf2Item{properties: map[string][]interface{}{}, prefixes: map[string]bool{}}
+	item.walk(n, base)
+
+	if _, found := item.properties["name"]; !found && !item.prefixes["p"] && !item.prefixes["e"] && len(item.children) == 0 {
+		if name := mf2ImpliedName(n); name != "" {
+			item.properties["name"] = []interface{}{name}
+		}
+	}
+	if _, found := item.properties["photo"]; !found && !item.prefixes["u"] {
+		if photo := mf2Implied(n, base, map[string]string{"img": "src", "object": "data"}); photo != "" {
+			item.properties["photo"] = []interface{}{photo}
+		}
+	}
+	if _, found := item.properties["url"]; !found && !item.prefixes["u"] {
+		if link := mf2Implied(n, base, map[string]string{"a": "href", "area": "href"}); link != "" {
+			item.properties["url"] = []interface{}{link}
+		}
+	}
+
+	parsed := map[string]interface{}{"type": roots, "properties": item.properties}
+	if len(item.children) > 0 {
+		parsed["children"] = item.children
+	}
+	return parsed
+}
+
+/**
+ * walk collects the properties and nested items of the descendants of n. Nested
+ * items are not descended into: their properties belong to them.
+ */
+func (item *mf2Item) walk(n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		roots, props := mf2Classes(c)
+		if len(roots) > 0 {
+			nested := mf2ParseItem(c, roots, base)
+			if len(props) == 0 {
+				item.children = append(item.children, nested)
+				continue
+			}
+			for _, prop := range props {
+				value := maps.Clone(nested)
+				value["value"] = mf2NestedValue(prop, nested, c, base)
+				item.add(prop, value)
+			}
+			continue
+		}
+		for _, prop := range props {
+			item.add(prop, mf2PropertyValue(prop.Prefix, c, base))
+		}
+		item.walk(c, base)
+	}
+}
+
+// add appends a value to the property prop of the item.
+func (item *mf2Item) add(prop mf2Property, value interface{}) {
+	item.properties[prop.Name] = append(item.properties[prop.Name], value)
+	item.prefixes[prop.Prefix] = true
+}
+
+/**
+ * mf2NestedValue returns the plain value of a nested item used as a property: its
+ * first name (`p-`) or url (`u-`), falling back to the value the element would
+ * have without the nested item.
+ */
+func mf2NestedValue(prop mf2Property, nested map[string]interface{}, n *html.Node, base *url.URL) interface{} {
+	properties := nested["properties"].(map[string][]interface{})
+	var key string
+	switch prop.Prefix {
+	case "p":
+		key = "name"
+	case "u":
+		key = "url"
+	}
+	if values := properties[key]; key != "" && len(values) > 0 {
+		if value, ok := values[0].(string); ok {
+			return value
+		}
+	}
+	value := mf2PropertyValue(prop.Prefix, n, base)
+	if embedded, ok := value.(map[string]interface{}); ok {
+		return embedded["value"]
+	}
+	return value
+}
+
+/**
+ * mf2PropertyValue returns the value of a property with the given prefix on n:
+ * - p: the text, or the title/value/alt attribute of abbr, link, data, input, img, area;
+ * - u: the resolved URL of links, images, media and objects, else as p;
+ * - dt: the datetime attribute of time, ins and del, else as p;
+ * - e: an object with the inner html and its text value.
+ */
+func mf2PropertyValue(prefix string, n *html.Node, base *url.URL) interface{} {
+	switch prefix {
+	case "u":
+		attrs := map[string]string{"a": "href", "area": "href", "link": "href", "img": "src", "audio": "src", "video": "src", "source": "src", "iframe": "src", "object": "data"}
+		if attr, found := attrs[n.Data]; found && dom.HasAttr(n, attr) {
+			return mf2URL(dom.Attr(n, attr), base)
+		}
+		if n.Data == "video" && dom.HasAttr(n, "poster") {
+			return mf2URL(dom.Attr(n, "poster"), base)
+		}
+	case "dt":
+		if (n.Data == "time" || n.Data == "ins" || n.Data == "del") && dom.HasAttr(n, "datetime") {
+			return strings.TrimSpace(dom.Attr(n, "datetime"))
+		}
+	case "e":
+		var content bytes.Buffer
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(&content, c); err != nil {
+				log.Printf("error rendering microformat property: %v", err)
+			}
+		}
+		return map[string]interface{}{"html": strings.TrimSpace(content.String()), "value": mf2Text(n)}
+	}
+	switch {
+	case (n.Data == "abbr" || n.Data == "link") && dom.HasAttr(n, "title"):
+		return dom.Attr(n, "title")
+	case (n.Data == "data" || n.Data == "input") && dom.HasAttr(n, "value"):
+		return dom.Attr(n, "value")
+	case (n.Data == "img" || n.Data == "area") && dom.HasAttr(n, "alt") && prefix == "p":
+		return dom.Attr(n, "alt")
+	}
+	return mf2Text(n)
+}
+
+/**
+ * mf2ImpliedName returns the implied name of an item without name property: the
+ * alt or title of the element or of its only child, else its text.
+ */
+func mf2ImpliedName(n *html.Node) string {
+	for _, el := range []*html.Node{n, mf2OnlyChild(n)} {
+		switch {
+		case el == nil:
+		case (el.Data == "img" || el.Data == "area") && dom.HasAttr(el, "alt"):
+			return dom.Attr(el, "alt")
+		case el.Data == "abbr" && dom.HasAttr(el, "title"):
+			return dom.Attr(el, "title")
+		}
+	}
+	return mf2Text(n)
+}
+
+/**
+ * mf2Implied returns the resolved URL attribute (from attrs, by element name) of n
+ * or of its only child, empty when neither has one. It implies the photo and url
+ * properties.
+ */
+func mf2Implied(n *html.Node, base *url.URL, attrs map[string]string) string {
+	for _, el := range []*html.Node{n, mf2OnlyChild(n)} {
+		if el == nil {
+			continue
+		}
+		if attr, found := attrs[el.Data]; found && dom.HasAttr(el, attr) {
+			// a nested item has its own photo and url
+			if roots, _ := mf2Classes(el); el == n || len(roots) == 0 {
+				return mf2URL(dom.Attr(el, attr), base)
+			}
+		}
+	}
+	return ""
+}
+
+// mf2OnlyChild returns the only element child of n, nil if it has none or several.
+func mf2OnlyChild(n *html.Node) *html.Node {
+	var only *html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		if only != nil {
+			return nil
+		}
+		only = c
+	}
+	return only
+}
+
+// mf2Text returns the text of n with its whitespace collapsed.
+func mf2Text(n *html.Node) string {
+	return strings.Join(strings.Fields(dom.TextContent(n)), " ")
+}
+
+// mf2URL resolves a URL attribute against base, keeping it as-is when invalid.
+func mf2URL(raw string, base *url.URL) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || base == nil {
+		return raw
+	}
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parseMF2Fixture(t *testing.T, page string) map[string]interface{} {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("failed to parse page: %v", err)
+	}
+	base, _ := url.Parse("https://blog.example/posts/1")
+	// round-trip through JSON so the test compares what clients see
+	data, err := json.Marshal(ParseMF2(doc, base))
+	if err != nil {
+		t.Fatalf("failed to encode result: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return result
+}
+
+func TestParseMF2Entry(t *testing.T) {
+	result := parseMF2Fixture(t, `<html><head><link rel="author me" href="/about"></head><body>
+<article class="h-entry">
+  <h1 class="p-name">Hello   World</h1>
+  <a class="u-url" href="/posts/1">permalink</a>
+  <time class="dt-published" datetime="2024-05-01T10:00:00Z">May 1</time>
+  <div class="p-author h-card"><a class="u-url p-name" href="https://alice.example/">Alice</a></div>
+  <div class="e-content"><p>Body <b>text</b></p></div>
+  <span class="p-category">go</span><span class="p-category">web</span>
+  <div class="h-cite"><span class="p-name">A reply</span></div>
+</article></body></html>`)
+
+	items := result["items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("items = %v; want one h-entry", items)
+	}
+	entry := items[0].(map[string]interface{})
+	if types := entry["type"].([]interface{}); len(types) != 1 || types[0] != "h-entry" {
+		t.Errorf("type = %v", types)
+	}
+	props := entry["properties"].(map[string]interface{})
+	want := map[string]string{
+		"name":      "Hello World",
+		"url":       "https://blog.example/posts/1",
+		"published": "2024-05-01T10:00:00Z",
+	}
+	for key, value := range want {
+		if got := props[key].([]interface{}); got[0] != value {
+			t.Errorf("%s = %v; want %q", key, got, value)
+		}
+	}
+	if got := props["category"].([]interface{}); len(got) != 2 || got[1] != "web" {
+		t.Errorf("category = %v", got)
+	}
+	content := props["content"].([]interface{})[0].(map[string]interface{})
+	if content["html"] != "<p>Body <b>text</b></p>" || content["value"] != "Body text" {
+		t.Errorf("content = %v", content)
+	}
+	author := props["author"].([]interface{})[0].(map[string]interface{})
+	if author["value"] != "Alice" {
+		t.Errorf("author value = %v; want the card name", author["value"])
+	}
+	if card := author["properties"].(map[string]interface{}); card["url"].([]interface{})[0] != "https://alice.example/" {
+		t.Errorf("author url = %v", card["url"])
+	}
+	if children := entry["children"].([]interface{}); len(children) != 1 {
+		t.Errorf("children = %v; want the h-cite", children)
+	}
+	if rels := result["rels"].(map[string]interface{}); rels["me"].([]interface{})[0] != "https://blog.example/about" {
+		t.Errorf("rels = %v", rels)
+	}
+}
+
+func TestParseMF2ImpliedCard(t *testing.T) {
+	result := parseMF2Fixture(t, `<body><a class="h-card" href="https://bob.example/"><img src="/bob.png" alt="Bob"></a></body>`)
+
+	card := result["items"].([]interface{})[0].(map[string]interface{})
+	props := card["properties"].(map[string]interface{})
+	want := map[string]string{
+		"name":  "Bob",
+		"photo": "https://blog.example/bob.png",
+		"url":   "https://bob.example/",
+	}
+	for key, value := range want {
+		if got, ok := props[key].([]interface{}); !ok || got[0] != value {
+			t.Errorf("implied %s = %v; want %q", key, props[key], value)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+/**
+ * Package meta reads article metadata from the page.
+ */
+package meta
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/lucasew/readability-web/internal/dom"
+	"golang.org/x/net/html"
+)
+
+/**
+ * Quote is a quotation found in the article, with its source when one is cited.
+ */
+type Quote struct {
+	Quote       string `json:"quote"`
+	Attribution string `json:"attribution,omitempty"`
+}
+
+// InlineElements are phrasing elements whose text flows into the surrounding text.
+var InlineElements = []string{"a", "abbr", "b", "code", "em", "i", "mark", "s", "small", "span", "strong", "sub", "sup", "u"}
+
+/**
+ * ExtractQuotes collects every <blockquote> and <q> element under node.
+ *
+ * Quote text is whitespace-normalized and excludes nested quotes (which are
+ * reported on their own) and <cite> elements. The first <cite> inside a quote
+ * is used as its attribution, falling back to the element's cite attribute.
+ */
+func ExtractQuotes(node *html.Node) []Quote {
+	quotes := []Quote{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "blockquote" || n.Data == "q") {
+			var text strings.Builder
+			attribution := ""
+			var collect func(parent *html.Node)
+			collect = func(parent *html.Node) {
+				for c := parent.FirstChild; c != nil; c = c.NextSibling {
+					switch {
+					case c.Type == html.TextNode:
+						text.WriteString(c.Data)
+					case c.Type != html.ElementNode, c.Data == "blockquote", c.Data == "q":
+						// nested quotes are reported on their own
+					case c.Data == "cite":
+						if attribution == "" {
+							attribution = strings.Join(strings.Fields(dom.TextContent(c)), " ")
+						}
+					default:
+						collect(c)
+						// keep block boundaries from gluing words together
+						if !slices.Contains(InlineElements, c.Data) {
+							text.WriteString(" ")
+						}
+					}
+				}
+			}
+			collect(n)
+			quote := strings.Join(strings.Fields(text.String()), " ")
+			quote = strings.TrimRight(quote, " —–-")
+			if quote != "" {
+				quotes = append(quotes, Quote{
+					Quote:       quote,
+					Attribution: cmp.Or(attribution, dom.Attr(n, "cite")),
+				})
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(node)
+	return quotes
+}
@@ -0,0 +1,55 @@
+package meta
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestExtractQuotes(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []Quote
+	}{
+		{
+			name: "blockquote with cite",
+			html: `<blockquote><p>Stay hungry, stay foolish.</p><footer>— <cite>Steve Jobs</cite></footer></blockquote>`,
+			want: []Quote{{Quote: "Stay hungry, stay foolish.", Attribution: "Steve Jobs"}},
+		},
+		{
+			name: "inline q without attribution",
+			html: `<p>He said <q>hello <b>the</b>re</q> and left.</p>`,
+			want: []Quote{{Quote: "hello there"}},
+		},
+		{
+			name: "cite attribute fallback",
+			html: `<blockquote cite="https://example.com/source"><p>Quoted text</p></blockquote>`,
+			want: []Quote{{Quote: "Quoted text", Attribution: "https://example.com/source"}},
+		},
+		{
+			name: "nested blockquotes",
+			html: `<blockquote><p>Outer reply</p><blockquote><p>Inner original</p><cite>Alice</cite></blockquote><cite>Bob</cite></blockquote>`,
+			want: []Quote{
+				{Quote: "Outer reply", Attribution: "Bob"},
+				{Quote: "Inner original", Attribution: "Alice"},
+			},
+		},
+		{
+			name: "no quotes",
+			html: `<p>Nothing quoted here.</p>`,
+			want: []Quote{},
+		},
+	}
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader(tt.html))
+		if err != nil {
+			t.Fatalf("%s: failed to parse html: %v", tt.name, err)
+		}
+		if got := ExtractQuotes(doc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ExtractQuotes() = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package meta
+
+import (
+	"slices"
+	"strings"
+)
+
+/**
+ * DetectPageRefs returns the proper nouns of text that make good page references
+ * in tools like Roam Research, in order of first appearance.
+ *
+ * It uses the capitalization heuristic of BuildCooccurrenceGraph but keeps only
+ * phrases of two or more words ("Ada Lovelace", "New York"): single capitalized
+ * words are too often just sentence starts or emphasis. Phrases with capitalized
+ * function words ("How The West Was Won") are title case text and skipped too.
+ */
+func DetectPageRefs(text string) []string {
+	refs := []string{}
+	start := 0
+	ends := append(SentenceEndPattern.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, end := range ends {
+		sentence := text[start:end[0]]
+		start = end[1]
+		for _, loc := range entityPattern.FindAllStringIndex(sentence, -1) {
+			words := strings.Fields(sentence[loc[0]:loc[1]])
+			for len(words) > 0 && slices.Contains(sentenceStarters, words[0]) {
+				words = words[1:]
+			}
+			for len(words) > 0 && slices.Contains(sentenceStarters, words[len(words)-1]) {
+				words = words[:len(words)-1]
+			}
+			// function words are capitalized inside headings and titles, not names
+			if len(words) < 2 || slices.ContainsFunc(words, func(word string) bool { return slices.Contains(sentenceStarters, word) }) {
+				continue
+			}
+			if ref := strings.Join(words, " "); !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
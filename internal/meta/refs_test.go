@@ -0,0 +1,27 @@
+package meta
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectPageRefs(t *testing.T) {
+	const text = `Ada Lovelace met Charles Babbage in London. The New York Times covered it.
+When Ada Lovelace published her notes, nobody cared. It was a Monday.
+How The Engine Was Built
+This is a sentence. And I think So does everyone. They said Yes.`
+	want := []string{"Ada Lovelace", "Charles Babbage", "New York Times"}
+	if got := DetectPageRefs(text); !slices.Equal(got, want) {
+		t.Errorf("DetectPageRefs() = %q; want %q", got, want)
+	}
+}
+
+func TestDetectPageRefsFalsePositives(t *testing.T) {
+	// ordinary prose, sentence starts and title case headings mention no names
+	const text = `The results were clear. After all, nobody expected it.
+Why We Sleep And What It Means
+In the end it worked. So we went home. I Think It Works. Then we left.`
+	if got := DetectPageRefs(text); len(got) != 0 {
+		t.Errorf("DetectPageRefs() = %q; want no references", got)
+	}
+}
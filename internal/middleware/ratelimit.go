@@ -0,0 +1,185 @@
+/**
+ * Package middleware holds http.Handler wrappers shared by the handlers.
+ */
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucasew/readability-web/internal/response"
+)
+
+/**
+ * RateLimiter counts requests per client in fixed windows.
+ *
+ * Like the article cache it is per function instance, so it caps bursts hitting a warm
+ * instance rather than enforcing a global quota.
+ */
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	clients map[string]*rateWindow
+}
+
+// rateWindow is the request count of a client in the window ending at reset.
+type rateWindow struct {
+	count int
+	reset time.Time
+}
+
+/**
+ * NewRateLimiter creates a limiter allowing limit requests per window for each client.
+ * A limit of zero or less disables rate limiting.
+ */
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, clients: map[string]*rateWindow{}}
+}
+
+/**
+ * Allow records a request from client and reports whether it is within the limit,
+ * along with the requests left in the current window and when the window resets.
+ */
+func (rl *RateLimiter) Allow(client string) (remaining int, reset time.Time, ok bool) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	win, found := rl.clients[client]
+	if !found || !now.Before(win.reset) {
+		if len(rl.clients) >= 10000 {
+			rl.pruneLocked(now)
+		}
+		win = &rateWindow{reset: now.Add(rl.window)}
+		rl.clients[client] = win
+	}
+	if win.count >= rl.limit {
+		return 0, win.reset, false
+	}
+	win.count++
+	return rl.limit - win.count, win.reset, true
+}
+
+/**
+ * Peek returns the requests client has left and when its window resets, without
+ * recording a request. When client has no window running, that is the full limit
+ * and the end of the window a request would start now.
+ */
+func (rl *RateLimiter) Peek(client string) (remaining int, reset time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	win, found := rl.clients[client]
+	if !found || !now.Before(win.reset) {
+		return rl.limit, now.Add(rl.window)
+	}
+	return max(rl.limit-win.count, 0), win.reset
+}
+
+// pruneLocked drops the windows that are over, so idle clients do not pile up.
+func (rl *RateLimiter) pruneLocked(now time.Time) {
+	for client, win := range rl.clients {
+		if !now.Before(win.reset) {
+			delete(rl.clients, client)
+		}
+	}
+}
+
+/**
+ * RateLimitFromEnv returns the number of requests allowed per client per minute from
+ * RATE_LIMIT. Rate limiting is disabled when it is unset or invalid.
+ */
+func RateLimitFromEnv() int {
+	raw := os.Getenv("RATE_LIMIT")
+	if raw == "" {
+		return 0
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("invalid RATE_LIMIT %q, rate limiting disabled: %v", raw, err)
+		return 0
+	}
+	return limit
+}
+
+/**
+ * ClientIP returns the address requests are rate limited by: the first
+ * X-Forwarded-For entry (set by the Vercel edge), falling back to the peer address.
+ */
+func ClientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+/**
+ * RateLimit applies rl, rejecting clients over their quota with 429.
+ *
+ * Every response carries the quota status so clients can throttle themselves:
+ * X-RateLimit-Limit (requests per window), X-RateLimit-Remaining and
+ * X-RateLimit-Reset (Unix time when the window restarts).
+ */
+func RateLimit(rl *RateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rl.limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		remaining, reset, ok := rl.Allow(ClientIP(r))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(reset).Seconds()))))
+			response.Error(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/**
+ * RateLimitStatus is the quota of a client. Limit is zero when rate limiting is
+ * disabled, and ResetAt is then empty.
+ */
+type RateLimitStatus struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	ResetAt   string `json:"reset_at,omitempty"`
+	IP        string `json:"ip"`
+}
+
+/**
+ * RateLimitStatusHandler writes the RateLimitStatus of the calling client in rl as
+ * JSON, without using its quota, so dashboards can poll it.
+ */
+func RateLimitStatusHandler(rl *RateLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		status := RateLimitStatus{IP: ClientIP(r)}
+		if rl.limit > 0 {
+			remaining, reset := rl.Peek(status.IP)
+			status.Limit, status.Remaining, status.ResetAt = rl.limit, remaining, reset.UTC().Format(time.RFC3339)
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			log.Printf("error encoding rate limit status: %v", err)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitWindowResets(t *testing.T) {
+	rl := NewRateLimiter(1, 20*time.Millisecond)
+	if _, _, ok := rl.Allow("a"); !ok {
+		t.Fatal("first request rejected")
+	}
+	if _, _, ok := rl.Allow("a"); ok {
+		t.Fatal("second request within the window allowed")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if remaining, _, ok := rl.Allow("a"); !ok || remaining != 0 {
+		t.Errorf("request after the window: ok = %v, remaining = %d", ok, remaining)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build chromium
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const backend = "chromium"
+
+/**
+ * chromiumBinaries are the names Chromium is installed as, tried in order.
+ * CHROMIUM_PATH overrides them.
+ */
+var chromiumBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+/**
+ * chromiumPath returns the Chromium executable to run, or "" when none is installed.
+ */
+func chromiumPath() string {
+	if path := os.Getenv("CHROMIUM_PATH"); path != "" {
+		return path
+	}
+	for _, name := range chromiumBinaries {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func available() bool {
+	return chromiumPath() != ""
+}
+
+/**
+ * render prints the page with `chromium --headless --print-to-pdf`. The page goes
+ * through a temporary directory, which also holds the throwaway browser profile.
+ *
+ * The page comes from arbitrary sites, so Chromium keeps its sandbox (the service
+ * must not run as root, which Chromium refuses to sandbox) and runs no JavaScript.
+ */
+func render(ctx context.Context, title string, content []byte) ([]byte, error) {
+	html, err := page(title, content)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "article-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "article.html"), filepath.Join(dir, "article.pdf")
+	if err := os.WriteFile(in, []byte(html), 0o600); err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, chromiumPath(),
+		"--headless",
+		"--disable-gpu",
+		"--blink-settings=scriptEnabled=false",
+		"--no-pdf-header-footer",
+		"--user-data-dir="+filepath.Join(dir, "profile"),
+		"--proxy-server="+deadProxy,
+		"--proxy-bypass-list=<-loopback>",
+		"--print-to-pdf="+out,
+		"file://"+in,
+	)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("chromium: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return os.ReadFile(out)
+}
@@ -0,0 +1,46 @@
+//go:build chromium || wkhtmltopdf
+
+package pdf
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+/**
+ * deadProxy is handed to the external renderers as their proxy, so any attempt
+ * to load a remote resource fails instead of reaching the network.
+ */
+const deadProxy = "127.0.0.1:9"
+
+// pageTemplate wraps the content into the standalone page printed by the external renderers.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<meta http-equiv="Content-Security-Policy" content="script-src 'none'">
+	<title>{{.Title}}</title>
+	<style>body{font-family:serif;font-size:12pt;line-height:1.5;margin:0}pre{white-space:pre-wrap}img{max-width:100%}</style>
+</head>
+<body>
+	<h1>{{.Title}}</h1>
+	{{.Content}}
+</body>
+</html>
+`))
+
+/**
+ * page renders the standalone HTML page for the external renderers.
+ */
+func page(title string, content []byte) (string, error) {
+	var sb strings.Builder
+	err := pageTemplate.Execute(&sb, struct {
+		Title   string
+		Content template.HTML
+	}{title, template.HTML(content)})
+	if err != nil {
+		return "", fmt.Errorf("rendering page: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,418 @@
+//go:build !chromium && !wkhtmltopdf
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const backend = "native"
+
+func available() bool {
+	return true
+}
+
+// A4 page layout, in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 56
+)
+
+/**
+ * style is how a block of text is set: its font, size, leading and indentation.
+ */
+type style struct {
+	font    string // resource name of the font, see fonts
+	size    float64
+	leading float64
+	indent  float64
+	// spaceBefore is the gap left above the block.
+	spaceBefore float64
+}
+
+var (
+	titleStyle     = style{font: "F2", size: 20, leading: 26}
+	headingStyle   = style{font: "F2", size: 14, leading: 19, spaceBefore: 10}
+	paragraphStyle = style{font: "F1", size: 11, leading: 15, spaceBefore: 7}
+	quoteStyle     = style{font: "F1", size: 11, leading: 15, indent: 20, spaceBefore: 7}
+	codeStyle      = style{font: "F3", size: 9, leading: 12, indent: 10, spaceBefore: 7}
+	listStyle      = style{font: "F1", size: 11, leading: 15, indent: 14, spaceBefore: 3}
+)
+
+// fonts are the base 14 fonts used, by resource name. They need no embedding.
+var fonts = [][2]string{{"F1", "Helvetica"}, {"F2", "Helvetica-Bold"}, {"F3", "Courier"}}
+
+/**
+ * block is a paragraph-like unit of text. Preformatted blocks keep their lines.
+ */
+type block struct {
+	style style
+	text  string
+	pre   bool
+}
+
+/**
+ * render lays the title and the text of content out on A4 pages. Text is kept,
+ * with headings, lists, quotes and code set apart; images are left out.
+ */
+func render(ctx context.Context, title string, content []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content: %w", err)
+	}
+	blocks := []block{{style: titleStyle, text: title}}
+	blocks = append(blocks, collectBlocks(doc, 0)...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return writeDocument(title, layout(blocks)), nil
+}
+
+/**
+ * collectBlocks splits the content under n into blocks, in document order.
+ * depth is the nesting level of lists, used to indent their items.
+ */
+func collectBlocks(n *html.Node, depth int) []block {
+	var blocks []block
+	var inline strings.Builder
+	flush := func() {
+		if text := collapse(inline.String()); text != "" {
+			blocks = append(blocks, block{style: paragraphStyle, text: text})
+		}
+		inline.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			inline.WriteString(c.Data)
+			continue
+		}
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "script", "style", "noscript", "template", "img", "picture", "svg", "iframe":
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			flush()
+			blocks = append(blocks, block{style: headingStyle, text: collapse(text(c))})
+		case "p":
+			flush()
+			blocks = append(blocks, block{style: paragraphStyle, text: collapse(text(c))})
+		case "blockquote":
+			flush()
+			blocks = append(blocks, block{style: quoteStyle, text: collapse(text(c))})
+		case "pre":
+			flush()
+			blocks = append(blocks, block{style: codeStyle, text: strings.Trim(text(c), "\n"), pre: true})
+		case "ul", "ol":
+			flush()
+			blocks = append(blocks, listBlocks(c, depth)...)
+		case "br":
+			inline.WriteString(" ")
+		case "a", "abbr", "b", "code", "em", "i", "mark", "q", "s", "small", "span", "strong", "sub", "sup", "time", "u":
+			inline.WriteString(text(c))
+		default:
+			flush()
+			blocks = append(blocks, collectBlocks(c, depth)...)
+		}
+	}
+	flush()
+	return slices.DeleteFunc(blocks, func(b block) bool { return b.text == "" })
+}
+
+/**
+ * listBlocks returns a block per item of the list, followed by the blocks of
+ * the lists nested in it, indented one more level.
+ */
+func listBlocks(list *html.Node, depth int) []block {
+	var blocks []block
+	number := 0
+	for li := list.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.Data != "li" {
+			continue
+		}
+		bullet := "• "
+		if list.Data == "ol" {
+			number++
+			bullet = fmt.Sprintf("%d. ", number)
+		}
+		item := listStyle
+		item.indent *= float64(depth + 1)
+		blocks = append(blocks, block{style: item, text: bullet + collapse(text(li))})
+		for nested := li.FirstChild; nested != nil; nested = nested.NextSibling {
+			if nested.Type == html.ElementNode && (nested.Data == "ul" || nested.Data == "ol") {
+				blocks = append(blocks, listBlocks(nested, depth+1)...)
+			}
+		}
+	}
+	return blocks
+}
+
+/**
+ * text returns the text under n, leaving nested lists (laid out on their own) out.
+ */
+func text(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && slices.Contains([]string{"ul", "ol", "script", "style", "noscript", "template"}, n.Data):
+		case n.Type == html.ElementNode && n.Data == "br":
+			sb.WriteString("\n")
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// collapse squashes whitespace runs into single spaces.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+/**
+ * line is a line of text placed on a page, at its baseline.
+ */
+type line struct {
+	font string
+	size float64
+	x, y float64
+	text string
+}
+
+/**
+ * layout wraps the blocks into lines and spreads them over pages.
+ */
+func layout(blocks []block) [][]line {
+	pages := [][]line{nil}
+	y := float64(pageHeight - margin)
+	for i, b := range blocks {
+		if i > 0 {
+			y -= b.style.spaceBefore
+		}
+		width := pageWidth - 2*margin - b.style.indent
+		var lines []string
+		if b.pre {
+			for l := range strings.SplitSeq(b.text, "\n") {
+				lines = append(lines, wrap(strings.ReplaceAll(l, "\t", "    "), b.style, width)...)
+			}
+		} else {
+			lines = wrap(b.text, b.style, width)
+		}
+		for _, l := range lines {
+			if y-b.style.leading < margin {
+				pages = append(pages, nil)
+				y = pageHeight - margin
+			}
+			y -= b.style.leading
+			pages[len(pages)-1] = append(pages[len(pages)-1], line{
+				font: b.style.font, size: b.style.size, x: margin + b.style.indent, y: y, text: l,
+			})
+		}
+	}
+	return pages
+}
+
+/**
+ * wrap breaks text into lines no wider than width, breaking words that don't fit a line.
+ */
+func wrap(text string, s style, width float64) []string {
+	if textWidth(text, s) <= width {
+		return []string{text}
+	}
+	var lines []string
+	var current string
+	for _, word := range strings.Split(text, " ") {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if textWidth(candidate, s) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		current = word
+		for textWidth(current, s) > width {
+			runes := []rune(current)
+			cut := len(runes) - 1
+			for cut > 1 && textWidth(string(runes[:cut]), s) > width {
+				cut--
+			}
+			lines = append(lines, string(runes[:cut]))
+			current = string(runes[cut:])
+		}
+	}
+	return append(lines, current)
+}
+
+/**
+ * textWidth is the width of text set in s, in points.
+ */
+func textWidth(text string, s style) float64 {
+	total := 0
+	for _, r := range text {
+		total += glyphWidth(s.font, r)
+	}
+	return float64(total) * s.size / 1000
+}
+
+/**
+ * writeDocument serializes the laid out pages into a PDF file.
+ */
+func writeDocument(title string, pages [][]line) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// objects: 1 catalog, 2 page tree, 3 info, then the fonts, then a page and its content per page
+	firstFont := 4
+	firstPage := firstFont + len(fonts)
+	var kids, fontRefs []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	for i, font := range fonts {
+		fontRefs = append(fontRefs, fmt.Sprintf("/%s %d 0 R", font[0], firstFont+i))
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object(fmt.Sprintf("<< /Title %s /Producer (articleparser) >>", pdfString(title)))
+	for _, font := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font[1]))
+	}
+	for i, page := range pages {
+		var content strings.Builder
+		for _, l := range page {
+			fmt.Fprintf(&content, "BT /%s %g Tf %g %g Td %s Tj ET\n", l.font, l.size, l.x, l.y, pdfString(l.text))
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fontRefs, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+/**
+ * pdfString encodes text as a PDF literal string in WinAnsiEncoding. Characters
+ * the encoding lacks are replaced with "?".
+ */
+func pdfString(text string) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, r := range text {
+		c, ok := winAnsi(r)
+		if !ok {
+			c = '?'
+		}
+		switch c {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		default:
+			if c < 32 || c > 126 {
+				fmt.Fprintf(&sb, "\\%03o", c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// winAnsiExtras are the WinAnsiEncoding codes of characters outside Latin-1.
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+/**
+ * winAnsi returns the WinAnsiEncoding code of r.
+ */
+func winAnsi(r rune) (byte, bool) {
+	if c, ok := winAnsiExtras[r]; ok {
+		return c, true
+	}
+	if r == ' ' {
+		return ' ', true
+	}
+	if (r >= 32 && r < 127) || (r >= 0xa0 && r <= 0xff) {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+/**
+ * glyphWidth returns the advance width of r in the given font, in thousandths of
+ * the font size. Characters outside ASCII are approximated.
+ */
+func glyphWidth(font string, r rune) int {
+	var widths *[95]int
+	switch font {
+	case "F3":
+		return 600
+	case "F2":
+		widths = &helveticaBoldWidths
+	default:
+		widths = &helveticaWidths
+	}
+	if _, ok := winAnsi(r); !ok {
+		r = '?'
+	}
+	if r == ' ' {
+		r = ' '
+	}
+	if r < 32 || r > 126 {
+		return 556
+	}
+	return widths[r-32]
+}
+
+// helveticaWidths are the widths of the printable ASCII characters in Helvetica (from its AFM metrics).
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths are the widths of the printable ASCII characters in Helvetica-Bold.
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,85 @@
+//go:build !chromium && !wkhtmltopdf
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRenderNative(t *testing.T) {
+	content := `<h2>Section (one)</h2><p>Some <b>bold</b> text, café — “quoted”.</p>
+<ul><li>First<ul><li>Nested</li></ul></li></ul><pre>x := 1
+	y := 2</pre><img src="https://example.com/a.png"><script>alert(1)</script>`
+	doc, err := Render(context.Background(), "Title", []byte(content))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF file: %q", doc)
+	}
+	for _, want := range []string{
+		"(Title) Tj",
+		`(Section \(one\)) Tj`,
+		`(Some bold text, caf\351 \227 \223quoted\224.) Tj`,
+		`(\225 First) Tj`,
+		`(\225 Nested) Tj`,
+		"(    y := 2) Tj",
+		"/BaseFont /Helvetica-Bold",
+	} {
+		if !bytes.Contains(doc, []byte(want)) {
+			t.Errorf("PDF missing %q", want)
+		}
+	}
+	if bytes.Contains(doc, []byte("alert")) {
+		t.Error("PDF contains script text")
+	}
+
+	// every xref entry must point at its object
+	xref := bytes.LastIndex(doc, []byte("\nxref\n")) + 1
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(doc[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("empty xref table")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q; want %q", i+1, doc[offset:offset+10], want)
+		}
+	}
+	if got := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(doc); got == nil || string(got[1]) != strconv.Itoa(xref) {
+		t.Errorf("startxref does not point at the xref table")
+	}
+}
+
+func TestRenderNativePaginates(t *testing.T) {
+	content := strings.Repeat("<p>"+strings.Repeat("Long paragraph text that needs wrapping. ", 20)+"</p>", 30)
+	doc, err := Render(context.Background(), "Long", []byte(content))
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	pages := bytes.Count(doc, []byte("/Type /Page "))
+	if pages < 2 {
+		t.Errorf("got %d pages; want the content spread over several", pages)
+	}
+	if !bytes.Contains(doc, []byte("/Count "+strconv.Itoa(pages))) {
+		t.Errorf("page tree does not count the %d pages", pages)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("aaaa bbbb cccc", paragraphStyle, textWidth("aaaa bbbb", paragraphStyle))
+	if len(lines) != 2 || lines[0] != "aaaa bbbb" || lines[1] != "cccc" {
+		t.Errorf("wrap() = %q", lines)
+	}
+	long := strings.Repeat("x", 200)
+	for _, l := range wrap(long, paragraphStyle, 100) {
+		if textWidth(l, paragraphStyle) > 100 {
+			t.Errorf("line %q is wider than the limit", l)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+/**
+ * Package pdf converts article HTML into PDF documents.
+ *
+ * The backend is chosen at build time: the `chromium` tag prints the page with a
+ * headless Chromium, the `wkhtmltopdf` tag runs wkhtmltopdf, and by default a
+ * small pure-Go writer lays the text out with the PDF base fonts.
+ */
+package pdf
+
+import "context"
+
+/**
+ * Render converts the article content (an HTML fragment) titled title into a PDF document.
+ *
+ * Remote resources (images, stylesheets) are never fetched: the content comes
+ * from arbitrary pages and must not reach the network of the server.
+ */
+func Render(ctx context.Context, title string, content []byte) ([]byte, error) {
+	return render(ctx, title, content)
+}
+
+/**
+ * Available reports whether the backend compiled in can run here, e.g. whether
+ * the external program it needs is installed.
+ */
+func Available() bool {
+	return available()
+}
+
+/**
+ * Backend names the backend compiled in ("chromium", "wkhtmltopdf" or "native").
+ */
+func Backend() string {
+	return backend
+}
@@ -0,0 +1,45 @@
+//go:build wkhtmltopdf && !chromium
+
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const backend = "wkhtmltopdf"
+
+func available() bool {
+	_, err := exec.LookPath("wkhtmltopdf")
+	return err == nil
+}
+
+/**
+ * render pipes the page through `wkhtmltopdf - -`, with JavaScript and local
+ * file access disabled.
+ */
+func render(ctx context.Context, title string, content []byte) ([]byte, error) {
+	html, err := page(title, content)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "wkhtmltopdf",
+		"--quiet",
+		"--encoding", "utf-8",
+		"--disable-javascript",
+		"--disable-local-file-access",
+		"--proxy", deadProxy,
+		"-", "-",
+	)
+	cmd.Stdin = strings.NewReader(html)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("wkhtmltopdf: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
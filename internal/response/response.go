@@ -0,0 +1,60 @@
+/**
+ * Package response holds helpers for writing HTTP responses.
+ */
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+/**
+ * Buffered is an http.ResponseWriter keeping the response in memory,
+ * so formatters can render batch items side by side.
+ */
+type Buffered struct {
+	header http.Header
+	Status int
+	Body   bytes.Buffer
+}
+
+/**
+ * NewBuffered returns an empty Buffered with status 200.
+ */
+func NewBuffered() *Buffered {
+	return &Buffered{header: http.Header{}, Status: http.StatusOK}
+}
+
+func (b *Buffered) Header() http.Header { return b.header }
+
+func (b *Buffered) Write(p []byte) (int, error) { return b.Body.Write(p) }
+
+func (b *Buffered) WriteHeader(status int) { b.Status = status }
+
+/**
+ * Error writes a structured JSON error response.
+ *
+ * It enforces a consistent error format {"error": "message"} across the API
+ * and sets the correct HTTP status code and Content-Type header.
+ */
+func Error(w http.ResponseWriter, status int, msg string) {
+	ErrorCode(w, status, msg, "")
+}
+
+/**
+ * ErrorCode is Error with a machine readable code, for errors clients may
+ * want to handle specifically. The code is omitted when empty.
+ */
+func ErrorCode(w http.ResponseWriter, status int, msg, code string) {
+	body := map[string]string{"error": msg}
+	if code != "" {
+		body["code"] = code
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("error writing error response: %v", err)
+	}
+}
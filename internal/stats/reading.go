@@ -0,0 +1,39 @@
+package stats
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// wordsPerMinute is the average reading speed used for reading time estimates.
+	wordsPerMinute = 200
+)
+
+/**
+ * WordCount counts the whitespace separated words in the text of n,
+ * ignoring scripts, styles and other non-content elements.
+ */
+func WordCount(n *html.Node) int {
+	switch {
+	case n.Type == html.TextNode:
+		return len(strings.Fields(n.Data))
+	case n.Type == html.ElementNode && slices.Contains([]string{"script", "style", "noscript", "template"}, n.Data):
+		return 0
+	}
+	count := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		count += WordCount(c)
+	}
+	return count
+}
+
+/**
+ * ReadingTimeMinutes estimates how long it takes to read words at wordsPerMinute,
+ * rounded up so that any non-empty article takes at least a minute.
+ */
+func ReadingTimeMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -0,0 +1,11 @@
+package stats
+
+import "testing"
+
+func TestReadingTimeMinutes(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, 200: 1, 201: 2, 1000: 5} {
+		if got := ReadingTimeMinutes(words); got != want {
+			t.Errorf("readingTimeMinutes(%d) = %d; want %d", words, got, want)
+		}
+	}
+}
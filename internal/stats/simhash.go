@@ -0,0 +1,61 @@
+/**
+ * Package stats computes text statistics of articles.
+ */
+package stats
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+/**
+ * simHashShingle is the number of consecutive words SimHash hashes together.
+ */
+const simHashShingle = 3
+
+/**
+ * SimHash computes a 64-bit similarity hash of the words in text.
+ *
+ * The lowercased words are grouped into overlapping shingles of simHashShingle
+ * words, so word order counts, and each shingle is hashed with FNV-1a and votes
+ * on every bit; the result keeps the bits with a positive total. Texts that
+ * differ by a few words get hashes with a small Hamming distance (compare with
+ * bits.OnesCount64(a^b)), unlike cryptographic hashes where any change flips
+ * about half the bits. Texts shorter than a shingle are hashed as one.
+ */
+func SimHash(text string) uint64 {
+	var votes [64]int
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i := 0; i+simHashShingle <= max(len(tokens), simHashShingle); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens[i:min(i+simHashShingle, len(tokens))], " ")))
+		sum := mix64(h.Sum64())
+		for bit := range votes {
+			if sum&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+	var hash uint64
+	for bit, v := range votes {
+		if v > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+/**
+ * mix64 is the splitmix64 finalizer. FNV-1a alone leaves the bits of similar
+ * shingles correlated, which skews SimHash's votes.
+ */
+func mix64(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"math/bits"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const otherText = `Quantum computers exploit superposition and entanglement to explore many computational paths at once.
+Researchers at several laboratories have demonstrated processors with hundreds of qubits, although error rates remain
+high and most algorithms still require fault tolerance that is years away. Cryogenic systems keep superconducting circuits
+near absolute zero, while trapped ion machines use lasers to manipulate individual atoms suspended in electromagnetic fields.
+Software frameworks let programmers describe circuits in familiar languages and simulate them on classical hardware first.`
+
+func hamming(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func TestSimHash(t *testing.T) {
+	base := SimHash(simhashText)
+	if again := SimHash(simhashText); again != base {
+		t.Errorf("SimHash is not deterministic: %016x != %016x", base, again)
+	}
+	if got := SimHash(strings.ToUpper(simhashText)); got != base {
+		t.Errorf("SimHash should ignore case: %016x != %016x", got, base)
+	}
+
+	oneWord := strings.Replace(simhashText, "Tuesday", "Wednesday", 1)
+	if d := hamming(base, SimHash(oneWord)); d > 3 {
+		t.Errorf("texts differing by one word have Hamming distance %d; want <= 3", d)
+	}
+
+	if d := hamming(base, SimHash(otherText)); d < 16 {
+		t.Errorf("unrelated texts have Hamming distance %d; want a high distance", d)
+	}
+
+	words := strings.Fields(simhashText)
+	slices.Reverse(words)
+	if d := hamming(base, SimHash(strings.Join(words, " "))); d < 16 {
+		t.Errorf("the same words in reverse order have Hamming distance %d; want a high distance", d)
+	}
+	if SimHash("short text") == SimHash("text short") {
+		t.Error("texts shorter than a shingle should still depend on word order")
+	}
+}
+
+const simhashText = `The city council met on Tuesday evening to debate the proposed budget for the coming year.
+Residents filled the chamber, many of them carrying signs about the planned cuts to library hours and park maintenance.
+The mayor opened the session by thanking volunteers who had organized neighborhood cleanups over the summer,
+then handed the floor to the finance director, who walked through a long presentation on revenue projections.
+Property tax receipts are expected to grow slightly, she said, but rising pension obligations and the cost of
+repairing aging water pipes will consume most of that increase. Several council members questioned whether the
+projections were too optimistic given the slowdown in new construction permits. One member proposed delaying the
+purchase of two new fire trucks, a suggestion that drew loud objections from firefighters seated in the back row.
+After nearly three hours of public comment, in which teachers, shop owners, retirees and students took turns at the
+microphone, the council agreed to postpone the final vote until next month. A working group will study alternatives,
+including a modest fee on commercial parking lots and a partnership with the county to share equipment costs.
+The library director said she was cautiously hopeful that weekend hours could be preserved if the fee passes.
+Outside the building, a small crowd lingered to discuss the evening, and a food truck did brisk business until
+well after midnight, when the last of the residents finally headed home through the quiet downtown streets.`
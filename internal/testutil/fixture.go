@@ -0,0 +1,56 @@
+/**
+ * Package testutil holds helpers shared by the tests of the handlers.
+ */
+package testutil
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"testing"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"golang.org/x/net/html"
+)
+
+/**
+ * FixtureBaseURL is the address fixtures are parsed as coming from, so their
+ * relative links resolve to https://example.com/.
+ */
+var FixtureBaseURL = &url.URL{Scheme: "https", Host: "example.com", Path: "/"}
+
+/**
+ * ArticleFromFixture parses the HTML page at path (usually under testdata/) with
+ * readability, as if fetched from FixtureBaseURL. It fails the test when the file
+ * can't be read or parsed.
+ */
+func ArticleFromFixture(t *testing.T, path string) readability.Article {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", path, err)
+	}
+	parser := readability.NewParser()
+	article, err := parser.ParseDocument(doc, FixtureBaseURL)
+	if err != nil {
+		t.Fatalf("failed to extract article from fixture %s: %v", path, err)
+	}
+	return article
+}
+
+/**
+ * MustRenderHTML renders the article content like the handler does before
+ * calling a formatter, failing the test on error.
+ */
+func MustRenderHTML(t *testing.T, article readability.Article) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	if err := article.RenderHTML(buf); err != nil {
+		t.Fatalf("failed to render article: %v", err)
+	}
+	return buf
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+/**
+ * TestTransportIPv4Only checks that ipv4-only requests get their own
+ * connection pool, so they never reuse IPv6 connections of other requests.
+ */
+func TestTransportIPv4Only(t *testing.T) {
+	if transport := NewTransport(nil); transport.direct == transport.ipv4Only {
+		t.Error("NewTransport() should create two distinct connection pools")
+	}
+
+	var used []string
+	transport := &Transport{
+		direct: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			used = append(used, "direct")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+		ipv4Only: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			used = append(used, "ipv4-only")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+	for _, ctx := range []context.Context{t.Context(), WithIPv4Only(t.Context())} {
+		req, err := http.NewRequestWithContext(ctx, "GET", "http://203.0.113.10/", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+	}
+	if !slices.Equal(used, []string{"direct", "ipv4-only"}) {
+		t.Errorf("transports used = %v; want [direct ipv4-only]", used)
+	}
+}
@@ -0,0 +1,27 @@
+package transport
+
+import "io"
+
+/**
+ * CountingReader wraps an io.Reader and tracks how many bytes were read through it.
+ */
+type CountingReader struct {
+	r io.Reader
+	n int64
+}
+
+// NewCountingReader returns a CountingReader reading from r.
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// BytesRead returns the number of bytes read so far.
+func (c *CountingReader) BytesRead() int64 {
+	return c.n
+}
@@ -0,0 +1,18 @@
+package transport
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCountingReader(t *testing.T) {
+	const data = "hello, counting reader"
+	cr := NewCountingReader(strings.NewReader(data))
+	if _, err := io.Copy(io.Discard, cr); err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if got := cr.BytesRead(); got != int64(len(data)) {
+		t.Errorf("BytesRead() = %d; want %d", got, len(data))
+	}
+}
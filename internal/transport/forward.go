@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+/**
+ * hopHeaders are the headers meant for the proxy itself, which ForwardProxy
+ * doesn't pass on.
+ */
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+/**
+ * ForwardProxy is an HTTP proxy connecting through NewSafeDialer: plain http
+ * requests are forwarded and https goes through CONNECT tunnels, to public
+ * addresses only.
+ *
+ * It is what keeps a headless browser from reaching private networks: checking
+ * the page's address before handing it to the browser is not enough, as the
+ * browser follows redirects, meta refreshes and JavaScript navigation, and loads
+ * subresources, on its own. Anyone who can reach the proxy can use it, so it must
+ * only listen where the browser can reach it.
+ */
+type ForwardProxy struct {
+	// DialContext connects to the sites (NewSafeDialer when nil).
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+func (p *ForwardProxy) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if p.DialContext != nil {
+		return p.DialContext(ctx, network, address)
+	}
+	return NewSafeDialer().DialContext(ctx, network, address)
+}
+
+func (p *ForwardProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if r.URL.Scheme != "http" || r.URL.Host == "" {
+		http.Error(w, "only absolute http URLs and CONNECT are proxied", http.StatusBadRequest)
+		return
+	}
+	p.once.Do(func() {
+		p.transport = &http.Transport{DialContext: p.dial}
+	})
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, header := range hopHeaders {
+		out.Header.Del(header)
+	}
+	res, err := p.transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for _, header := range hopHeaders {
+		res.Header.Del(header)
+	}
+	for name, values := range res.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(res.StatusCode)
+	if _, err := io.Copy(w, res.Body); err != nil {
+		log.Printf("error proxying %q: %v", r.URL, err)
+	}
+}
+
+/**
+ * tunnel answers a CONNECT request, relaying the client's connection to the
+ * requested host:port.
+ */
+func (p *ForwardProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dial(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		log.Printf("error hijacking CONNECT to %q: %v", r.Host, err)
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		upstream.Close()
+		client.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	relay := func(dst io.Writer, src io.Reader) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go relay(upstream, buffered)
+	go relay(client, upstream)
+	// either side closing ends the tunnel
+	<-done
+	upstream.Close()
+	client.Close()
+	<-done
+}
@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestForwardProxy(t *testing.T) {
+	site := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Error("Proxy-Authorization was forwarded to the site")
+		}
+		_, _ = w.Write([]byte("hello from " + r.URL.Path))
+	}))
+	defer site.Close()
+	plain := httptest.NewServer(site.Config.Handler)
+	defer plain.Close()
+
+	// example.com stands for a public site, served by the test servers
+	proxy := httptest.NewServer(&ForwardProxy{DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+		target := plain.Listener.Addr().String()
+		if address == "example.com:443" {
+			target = site.Listener.Addr().String()
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, target)
+	}})
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := site.Client()
+	client.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+	for _, link := range []string{"http://example.com/forwarded", "https://example.com/tunneled"} {
+		req, _ := http.NewRequest("GET", link, nil)
+		if req.URL.Scheme == "http" {
+			// tunneled requests are the client's own business
+			req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s through the proxy: %v", link, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		if want := "hello from " + req.URL.Path; res.StatusCode != http.StatusOK || string(body) != want {
+			t.Errorf("GET %s = %d %q; want %q", link, res.StatusCode, body, want)
+		}
+	}
+}
+
+/**
+ * TestForwardProxyRefusesPrivateAddresses checks that neither forwarded requests
+ * nor tunnels reach private addresses.
+ */
+func TestForwardProxyRefusesPrivateAddresses(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("the proxy reached a loopback address")
+	}))
+	defer site.Close()
+	proxy := httptest.NewServer(&ForwardProxy{})
+	defer proxy.Close()
+
+	rec := httptest.NewRecorder()
+	(&ForwardProxy{}).ServeHTTP(rec, httptest.NewRequest("GET", site.URL+"/admin", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("forwarded request status = %d; want %d", rec.Code, http.StatusBadGateway)
+	}
+
+	req, _ := http.NewRequest(http.MethodConnect, proxy.URL, nil)
+	req.Host = site.Listener.Addr().String()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("CONNECT: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Errorf("CONNECT status = %d; want %d", res.StatusCode, http.StatusBadGateway)
+	}
+}
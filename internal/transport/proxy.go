@@ -0,0 +1,127 @@
+package transport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+/**
+ * ParseProxyURL validates an outbound proxy URL: http, https, socks5 or socks5h.
+ * The proxy resolves hostnames with both SOCKS schemes, as Tor needs.
+ */
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains([]string{"http", "https", "socks5", "socks5h"}, proxy.Scheme) || proxy.Hostname() == "" {
+		return nil, errors.New("proxy must be an http, https, socks5 or socks5h URL")
+	}
+	return proxy, nil
+}
+
+// proxyKey is the context key set by WithProxy.
+type proxyKey struct{}
+
+/**
+ * WithProxy makes the requests made with ctx go through proxy instead of the
+ * Transport's own Proxy.
+ */
+func WithProxy(ctx context.Context, proxy *url.URL) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxy)
+}
+
+/**
+ * proxy returns the proxy of ctx (see WithProxy), or the Transport's Proxy.
+ */
+func (t *Transport) proxy(ctx context.Context) *url.URL {
+	if proxy, ok := ctx.Value(proxyKey{}).(*url.URL); ok {
+		return proxy
+	}
+	return t.Proxy
+}
+
+/**
+ * proxyForRequest selects the proxy of a request.
+ *
+ * As the proxy connects to the site in our stead, NewSafeDialer can't check it.
+ * With socks5h the proxy is trusted to refuse private addresses itself: the host
+ * is not resolved here, so lookups don't leak outside of the proxy (as Tor needs)
+ * and hosts only the proxy can resolve work. With the other schemes the host is
+ * resolved and checked first, which is best effort: the proxy resolves it again,
+ * and DNS rebinding can answer differently.
+ */
+func (t *Transport) proxyForRequest(req *http.Request) (*url.URL, error) {
+	proxy := t.proxy(req.Context())
+	if proxy == nil || proxy.Scheme == "socks5h" {
+		return proxy, nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(req.Context(), "ip", req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(ips, IsBlockedIP) {
+		return nil, errors.New("refusing to connect to private network address")
+	}
+	return proxy, nil
+}
+
+/**
+ * proxyAddress returns the host:port http.Transport dials for proxy,
+ * filling in the default port of its scheme.
+ */
+func proxyAddress(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxy.Scheme]
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+/**
+ * SignProxy returns the signature letting requests use proxy until expires:
+ * the hex HMAC-SHA256 under key of the proxy URL and the Unix time it expires at.
+ */
+func SignProxy(key, proxy string, expires time.Time) string {
+	return hex.EncodeToString(proxyMAC(key, proxy, strconv.FormatInt(expires.Unix(), 10)))
+}
+
+/**
+ * VerifyProxySignature checks the signature of a request's proxy (see SignProxy),
+ * with expires the Unix time it was signed to expire at. Without a key no
+ * signature is valid, as letting clients choose the proxy lets them make the
+ * service connect anywhere.
+ */
+func VerifyProxySignature(key, proxy, expires, signature string, now time.Time) error {
+	if key == "" {
+		return errors.New("proxy is not enabled on this server")
+	}
+	deadline, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return errors.New("proxy-expires must be a Unix time")
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, proxyMAC(key, proxy, expires)) {
+		return errors.New("invalid proxy-signature")
+	}
+	if now.Unix() > deadline {
+		return errors.New("proxy-signature expired")
+	}
+	return nil
+}
+
+// proxyMAC is the HMAC SignProxy and VerifyProxySignature compute.
+func proxyMAC(key, proxy, expires string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(proxy + "\n" + expires))
+	return mac.Sum(nil)
+}
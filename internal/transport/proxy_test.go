@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyProxySignature(t *testing.T) {
+	const proxy = "socks5h://127.0.0.1:9050"
+	now := time.Unix(1_800_000_000, 0)
+	expires := now.Add(time.Hour)
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	tests := []struct {
+		name, key, proxy, expires, signature string
+		now                                  time.Time
+		wantErr                              bool
+	}{
+		{"valid", "secret", proxy, unix, SignProxy("secret", proxy, expires), now, false},
+		{"no key", "", proxy, unix, SignProxy("secret", proxy, expires), now, true},
+		{"other key", "secret", proxy, unix, SignProxy("other", proxy, expires), now, true},
+		{"other proxy", "secret", "socks5h://203.0.113.10:9050", unix, SignProxy("secret", proxy, expires), now, true},
+		{"extended expiry", "secret", proxy, strconv.FormatInt(expires.Add(time.Hour).Unix(), 10), SignProxy("secret", proxy, expires), now, true},
+		{"expired", "secret", proxy, unix, SignProxy("secret", proxy, expires), expires.Add(time.Second), true},
+		{"no expiry", "secret", proxy, "", SignProxy("secret", proxy, expires), now, true},
+		{"not hex", "secret", proxy, unix, "zz", now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyProxySignature(tt.key, tt.proxy, tt.expires, tt.signature, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyProxySignature() error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+/**
+ * TestProxyForRequestSocks5h checks that socks5h proxies get the hostname
+ * unresolved, while the other schemes refuse hosts resolving to private addresses.
+ */
+func TestProxyForRequestSocks5h(t *testing.T) {
+	for _, tt := range []struct {
+		proxy   string
+		wantErr bool
+	}{
+		{"socks5h://203.0.113.1:9050", false},
+		{"socks5://203.0.113.1:9050", true},
+		{"http://203.0.113.1:3128", true},
+	} {
+		proxy, _ := url.Parse(tt.proxy)
+		req, err := http.NewRequestWithContext(WithProxy(t.Context(), proxy), "GET", "http://localhost/", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		got, err := NewTransport(nil).proxyForRequest(req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: proxyForRequest() error = %v; wantErr %v", tt.proxy, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != proxy {
+			t.Errorf("%s: proxyForRequest() = %v", tt.proxy, got)
+		}
+	}
+}
+
+func TestParseProxyURL(t *testing.T) {
+	for raw, wantErr := range map[string]bool{
+		"socks5h://127.0.0.1:9050":  false,
+		"http://proxy.example:3128": false,
+		"ftp://proxy.example":       true,
+		"http://":                   true,
+	} {
+		if _, err := ParseProxyURL(raw); (err != nil) != wantErr {
+			t.Errorf("ParseProxyURL(%q) error = %v; wantErr %v", raw, err, wantErr)
+		}
+	}
+}